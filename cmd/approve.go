@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+)
+
+type approveResponse struct {
+	id uint64
+}
+
+type approveCommand struct {
+	id uint64
+}
+
+func (r *approveCommand) Execute(u User) (Response, error) {
+	if err := u.Approve(r.id); err != nil {
+		return nil, err
+	}
+
+	return &approveResponse{
+		id: r.id,
+	}, nil
+}
+
+func (r *approveCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Approve{
+			Approve: &rpc.ApproveRequest{
+				Version: &version,
+				Id:      &r.id,
+			},
+		},
+	}, nil
+}
+
+func readApproveCommand(param []string) (Command, error) {
+	c := &approveCommand{}
+	if err := ReadPattern(param, &c.id); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func buildApproveCommand(r *rpc.ApproveRequest) (Command, error) {
+	if r.Id == nil {
+		return nil, ErrInvalidRPCRequest
+	}
+
+	return &approveCommand{
+		id: r.GetId(),
+	}, nil
+}
+
+var approve = command{
+	help: "send a message which is held because its recipient demands too much proof-of-work.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{KeyNatural},
+			help: "send the message in the Outbox with the given id anyway, however long its proof-of-work takes.",
+			read: readApproveCommand,
+		},
+	},
+}
+
+// String writes the approve response as a string.
+func (r *approveResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *approveResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Approve{
+			Approve: &rpc.ApproveReply{
+				Version: &version,
+				Id:      &r.id,
+			},
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+	"github.com/DanielKrawisz/bmagent/powmgr"
+)
+
+type benchmarkResponse struct {
+	calibration *powmgr.Calibration
+}
+
+type benchmarkCommand struct{}
+
+func (r *benchmarkCommand) Execute(u User) (Response, error) {
+	c, err := u.Benchmark()
+	if err != nil {
+		return nil, err
+	}
+
+	return &benchmarkResponse{
+		calibration: c,
+	}, nil
+}
+
+func (r *benchmarkCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Benchmark{
+			Benchmark: &rpc.BenchmarkRequest{
+				Version: &version,
+			},
+		},
+	}, nil
+}
+
+func readBenchmarkCommand(param []string) (Command, error) {
+	if len(param) != 0 {
+		return nil, &ErrInvalidNumberOfParameters{
+			params:   param,
+			expected: 0,
+		}
+	}
+
+	return &benchmarkCommand{}, nil
+}
+
+func buildBenchmarkCommand(r *rpc.BenchmarkRequest) (Command, error) {
+	return &benchmarkCommand{}, nil
+}
+
+var benchmark = command{
+	help: "measure how fast proof-of-work is done.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{},
+			help: "measure the hashrate with different numbers of threads, which takes a few seconds, use it for estimates from now on and recommend a number of threads.",
+			read: readBenchmarkCommand,
+		},
+	},
+}
+
+// String writes the benchmark response as a string.
+func (r *benchmarkResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *benchmarkResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	rates := make([]*rpc.PowRate, len(r.calibration.Rates))
+	for i, rate := range r.calibration.Rates {
+		threads := uint32(rate.Threads)
+		hashrate := rate.Hashrate
+		rates[i] = &rpc.PowRate{
+			Version:  &version,
+			Threads:  &threads,
+			Hashrate: &hashrate,
+		}
+	}
+	recommended := uint32(r.calibration.Recommend())
+
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Benchmark{
+			Benchmark: &rpc.BenchmarkReply{
+				Version:            &version,
+				Rates:              rates,
+				RecommendedThreads: &recommended,
+			},
+		},
+	}
+}
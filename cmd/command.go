@@ -17,12 +17,21 @@ import (
 
 // Commands is the list of commands.
 var Commands = []string{
+	"approve",
+	"benchmark",
 	"deletemessages",
 	"getmessages",
 	"help",
 	"listaddresses",
 	"newaddress",
+	"powpolicy",
+	"powqueue",
+	"quote",
+	"rebuildindex",
+	"search",
+	"senddraft",
 	"sendmessage",
+	"subscribe",
 }
 
 // The following commands are partially implemented.
@@ -34,7 +43,6 @@ var Commands = []string{
 // Unimplemented is the list of unimplemented commands.
 var Unimplemented = []string{
 	"deletemessages",
-	"getmessages",
 	"listaddresses",
 	"newaddress",
 	"sendmessage",
@@ -105,10 +113,18 @@ func init() {
 	commands["help"] = help
 	commands["newaddress"] = newAddress
 	commands["listaddresses"] = listAddresses
-	commands["getmessages"] = unimplementedStub
+	commands["getmessages"] = getMessages
+	commands["search"] = search
+	commands["rebuildindex"] = rebuildIndex
+	commands["senddraft"] = sendDraft
+	commands["quote"] = quote
+	commands["powqueue"] = powQueue
+	commands["powpolicy"] = powPolicy
+	commands["benchmark"] = benchmark
+	commands["approve"] = approve
+	commands["subscribe"] = subscribe
 	commands["deletemessages"] = unimplementedStub
 	commands["sendmessage"] = unimplementedStub
-	commands["subscribe"] = unimplementedStub
 
 	// Ensure that Commands is in alphabetical order and every element
 	// in Commands is in commands.
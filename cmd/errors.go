@@ -26,6 +26,10 @@ var (
 
 	// ErrInvalidRPCRequest is returned when an rpc request is invalid.
 	ErrInvalidRPCRequest = errors.New("Invalid rpc request.")
+
+	// ErrUnknownSetting is returned when the user tries to change a
+	// setting that does not exist.
+	ErrUnknownSetting = errors.New("Unknown setting.")
 )
 
 // ErrUnknownCommand implements the error interface
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/format"
+)
+
+type getMessagesResponse struct {
+	messages []*email.Bmail
+}
+
+type getMessagesCommand struct {
+	folder   string
+	keywords []string
+}
+
+func (r *getMessagesCommand) Execute(u User) (Response, error) {
+	messages, err := u.GetMessages(r.folder, r.keywords)
+	if err != nil {
+		return nil, err
+	}
+
+	return &getMessagesResponse{
+		messages: messages,
+	}, nil
+}
+
+func (r *getMessagesCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Getmessages{
+			Getmessages: &rpc.GetMessagesRequest{
+				Version:  &version,
+				Folder:   &r.folder,
+				Keywords: r.keywords,
+			},
+		},
+	}, nil
+}
+
+func readGetMessagesCommand(param []string) (Command, error) {
+	strs := make([]string, len(param))
+	for i := range param {
+		err := ReadPattern(param[i:i+1], &strs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c := &getMessagesCommand{}
+	if len(strs) > 0 {
+		c.folder = strs[0]
+		c.keywords = strs[1:]
+	}
+
+	return c, nil
+}
+
+func buildGetMessagesCommand(r *rpc.GetMessagesRequest) (Command, error) {
+	return &getMessagesCommand{
+		folder:   r.GetFolder(),
+		keywords: r.GetKeywords(),
+	}, nil
+}
+
+var getMessages = command{
+	help: "list messages along with their keywords.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{},
+			help: "list the messages in every folder.",
+			read: readGetMessagesCommand,
+		},
+		Pattern{
+			key:  []Key{KeyString},
+			help: "list the messages in the given folder.",
+			read: readGetMessagesCommand,
+		},
+		Pattern{
+			key:  []Key{KeyString, KeyString, KeyRepeated},
+			help: "list the messages in the given folder which have all the given keywords. An empty folder name means every folder.",
+			read: readGetMessagesCommand,
+		},
+	},
+}
+
+// String writes the getmessages response as a string.
+func (r *getMessagesResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *getMessagesResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	messages := make([]*rpc.Bitmessage, len(r.messages))
+	for i, bm := range r.messages {
+		messages[i] = BitmessageToRPC(bm)
+	}
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Getmessages{
+			Getmessages: &rpc.GetMessagesReply{
+				Version:  &version,
+				Messages: messages,
+			},
+		},
+	}
+}
+
+// BitmessageToRPC converts an email.Bmail to an rpc Bitmessage.
+func BitmessageToRPC(bm *email.Bmail) *rpc.Bitmessage {
+	version := uint32(1)
+	msg := &rpc.Bitmessage{
+		Version:   &version,
+		Sender:    &bm.From,
+		Recipient: &bm.To,
+	}
+
+	t := rpc.BitmessageType_BITMESSAGE_MESSAGE
+	if bm.From == email.Broadcast || bm.To == email.Broadcast {
+		t = rpc.BitmessageType_BITMESSAGE_BROADCAST
+	}
+	msg.Type = &t
+
+	if bm.ImapData != nil {
+		id := strconv.FormatUint(bm.ImapData.UID, 10)
+		msg.Id = &id
+		msg.Keywords = bm.ImapData.Keywords
+
+		if bm.ImapData.Mailbox != nil {
+			folder := bm.ImapData.Mailbox.Name()
+			msg.Folder = &folder
+		}
+	}
+
+	var subject, body string
+	var attachments []string
+	switch c := bm.Content.(type) {
+	case *format.Encoding1:
+		body = c.Body
+	case *format.Encoding2:
+		subject = c.Subject
+		body = c.Body
+	case *email.Extended:
+		subject = c.Subject
+		body = c.Body
+		for _, part := range c.Parts {
+			if part.Name != "" {
+				attachments = append(attachments, part.Name)
+			}
+		}
+	}
+	msg.Body = &rpc.Bitmessage_Text{
+		Text: &rpc.TextBitmessage{
+			Version:     &version,
+			Subject:     &subject,
+			Contents:    &body,
+			Attachments: attachments,
+		},
+	}
+
+	return msg
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+	"github.com/DanielKrawisz/bmagent/powmgr"
+)
+
+type powPolicyResponse struct {
+	policy powmgr.Policy
+}
+
+// powPolicyCommand changes the settings that are not nil, and the windows
+// if setWindows is true.
+type powPolicyCommand struct {
+	dutyCycle      *uint32
+	pauseOnBattery *bool
+	maxLoad        *float64
+	windows        []powmgr.Window
+	setWindows     bool
+	longOrder      *time.Duration
+}
+
+func (r *powPolicyCommand) Execute(u User) (Response, error) {
+	policy := u.PowPolicy()
+	changed := false
+	if r.dutyCycle != nil {
+		policy.DutyCycle = *r.dutyCycle
+		changed = true
+	}
+	if r.pauseOnBattery != nil {
+		policy.PauseOnBattery = *r.pauseOnBattery
+		changed = true
+	}
+	if r.maxLoad != nil {
+		policy.MaxLoad = *r.maxLoad
+		changed = true
+	}
+	if r.setWindows {
+		policy.Windows = r.windows
+		changed = true
+	}
+	if r.longOrder != nil {
+		policy.LongOrder = *r.longOrder
+		changed = true
+	}
+
+	if changed {
+		if err := u.SetPowPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+
+	return &powPolicyResponse{
+		policy: policy,
+	}, nil
+}
+
+func (r *powPolicyCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	request := &rpc.PowPolicyRequest{
+		Version:        &version,
+		DutyCycle:      r.dutyCycle,
+		PauseOnBattery: r.pauseOnBattery,
+		MaxLoad:        r.maxLoad,
+	}
+	if r.setWindows {
+		request.SetWindows = &r.setWindows
+		request.Windows = make([]string, len(r.windows))
+		for i, w := range r.windows {
+			request.Windows[i] = w.String()
+		}
+	}
+	if r.longOrder != nil {
+		longOrder := uint64(*r.longOrder / time.Second)
+		request.LongOrder = &longOrder
+	}
+
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Powpolicy{
+			Powpolicy: request,
+		},
+	}, nil
+}
+
+func readPowPolicyCommand(param []string) (Command, error) {
+	c := &powPolicyCommand{}
+	if len(param) == 0 {
+		return c, nil
+	}
+
+	var setting, value string
+	if err := ReadPattern(param, &setting, &value); err != nil {
+		return nil, err
+	}
+
+	switch setting {
+	default:
+		return nil, ErrUnknownSetting
+	case "dutycycle":
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		dutyCycle := uint32(n)
+		c.dutyCycle = &dutyCycle
+	case "pauseonbattery":
+		var pause bool
+		if err := ReadPattern([]string{value}, &pause); err != nil {
+			return nil, err
+		}
+		c.pauseOnBattery = &pause
+	case "maxload":
+		maxLoad, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		c.maxLoad = &maxLoad
+	case "windows":
+		windows, err := readWindows(strings.Fields(value))
+		if err != nil {
+			return nil, err
+		}
+		c.windows = windows
+		c.setWindows = true
+	case "longorder":
+		longOrder, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		c.longOrder = &longOrder
+	}
+
+	return c, nil
+}
+
+func readWindows(s []string) ([]powmgr.Window, error) {
+	windows := make([]powmgr.Window, len(s))
+	for i, w := range s {
+		var err error
+		windows[i], err = powmgr.ParseWindow(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return windows, nil
+}
+
+func buildPowPolicyCommand(r *rpc.PowPolicyRequest) (Command, error) {
+	c := &powPolicyCommand{
+		dutyCycle:      r.DutyCycle,
+		pauseOnBattery: r.PauseOnBattery,
+		maxLoad:        r.MaxLoad,
+		setWindows:     r.GetSetWindows(),
+	}
+
+	if c.setWindows {
+		var err error
+		c.windows, err = readWindows(r.Windows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.LongOrder != nil {
+		longOrder := time.Duration(r.GetLongOrder()) * time.Second
+		c.longOrder = &longOrder
+	}
+
+	return c, nil
+}
+
+var powPolicy = command{
+	help: "show or change the limits on how much of the computer proof-of-work uses.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{},
+			help: "show the limits on proof-of-work.",
+			read: readPowPolicyCommand,
+		},
+		Pattern{
+			key: []Key{KeyString, KeyString},
+			help: "change one limit on proof-of-work. The settings are \"dutycycle\", the percentage of the time that work is done; " +
+				"\"pauseonbattery\", \"true\" or \"false\"; \"maxload\", the load average above which work is paused, or \"0\" for no limit; " +
+				"\"windows\", the times of day in which low-priority work is started, such as \"22:00-07:00 12:00-13:00\", or \"\" for any time; " +
+				"and \"longorder\", how long work must be expected to take to be given low priority, such as \"10m\", or \"0\" for never.",
+			read: readPowPolicyCommand,
+		},
+	},
+}
+
+// String writes the powpolicy response as a string.
+func (r *powPolicyResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *powPolicyResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	longOrder := uint64(r.policy.LongOrder / time.Second)
+	windows := make([]string, len(r.policy.Windows))
+	for i, w := range r.policy.Windows {
+		windows[i] = w.String()
+	}
+
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Powpolicy{
+			Powpolicy: &rpc.PowPolicyReply{
+				Version:        &version,
+				DutyCycle:      &r.policy.DutyCycle,
+				PauseOnBattery: &r.policy.PauseOnBattery,
+				MaxLoad:        &r.policy.MaxLoad,
+				Windows:        windows,
+				LongOrder:      &longOrder,
+			},
+		},
+	}
+}
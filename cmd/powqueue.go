@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+	"github.com/DanielKrawisz/bmagent/powmgr"
+)
+
+type powQueueResponse struct {
+	orders []powmgr.OrderInfo
+}
+
+type powQueueCommand struct{}
+
+func (r *powQueueCommand) Execute(u User) (Response, error) {
+	return &powQueueResponse{
+		orders: u.PowQueue(),
+	}, nil
+}
+
+func (r *powQueueCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Powqueue{
+			Powqueue: &rpc.PowQueueRequest{
+				Version: &version,
+			},
+		},
+	}, nil
+}
+
+func readPowQueueCommand(param []string) (Command, error) {
+	if len(param) != 0 {
+		return nil, &ErrInvalidNumberOfParameters{
+			params:   param,
+			expected: 0,
+		}
+	}
+
+	return &powQueueCommand{}, nil
+}
+
+func buildPowQueueCommand(r *rpc.PowQueueRequest) (Command, error) {
+	return &powQueueCommand{}, nil
+}
+
+var powQueue = command{
+	help: "show the proof-of-work queue.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{},
+			help: "list the orders for proof-of-work in the order in which they will be run, with the estimated time until each is done.",
+			read: readPowQueueCommand,
+		},
+	},
+}
+
+// String writes the powqueue response as a string.
+func (r *powQueueResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *powQueueResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	orders := make([]*rpc.PowOrder, len(r.orders))
+	for i, o := range r.orders {
+		position := uint32(o.Position)
+		orderType := o.Type.String()
+		priority := o.Priority.String()
+		target := uint64(o.Target)
+		estimate := uint64(o.Estimate / time.Second)
+		elapsed := uint64(o.Elapsed / time.Second)
+		orders[i] = &rpc.PowOrder{
+			Version:  &version,
+			Position: &position,
+			Type:     &orderType,
+			Priority: &priority,
+			Target:   &target,
+			Estimate: &estimate,
+			Elapsed:  &elapsed,
+		}
+	}
+
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Powqueue{
+			Powqueue: &rpc.PowQueueReply{
+				Version: &version,
+				Orders:  orders,
+			},
+		},
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// powworker calculates proof-of-work for instances of bmagent that are
+// run with --powworker, so that a faster computer can do the work for
+// slower ones.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/DanielKrawisz/bmagent/powmgr"
+	"github.com/DanielKrawisz/bmagent/powmgr/rpc"
+)
+
+// isLoopback returns whether the address only accepts connections from this
+// computer.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func main() {
+	listen := flag.String("listen", net.JoinHostPort("localhost", strconv.Itoa(powmgr.DefaultWorkerPort)),
+		"interface/port on which to listen for work; any other interface than localhost requires -cert")
+	threads := flag.Int("threads", runtime.NumCPU(), "number of threads with which to calculate proof-of-work")
+	jobs := flag.Int("jobs", 1, "number of requests to work on at once; more are refused")
+	cert := flag.String("cert", "", "file containing the certificate; connections are not encrypted without one")
+	key := flag.String("key", "", "file containing the certificate key")
+	flag.Parse()
+
+	// Anyone who can reach the worker can use it, so it must not be
+	// reachable from other computers over plaintext.
+	if *cert == "" && !isLoopback(*listen) {
+		fmt.Fprintf(os.Stderr, "Refusing to listen on %s without TLS; give -cert and -key.\n", *listen)
+		os.Exit(1)
+	}
+
+	var opts []grpc.ServerOption
+	if *cert != "" {
+		creds, err := credentials.NewServerTLSFromFile(*cert, *key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load TLS credentials: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to listen on %s: %v\n", *listen, err)
+		os.Exit(1)
+	}
+
+	server := grpc.NewServer(opts...)
+	rpc.RegisterPowWorkerServer(server, powmgr.NewWorker(*threads, *jobs))
+
+	fmt.Printf("Calculating proof-of-work with %d threads for up to %d requests on %s.\n",
+		*threads, *jobs, lis.Addr())
+	if err := server.Serve(lis); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+// Quote is an estimate of the proof-of-work required to send a message.
+type Quote struct {
+	TTL time.Duration
+
+	// Size is the estimated size of each object to be sent.
+	Size uint64
+
+	// Hashrate is the number of hashes per second that are computed.
+	Hashrate float64
+
+	// Recipients are the estimates for each object to be sent, one for
+	// each recipient.
+	Recipients []RecipientQuote
+
+	// Time is how long the proof-of-work for every recipient whose pubkey
+	// is known is expected to take.
+	Time time.Duration
+}
+
+// RecipientQuote is an estimate of the proof-of-work required to send a
+// message to one recipient.
+type RecipientQuote struct {
+	Address string
+
+	// Known is whether the pubkey of the recipient is known. If not, the
+	// difficulty it demands is unknown and so are Target and Time.
+	Known bool
+
+	Target pow.Target
+
+	// Time is how long the proof-of-work is expected to take.
+	Time time.Duration
+}
+
+type quoteResponse struct {
+	quote *Quote
+}
+
+type quoteCommand struct {
+	id  uint64
+	ttl time.Duration // Zero for the default.
+}
+
+func (r *quoteCommand) Execute(u User) (Response, error) {
+	q, err := u.Quote(r.id, r.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quoteResponse{
+		quote: q,
+	}, nil
+}
+
+func (r *quoteCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	request := &rpc.QuoteRequest{
+		Version: &version,
+		Id:      &r.id,
+	}
+	if r.ttl != 0 {
+		ttl := email.FormatTTL(r.ttl)
+		request.Ttl = &ttl
+	}
+
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Quote{
+			Quote: request,
+		},
+	}, nil
+}
+
+func readQuoteCommand(param []string) (Command, error) {
+	c := &quoteCommand{}
+	var ttl string
+	var err error
+	if len(param) == 2 {
+		err = ReadPattern(param, &c.id, &ttl)
+	} else {
+		err = ReadPattern(param, &c.id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl != "" {
+		c.ttl, err = email.ParseTTL(ttl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func buildQuoteCommand(r *rpc.QuoteRequest) (Command, error) {
+	if r.Id == nil {
+		return nil, ErrInvalidRPCRequest
+	}
+
+	c := &quoteCommand{
+		id: r.GetId(),
+	}
+
+	if r.Ttl != nil {
+		var err error
+		c.ttl, err = email.ParseTTL(r.GetTtl())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+var quote = command{
+	help: "estimate the proof-of-work needed to send a draft.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{KeyNatural},
+			help: "estimate the proof-of-work for the draft with the given id.",
+			read: readQuoteCommand,
+		},
+		Pattern{
+			key:  []Key{KeyNatural, KeyString},
+			help: "estimate the proof-of-work for the draft with the given id if it were to live in the network for the given time, such as \"4d\".",
+			read: readQuoteCommand,
+		},
+	},
+}
+
+// String writes the quote response as a string.
+func (r *quoteResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *quoteResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	ttl := uint64(r.quote.TTL / time.Second)
+	estimate := uint64(r.quote.Time / time.Second)
+
+	recipients := make([]*rpc.RecipientQuote, len(r.quote.Recipients))
+	for i, q := range r.quote.Recipients {
+		address := q.Address
+		known := q.Known
+		recipients[i] = &rpc.RecipientQuote{
+			Version: &version,
+			Address: &address,
+			Known:   &known,
+		}
+		if known {
+			target := uint64(q.Target)
+			estimate := uint64(q.Time / time.Second)
+			recipients[i].Target = &target
+			recipients[i].Time = &estimate
+		}
+	}
+
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Quote{
+			Quote: &rpc.QuoteReply{
+				Version:    &version,
+				Ttl:        &ttl,
+				Size:       &r.quote.Size,
+				Hashrate:   &r.quote.Hashrate,
+				Time:       &estimate,
+				Recipients: recipients,
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+)
+
+type rebuildIndexResponse struct {
+	messages uint32
+}
+
+type rebuildIndexCommand struct{}
+
+func (r *rebuildIndexCommand) Execute(u User) (Response, error) {
+	n, err := u.RebuildIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rebuildIndexResponse{
+		messages: n,
+	}, nil
+}
+
+func (r *rebuildIndexCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Rebuildindex{
+			Rebuildindex: &rpc.RebuildIndexRequest{
+				Version: &version,
+			},
+		},
+	}, nil
+}
+
+func readRebuildIndexCommand(param []string) (Command, error) {
+	if len(param) != 0 {
+		return nil, &ErrInvalidNumberOfParameters{
+			params:   param,
+			expected: 0,
+		}
+	}
+
+	return &rebuildIndexCommand{}, nil
+}
+
+func buildRebuildIndexCommand(r *rpc.RebuildIndexRequest) (Command, error) {
+	return &rebuildIndexCommand{}, nil
+}
+
+var rebuildIndex = command{
+	help: "rebuild the full-text search index.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{},
+			help: "rebuild the full-text search index from the messages in every folder.",
+			read: readRebuildIndexCommand,
+		},
+	},
+}
+
+// String writes the rebuildindex response as a string.
+func (r *rebuildIndexResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *rebuildIndexResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Rebuildindex{
+			Rebuildindex: &rpc.RebuildIndexReply{
+				Version:  &version,
+				Messages: &r.messages,
+			},
+		},
+	}
+}
@@ -33,6 +33,26 @@ func BuildCommand(r *pb.BMRPCRequest) (Command, error) {
 		return buildNewAddressCommand(r.Newaddress)
 	case *pb.BMRPCRequest_Listaddresses:
 		return buildListAddressesCommand(r.Listaddresses)
+	case *pb.BMRPCRequest_Getmessages:
+		return buildGetMessagesCommand(r.Getmessages)
+	case *pb.BMRPCRequest_Search:
+		return buildSearchCommand(r.Search)
+	case *pb.BMRPCRequest_Rebuildindex:
+		return buildRebuildIndexCommand(r.Rebuildindex)
+	case *pb.BMRPCRequest_Senddraft:
+		return buildSendDraftCommand(r.Senddraft)
+	case *pb.BMRPCRequest_Quote:
+		return buildQuoteCommand(r.Quote)
+	case *pb.BMRPCRequest_Powqueue:
+		return buildPowQueueCommand(r.Powqueue)
+	case *pb.BMRPCRequest_Powpolicy:
+		return buildPowPolicyCommand(r.Powpolicy)
+	case *pb.BMRPCRequest_Benchmark:
+		return buildBenchmarkCommand(r.Benchmark)
+	case *pb.BMRPCRequest_Approve:
+		return buildApproveCommand(r.Approve)
+	case *pb.BMRPCRequest_Subscribe:
+		return buildSubscribeCommand(r.Subscribe)
 	}
 }
 
@@ -5,6 +5,8 @@ package rpc
 import (
 	"bytes"
 	"fmt"
+	"strings"
+	"time"
 )
 
 func Message(r *BMRPCReply) string {
@@ -23,6 +25,26 @@ func Message(r *BMRPCReply) string {
 		return x.Listaddresses.Message()
 	case *BMRPCReply_HelpReply:
 		return x.HelpReply.Message()
+	case *BMRPCReply_Getmessages:
+		return x.Getmessages.Message()
+	case *BMRPCReply_Search:
+		return x.Search.Message()
+	case *BMRPCReply_Rebuildindex:
+		return x.Rebuildindex.Message()
+	case *BMRPCReply_Senddraft:
+		return x.Senddraft.Message()
+	case *BMRPCReply_Quote:
+		return x.Quote.Message()
+	case *BMRPCReply_Powqueue:
+		return x.Powqueue.Message()
+	case *BMRPCReply_Powpolicy:
+		return x.Powpolicy.Message()
+	case *BMRPCReply_Benchmark:
+		return x.Benchmark.Message()
+	case *BMRPCReply_Approve:
+		return x.Approve.Message()
+	case *BMRPCReply_Subscribe:
+		return x.Subscribe.Message()
 	}
 }
 
@@ -79,6 +101,197 @@ func (r *ListAddressesReply) Message() string {
 	return b.String()
 }
 
+func (m *Bitmessage) Message() string {
+	var folder, keywords, subject, attachments string
+	if m.Folder != nil {
+		folder = fmt.Sprintf("%s/", *m.Folder)
+	}
+	if len(m.Keywords) > 0 {
+		keywords = fmt.Sprintf(" [%s]", strings.Join(m.Keywords, " "))
+	}
+	if text := m.GetText(); text != nil && text.Subject != nil {
+		subject = fmt.Sprintf(" \"%s\"", *text.Subject)
+	}
+	if text := m.GetText(); text != nil && len(text.Attachments) > 0 {
+		attachments = fmt.Sprintf(" (attached: %s)", strings.Join(text.Attachments, ", "))
+	}
+	return fmt.Sprintf("%s%s %s -> %s%s%s%s", folder, m.GetId(),
+		m.GetSender(), m.GetRecipient(), subject, attachments, keywords)
+}
+
+func (r *GetMessagesReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	var b bytes.Buffer
+	for i := 0; i < len(r.Messages); i++ {
+		if i != 0 {
+			b.Write([]byte("\n"))
+		}
+		b.Write([]byte(r.Messages[i].Message()))
+	}
+
+	return b.String()
+}
+
+func (r *SearchReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	var b bytes.Buffer
+	for i := 0; i < len(r.Results); i++ {
+		if i != 0 {
+			b.Write([]byte("\n"))
+		}
+		b.Write([]byte(fmt.Sprintf("%.3f %s", r.Results[i].GetScore(),
+			r.Results[i].Message.Message())))
+	}
+
+	return b.String()
+}
+
+func (r *RebuildIndexReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d messages indexed.", r.GetMessages())
+}
+
+func (r *SendDraftReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	var b bytes.Buffer
+	for i := 0; i < len(r.Messages); i++ {
+		if i != 0 {
+			b.Write([]byte("\n"))
+		}
+		b.Write([]byte(r.Messages[i].Message()))
+	}
+
+	return b.String()
+}
+
+func (r *QuoteReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	var b bytes.Buffer
+	ttl := time.Duration(r.GetTtl()) * time.Second
+	b.Write([]byte(fmt.Sprintf("%d messages of about %d bytes living %s.\n",
+		len(r.Recipients), r.GetSize(), ttl)))
+	for i := 0; i < len(r.Recipients); i++ {
+		b.Write([]byte(r.Recipients[i].Message()))
+		b.Write([]byte("\n"))
+	}
+
+	estimate := time.Duration(r.GetTime()) * time.Second
+	b.Write([]byte(fmt.Sprintf("about %s of proof-of-work at %.0f hashes per second.",
+		estimate, r.GetHashrate())))
+
+	return b.String()
+}
+
+func (r *RecipientQuote) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	if !r.GetKnown() {
+		return fmt.Sprintf("%s: unknown until its pubkey is received.", r.GetAddress())
+	}
+
+	estimate := time.Duration(r.GetTime()) * time.Second
+	return fmt.Sprintf("%s: target %d, about %s.", r.GetAddress(), r.GetTarget(), estimate)
+}
+
+func (r *PowQueueReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	if len(r.Orders) == 0 {
+		return "the proof-of-work queue is empty."
+	}
+
+	var b bytes.Buffer
+	for i := 0; i < len(r.Orders); i++ {
+		if i != 0 {
+			b.Write([]byte("\n"))
+		}
+		b.Write([]byte(r.Orders[i].Message()))
+	}
+
+	return b.String()
+}
+
+func (r *PowPolicyReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	windows := "any time"
+	if len(r.Windows) != 0 {
+		windows = strings.Join(r.Windows, ", ")
+	}
+	maxLoad := "none"
+	if r.GetMaxLoad() != 0 {
+		maxLoad = fmt.Sprintf("%.2f", r.GetMaxLoad())
+	}
+	longOrder := "none"
+	if r.GetLongOrder() != 0 {
+		longOrder = (time.Duration(r.GetLongOrder()) * time.Second).String()
+	}
+	return fmt.Sprintf("duty cycle: %d%%\npause on battery: %t\nmax load: %s\n"+
+		"low-priority windows: %s\nlow priority after: %s", r.GetDutyCycle(),
+		r.GetPauseOnBattery(), maxLoad, windows, longOrder)
+}
+
+func (r *BenchmarkReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	var b bytes.Buffer
+	for _, rate := range r.Rates {
+		handler := "parallel"
+		if rate.GetThreads() == 1 {
+			handler = "sequential"
+		}
+		b.WriteString(fmt.Sprintf("%d threads (%s): %.0f hashes per second\n",
+			rate.GetThreads(), handler, rate.GetHashrate()))
+	}
+
+	if r.GetRecommendedThreads() == 1 {
+		b.WriteString("recommended: --pow=sequential")
+	} else {
+		b.WriteString(fmt.Sprintf("recommended: --pow=parallel --powthreads=%d",
+			r.GetRecommendedThreads()))
+	}
+
+	return b.String()
+}
+
+func (o *PowOrder) Message() string {
+	if o == nil {
+		return ""
+	}
+
+	position := fmt.Sprintf("%d", o.GetPosition())
+	if o.GetPosition() == 0 {
+		position = "running"
+	}
+	estimate := time.Duration(o.GetEstimate()) * time.Second
+	elapsed := time.Duration(o.GetElapsed()) * time.Second
+	return fmt.Sprintf("%s: %s, %s priority, target %d, done in about %s, given %s ago.",
+		position, o.GetType(), o.GetPriority(), o.GetTarget(), estimate, elapsed)
+}
+
 func (r *HelpReply) Message() string {
 	if r == nil {
 		return ""
@@ -94,3 +307,19 @@ func (r *HelpReply) Message() string {
 
 	return b.String()
 }
+
+func (r *ApproveReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("message %d approved for sending.", r.GetId())
+}
+
+func (r *SubscribeReply) Message() string {
+	if r == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("subscribed to %s.", r.GetAddress())
+}
@@ -18,8 +18,32 @@ It has these top-level messages:
 	BitmessageSelector
 	SendBitmessageRequest
 	ListAddressesRequest
+	GetMessagesRequest
+	SearchRequest
+	RebuildIndexRequest
+	SendDraftRequest
+	QuoteRequest
+	PowQueueRequest
+	PowPolicyRequest
+	BenchmarkRequest
+	ApproveRequest
+	SubscribeRequest
 	NewAddressReply
 	ListAddressesReply
+	GetMessagesReply
+	SearchReply
+	SearchResult
+	RebuildIndexReply
+	SendDraftReply
+	QuoteReply
+	RecipientQuote
+	PowQueueReply
+	PowPolicyReply
+	BenchmarkReply
+	ApproveReply
+	SubscribeReply
+	PowRate
+	PowOrder
 	BitmessageIdentity
 	Bitmessage
 	TextBitmessage
@@ -433,6 +457,16 @@ type BMRPCRequest struct {
 	//	*BMRPCRequest_Newaddress
 	//	*BMRPCRequest_Help
 	//	*BMRPCRequest_Listaddresses
+	//	*BMRPCRequest_Getmessages
+	//	*BMRPCRequest_Search
+	//	*BMRPCRequest_Rebuildindex
+	//	*BMRPCRequest_Senddraft
+	//	*BMRPCRequest_Quote
+	//	*BMRPCRequest_Powqueue
+	//	*BMRPCRequest_Powpolicy
+	//	*BMRPCRequest_Benchmark
+	//	*BMRPCRequest_Approve
+	//	*BMRPCRequest_Subscribe
 	Request          isBMRPCRequest_Request `protobuf_oneof:"request"`
 	XXX_unrecognized []byte                 `json:"-"`
 }
@@ -455,10 +489,50 @@ type BMRPCRequest_Help struct {
 type BMRPCRequest_Listaddresses struct {
 	Listaddresses *ListAddressesRequest `protobuf:"bytes,13,opt,name=listaddresses,oneof"`
 }
+type BMRPCRequest_Getmessages struct {
+	Getmessages *GetMessagesRequest `protobuf:"bytes,14,opt,name=getmessages,oneof"`
+}
+type BMRPCRequest_Search struct {
+	Search *SearchRequest `protobuf:"bytes,15,opt,name=search,oneof"`
+}
+type BMRPCRequest_Rebuildindex struct {
+	Rebuildindex *RebuildIndexRequest `protobuf:"bytes,16,opt,name=rebuildindex,oneof"`
+}
+type BMRPCRequest_Senddraft struct {
+	Senddraft *SendDraftRequest `protobuf:"bytes,17,opt,name=senddraft,oneof"`
+}
+type BMRPCRequest_Quote struct {
+	Quote *QuoteRequest `protobuf:"bytes,18,opt,name=quote,oneof"`
+}
+type BMRPCRequest_Powqueue struct {
+	Powqueue *PowQueueRequest `protobuf:"bytes,19,opt,name=powqueue,oneof"`
+}
+type BMRPCRequest_Powpolicy struct {
+	Powpolicy *PowPolicyRequest `protobuf:"bytes,20,opt,name=powpolicy,oneof"`
+}
+type BMRPCRequest_Benchmark struct {
+	Benchmark *BenchmarkRequest `protobuf:"bytes,21,opt,name=benchmark,oneof"`
+}
+type BMRPCRequest_Approve struct {
+	Approve *ApproveRequest `protobuf:"bytes,22,opt,name=approve,oneof"`
+}
+type BMRPCRequest_Subscribe struct {
+	Subscribe *SubscribeRequest `protobuf:"bytes,23,opt,name=subscribe,oneof"`
+}
 
 func (*BMRPCRequest_Newaddress) isBMRPCRequest_Request()    {}
 func (*BMRPCRequest_Help) isBMRPCRequest_Request()          {}
 func (*BMRPCRequest_Listaddresses) isBMRPCRequest_Request() {}
+func (*BMRPCRequest_Getmessages) isBMRPCRequest_Request()   {}
+func (*BMRPCRequest_Search) isBMRPCRequest_Request()        {}
+func (*BMRPCRequest_Rebuildindex) isBMRPCRequest_Request()  {}
+func (*BMRPCRequest_Senddraft) isBMRPCRequest_Request()     {}
+func (*BMRPCRequest_Quote) isBMRPCRequest_Request()         {}
+func (*BMRPCRequest_Powqueue) isBMRPCRequest_Request()      {}
+func (*BMRPCRequest_Powpolicy) isBMRPCRequest_Request()     {}
+func (*BMRPCRequest_Benchmark) isBMRPCRequest_Request()     {}
+func (*BMRPCRequest_Approve) isBMRPCRequest_Request()       {}
+func (*BMRPCRequest_Subscribe) isBMRPCRequest_Request()     {}
 
 func (m *BMRPCRequest) GetRequest() isBMRPCRequest_Request {
 	if m != nil {
@@ -523,12 +597,92 @@ func (m *BMRPCRequest) GetListaddresses() *ListAddressesRequest {
 	return nil
 }
 
+func (m *BMRPCRequest) GetGetmessages() *GetMessagesRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Getmessages); ok {
+		return x.Getmessages
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetSearch() *SearchRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Search); ok {
+		return x.Search
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetRebuildindex() *RebuildIndexRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Rebuildindex); ok {
+		return x.Rebuildindex
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetSenddraft() *SendDraftRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Senddraft); ok {
+		return x.Senddraft
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetQuote() *QuoteRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Quote); ok {
+		return x.Quote
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetPowqueue() *PowQueueRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Powqueue); ok {
+		return x.Powqueue
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetPowpolicy() *PowPolicyRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Powpolicy); ok {
+		return x.Powpolicy
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetBenchmark() *BenchmarkRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Benchmark); ok {
+		return x.Benchmark
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetApprove() *ApproveRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Approve); ok {
+		return x.Approve
+	}
+	return nil
+}
+
+func (m *BMRPCRequest) GetSubscribe() *SubscribeRequest {
+	if x, ok := m.GetRequest().(*BMRPCRequest_Subscribe); ok {
+		return x.Subscribe
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*BMRPCRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _BMRPCRequest_OneofMarshaler, _BMRPCRequest_OneofUnmarshaler, _BMRPCRequest_OneofSizer, []interface{}{
 		(*BMRPCRequest_Newaddress)(nil),
 		(*BMRPCRequest_Help)(nil),
 		(*BMRPCRequest_Listaddresses)(nil),
+		(*BMRPCRequest_Getmessages)(nil),
+		(*BMRPCRequest_Search)(nil),
+		(*BMRPCRequest_Rebuildindex)(nil),
+		(*BMRPCRequest_Senddraft)(nil),
+		(*BMRPCRequest_Quote)(nil),
+		(*BMRPCRequest_Powqueue)(nil),
+		(*BMRPCRequest_Powpolicy)(nil),
+		(*BMRPCRequest_Benchmark)(nil),
+		(*BMRPCRequest_Approve)(nil),
+		(*BMRPCRequest_Subscribe)(nil),
 	}
 }
 
@@ -551,6 +705,56 @@ func _BMRPCRequest_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Listaddresses); err != nil {
 			return err
 		}
+	case *BMRPCRequest_Getmessages:
+		b.EncodeVarint(14<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Getmessages); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Search:
+		b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Search); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Rebuildindex:
+		b.EncodeVarint(16<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Rebuildindex); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Senddraft:
+		b.EncodeVarint(17<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Senddraft); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Quote:
+		b.EncodeVarint(18<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Quote); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Powqueue:
+		b.EncodeVarint(19<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Powqueue); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Powpolicy:
+		b.EncodeVarint(20<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Powpolicy); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Benchmark:
+		b.EncodeVarint(21<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Benchmark); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Approve:
+		b.EncodeVarint(22<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Approve); err != nil {
+			return err
+		}
+	case *BMRPCRequest_Subscribe:
+		b.EncodeVarint(23<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Subscribe); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("BMRPCRequest.Request has unexpected type %T", x)
@@ -585,6 +789,86 @@ func _BMRPCRequest_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.B
 		err := b.DecodeMessage(msg)
 		m.Request = &BMRPCRequest_Listaddresses{msg}
 		return true, err
+	case 14: // request.getmessages
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(GetMessagesRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Getmessages{msg}
+		return true, err
+	case 15: // request.search
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SearchRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Search{msg}
+		return true, err
+	case 16: // request.rebuildindex
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(RebuildIndexRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Rebuildindex{msg}
+		return true, err
+	case 17: // request.senddraft
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SendDraftRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Senddraft{msg}
+		return true, err
+	case 18: // request.quote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(QuoteRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Quote{msg}
+		return true, err
+	case 19: // request.powqueue
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PowQueueRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Powqueue{msg}
+		return true, err
+	case 20: // request.powpolicy
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PowPolicyRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Powpolicy{msg}
+		return true, err
+	case 21: // request.benchmark
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BenchmarkRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Benchmark{msg}
+		return true, err
+	case 22: // request.approve
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ApproveRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Approve{msg}
+		return true, err
+	case 23: // request.subscribe
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SubscribeRequest)
+		err := b.DecodeMessage(msg)
+		m.Request = &BMRPCRequest_Subscribe{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -609,6 +893,56 @@ func _BMRPCRequest_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(13<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *BMRPCRequest_Getmessages:
+		s := proto.Size(x.Getmessages)
+		n += proto.SizeVarint(14<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Search:
+		s := proto.Size(x.Search)
+		n += proto.SizeVarint(15<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Rebuildindex:
+		s := proto.Size(x.Rebuildindex)
+		n += proto.SizeVarint(16<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Senddraft:
+		s := proto.Size(x.Senddraft)
+		n += proto.SizeVarint(17<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Quote:
+		s := proto.Size(x.Quote)
+		n += proto.SizeVarint(18<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Powqueue:
+		s := proto.Size(x.Powqueue)
+		n += proto.SizeVarint(19<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Powpolicy:
+		s := proto.Size(x.Powpolicy)
+		n += proto.SizeVarint(20<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Benchmark:
+		s := proto.Size(x.Benchmark)
+		n += proto.SizeVarint(21<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Approve:
+		s := proto.Size(x.Approve)
+		n += proto.SizeVarint(22<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCRequest_Subscribe:
+		s := proto.Size(x.Subscribe)
+		n += proto.SizeVarint(23<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -627,6 +961,16 @@ type BMRPCReply struct {
 	//	*BMRPCReply_Newaddress
 	//	*BMRPCReply_Listaddresses
 	//	*BMRPCReply_HelpReply
+	//	*BMRPCReply_Getmessages
+	//	*BMRPCReply_Search
+	//	*BMRPCReply_Rebuildindex
+	//	*BMRPCReply_Senddraft
+	//	*BMRPCReply_Quote
+	//	*BMRPCReply_Powqueue
+	//	*BMRPCReply_Powpolicy
+	//	*BMRPCReply_Benchmark
+	//	*BMRPCReply_Approve
+	//	*BMRPCReply_Subscribe
 	Reply            isBMRPCReply_Reply `protobuf_oneof:"reply"`
 	XXX_unrecognized []byte             `json:"-"`
 }
@@ -652,11 +996,51 @@ type BMRPCReply_Listaddresses struct {
 type BMRPCReply_HelpReply struct {
 	HelpReply *HelpReply `protobuf:"bytes,11,opt,name=helpReply,oneof"`
 }
+type BMRPCReply_Getmessages struct {
+	Getmessages *GetMessagesReply `protobuf:"bytes,12,opt,name=getmessages,oneof"`
+}
+type BMRPCReply_Search struct {
+	Search *SearchReply `protobuf:"bytes,13,opt,name=search,oneof"`
+}
+type BMRPCReply_Rebuildindex struct {
+	Rebuildindex *RebuildIndexReply `protobuf:"bytes,14,opt,name=rebuildindex,oneof"`
+}
+type BMRPCReply_Senddraft struct {
+	Senddraft *SendDraftReply `protobuf:"bytes,15,opt,name=senddraft,oneof"`
+}
+type BMRPCReply_Quote struct {
+	Quote *QuoteReply `protobuf:"bytes,16,opt,name=quote,oneof"`
+}
+type BMRPCReply_Powqueue struct {
+	Powqueue *PowQueueReply `protobuf:"bytes,17,opt,name=powqueue,oneof"`
+}
+type BMRPCReply_Powpolicy struct {
+	Powpolicy *PowPolicyReply `protobuf:"bytes,18,opt,name=powpolicy,oneof"`
+}
+type BMRPCReply_Benchmark struct {
+	Benchmark *BenchmarkReply `protobuf:"bytes,19,opt,name=benchmark,oneof"`
+}
+type BMRPCReply_Approve struct {
+	Approve *ApproveReply `protobuf:"bytes,20,opt,name=approve,oneof"`
+}
+type BMRPCReply_Subscribe struct {
+	Subscribe *SubscribeReply `protobuf:"bytes,21,opt,name=subscribe,oneof"`
+}
 
 func (*BMRPCReply_ErrorReply) isBMRPCReply_Reply()    {}
 func (*BMRPCReply_Newaddress) isBMRPCReply_Reply()    {}
 func (*BMRPCReply_Listaddresses) isBMRPCReply_Reply() {}
 func (*BMRPCReply_HelpReply) isBMRPCReply_Reply()     {}
+func (*BMRPCReply_Getmessages) isBMRPCReply_Reply()   {}
+func (*BMRPCReply_Search) isBMRPCReply_Reply()        {}
+func (*BMRPCReply_Rebuildindex) isBMRPCReply_Reply()  {}
+func (*BMRPCReply_Senddraft) isBMRPCReply_Reply()     {}
+func (*BMRPCReply_Quote) isBMRPCReply_Reply()         {}
+func (*BMRPCReply_Powqueue) isBMRPCReply_Reply()      {}
+func (*BMRPCReply_Powpolicy) isBMRPCReply_Reply()     {}
+func (*BMRPCReply_Benchmark) isBMRPCReply_Reply()     {}
+func (*BMRPCReply_Approve) isBMRPCReply_Reply()       {}
+func (*BMRPCReply_Subscribe) isBMRPCReply_Reply()     {}
 
 func (m *BMRPCReply) GetReply() isBMRPCReply_Reply {
 	if m != nil {
@@ -728,6 +1112,76 @@ func (m *BMRPCReply) GetHelpReply() *HelpReply {
 	return nil
 }
 
+func (m *BMRPCReply) GetGetmessages() *GetMessagesReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Getmessages); ok {
+		return x.Getmessages
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetSearch() *SearchReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Search); ok {
+		return x.Search
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetRebuildindex() *RebuildIndexReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Rebuildindex); ok {
+		return x.Rebuildindex
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetSenddraft() *SendDraftReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Senddraft); ok {
+		return x.Senddraft
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetQuote() *QuoteReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Quote); ok {
+		return x.Quote
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetPowqueue() *PowQueueReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Powqueue); ok {
+		return x.Powqueue
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetPowpolicy() *PowPolicyReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Powpolicy); ok {
+		return x.Powpolicy
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetBenchmark() *BenchmarkReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Benchmark); ok {
+		return x.Benchmark
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetApprove() *ApproveReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Approve); ok {
+		return x.Approve
+	}
+	return nil
+}
+
+func (m *BMRPCReply) GetSubscribe() *SubscribeReply {
+	if x, ok := m.GetReply().(*BMRPCReply_Subscribe); ok {
+		return x.Subscribe
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*BMRPCReply) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _BMRPCReply_OneofMarshaler, _BMRPCReply_OneofUnmarshaler, _BMRPCReply_OneofSizer, []interface{}{
@@ -735,6 +1189,16 @@ func (*BMRPCReply) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) er
 		(*BMRPCReply_Newaddress)(nil),
 		(*BMRPCReply_Listaddresses)(nil),
 		(*BMRPCReply_HelpReply)(nil),
+		(*BMRPCReply_Getmessages)(nil),
+		(*BMRPCReply_Search)(nil),
+		(*BMRPCReply_Rebuildindex)(nil),
+		(*BMRPCReply_Senddraft)(nil),
+		(*BMRPCReply_Quote)(nil),
+		(*BMRPCReply_Powqueue)(nil),
+		(*BMRPCReply_Powpolicy)(nil),
+		(*BMRPCReply_Benchmark)(nil),
+		(*BMRPCReply_Approve)(nil),
+		(*BMRPCReply_Subscribe)(nil),
 	}
 }
 
@@ -762,6 +1226,56 @@ func _BMRPCReply_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.HelpReply); err != nil {
 			return err
 		}
+	case *BMRPCReply_Getmessages:
+		b.EncodeVarint(12<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Getmessages); err != nil {
+			return err
+		}
+	case *BMRPCReply_Search:
+		b.EncodeVarint(13<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Search); err != nil {
+			return err
+		}
+	case *BMRPCReply_Rebuildindex:
+		b.EncodeVarint(14<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Rebuildindex); err != nil {
+			return err
+		}
+	case *BMRPCReply_Senddraft:
+		b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Senddraft); err != nil {
+			return err
+		}
+	case *BMRPCReply_Quote:
+		b.EncodeVarint(16<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Quote); err != nil {
+			return err
+		}
+	case *BMRPCReply_Powqueue:
+		b.EncodeVarint(17<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Powqueue); err != nil {
+			return err
+		}
+	case *BMRPCReply_Powpolicy:
+		b.EncodeVarint(18<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Powpolicy); err != nil {
+			return err
+		}
+	case *BMRPCReply_Benchmark:
+		b.EncodeVarint(19<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Benchmark); err != nil {
+			return err
+		}
+	case *BMRPCReply_Approve:
+		b.EncodeVarint(20<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Approve); err != nil {
+			return err
+		}
+	case *BMRPCReply_Subscribe:
+		b.EncodeVarint(21<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Subscribe); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("BMRPCReply.Reply has unexpected type %T", x)
@@ -804,50 +1318,180 @@ func _BMRPCReply_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buf
 		err := b.DecodeMessage(msg)
 		m.Reply = &BMRPCReply_HelpReply{msg}
 		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _BMRPCReply_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*BMRPCReply)
-	// reply
-	switch x := m.Reply.(type) {
-	case *BMRPCReply_ErrorReply:
-		s := proto.Size(x.ErrorReply)
-		n += proto.SizeVarint(8<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *BMRPCReply_Newaddress:
-		s := proto.Size(x.Newaddress)
-		n += proto.SizeVarint(9<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *BMRPCReply_Listaddresses:
-		s := proto.Size(x.Listaddresses)
-		n += proto.SizeVarint(10<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *BMRPCReply_HelpReply:
-		s := proto.Size(x.HelpReply)
-		n += proto.SizeVarint(11<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
-}
-
-type ErrorReply struct {
-	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
-	Error            *string `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
-}
-
-func (m *ErrorReply) Reset()                    { *m = ErrorReply{} }
-func (m *ErrorReply) String() string            { return proto.CompactTextString(m) }
+	case 12: // reply.getmessages
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(GetMessagesReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Getmessages{msg}
+		return true, err
+	case 13: // reply.search
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SearchReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Search{msg}
+		return true, err
+	case 14: // reply.rebuildindex
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(RebuildIndexReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Rebuildindex{msg}
+		return true, err
+	case 15: // reply.senddraft
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SendDraftReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Senddraft{msg}
+		return true, err
+	case 16: // reply.quote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(QuoteReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Quote{msg}
+		return true, err
+	case 17: // reply.powqueue
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PowQueueReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Powqueue{msg}
+		return true, err
+	case 18: // reply.powpolicy
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PowPolicyReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Powpolicy{msg}
+		return true, err
+	case 19: // reply.benchmark
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BenchmarkReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Benchmark{msg}
+		return true, err
+	case 20: // reply.approve
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ApproveReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Approve{msg}
+		return true, err
+	case 21: // reply.subscribe
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SubscribeReply)
+		err := b.DecodeMessage(msg)
+		m.Reply = &BMRPCReply_Subscribe{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _BMRPCReply_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*BMRPCReply)
+	// reply
+	switch x := m.Reply.(type) {
+	case *BMRPCReply_ErrorReply:
+		s := proto.Size(x.ErrorReply)
+		n += proto.SizeVarint(8<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Newaddress:
+		s := proto.Size(x.Newaddress)
+		n += proto.SizeVarint(9<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Listaddresses:
+		s := proto.Size(x.Listaddresses)
+		n += proto.SizeVarint(10<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_HelpReply:
+		s := proto.Size(x.HelpReply)
+		n += proto.SizeVarint(11<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Getmessages:
+		s := proto.Size(x.Getmessages)
+		n += proto.SizeVarint(12<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Search:
+		s := proto.Size(x.Search)
+		n += proto.SizeVarint(13<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Rebuildindex:
+		s := proto.Size(x.Rebuildindex)
+		n += proto.SizeVarint(14<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Senddraft:
+		s := proto.Size(x.Senddraft)
+		n += proto.SizeVarint(15<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Quote:
+		s := proto.Size(x.Quote)
+		n += proto.SizeVarint(16<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Powqueue:
+		s := proto.Size(x.Powqueue)
+		n += proto.SizeVarint(17<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Powpolicy:
+		s := proto.Size(x.Powpolicy)
+		n += proto.SizeVarint(18<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Benchmark:
+		s := proto.Size(x.Benchmark)
+		n += proto.SizeVarint(19<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Approve:
+		s := proto.Size(x.Approve)
+		n += proto.SizeVarint(20<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BMRPCReply_Subscribe:
+		s := proto.Size(x.Subscribe)
+		n += proto.SizeVarint(21<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type ErrorReply struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Error            *string `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ErrorReply) Reset()                    { *m = ErrorReply{} }
+func (m *ErrorReply) String() string            { return proto.CompactTextString(m) }
 func (*ErrorReply) ProtoMessage()               {}
 func (*ErrorReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
@@ -1184,187 +1828,1012 @@ func (m *ListAddressesRequest) GetVersion() uint32 {
 	return 0
 }
 
-type NewAddressReply struct {
-	Version          *uint32             `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
-	Address          *BitmessageIdentity `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
-	XXX_unrecognized []byte              `json:"-"`
+type GetMessagesRequest struct {
+	Version          *uint32  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Folder           *string  `protobuf:"bytes,2,opt,name=folder" json:"folder,omitempty"`
+	Keywords         []string `protobuf:"bytes,3,rep,name=keywords" json:"keywords,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
 }
 
-func (m *NewAddressReply) Reset()                    { *m = NewAddressReply{} }
-func (m *NewAddressReply) String() string            { return proto.CompactTextString(m) }
-func (*NewAddressReply) ProtoMessage()               {}
-func (*NewAddressReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (m *GetMessagesRequest) Reset()                    { *m = GetMessagesRequest{} }
+func (m *GetMessagesRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetMessagesRequest) ProtoMessage()               {}
+func (*GetMessagesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
-func (m *NewAddressReply) GetVersion() uint32 {
+func (m *GetMessagesRequest) GetVersion() uint32 {
 	if m != nil && m.Version != nil {
 		return *m.Version
 	}
 	return 0
 }
 
-func (m *NewAddressReply) GetAddress() *BitmessageIdentity {
+func (m *GetMessagesRequest) GetFolder() string {
+	if m != nil && m.Folder != nil {
+		return *m.Folder
+	}
+	return ""
+}
+
+func (m *GetMessagesRequest) GetKeywords() []string {
 	if m != nil {
-		return m.Address
+		return m.Keywords
 	}
 	return nil
 }
 
-type ListAddressesReply struct {
-	Version          *uint32               `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
-	Addresses        []*BitmessageIdentity `protobuf:"bytes,2,rep,name=addresses" json:"addresses,omitempty"`
-	XXX_unrecognized []byte                `json:"-"`
+type SearchRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Query            *string `protobuf:"bytes,2,opt,name=query" json:"query,omitempty"`
+	Limit            *uint32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
-func (m *ListAddressesReply) Reset()                    { *m = ListAddressesReply{} }
-func (m *ListAddressesReply) String() string            { return proto.CompactTextString(m) }
-func (*ListAddressesReply) ProtoMessage()               {}
-func (*ListAddressesReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (m *SearchRequest) Reset()                    { *m = SearchRequest{} }
+func (m *SearchRequest) String() string            { return proto.CompactTextString(m) }
+func (*SearchRequest) ProtoMessage()               {}
+func (*SearchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
-func (m *ListAddressesReply) GetVersion() uint32 {
+func (m *SearchRequest) GetVersion() uint32 {
 	if m != nil && m.Version != nil {
 		return *m.Version
 	}
 	return 0
 }
 
-func (m *ListAddressesReply) GetAddresses() []*BitmessageIdentity {
-	if m != nil {
-		return m.Addresses
+func (m *SearchRequest) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
 	}
-	return nil
+	return ""
 }
 
-type BitmessageIdentity struct {
-	Version            *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
-	Address            *string `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
-	Label              *string `protobuf:"bytes,3,opt,name=label" json:"label,omitempty"`
-	Behavior           *uint32 `protobuf:"varint,6,opt,name=behavior" json:"behavior,omitempty"`
-	Noncetrialsperbyte *uint64 `protobuf:"varint,7,opt,name=noncetrialsperbyte" json:"noncetrialsperbyte,omitempty"`
-	Extrabytes         *uint64 `protobuf:"varint,8,opt,name=extrabytes" json:"extrabytes,omitempty"`
-	XXX_unrecognized   []byte  `json:"-"`
+func (m *SearchRequest) GetLimit() uint32 {
+	if m != nil && m.Limit != nil {
+		return *m.Limit
+	}
+	return 0
 }
 
-func (m *BitmessageIdentity) Reset()                    { *m = BitmessageIdentity{} }
-func (m *BitmessageIdentity) String() string            { return proto.CompactTextString(m) }
-func (*BitmessageIdentity) ProtoMessage()               {}
-func (*BitmessageIdentity) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+type RebuildIndexRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
 
-func (m *BitmessageIdentity) GetVersion() uint32 {
+func (m *RebuildIndexRequest) Reset()                    { *m = RebuildIndexRequest{} }
+func (m *RebuildIndexRequest) String() string            { return proto.CompactTextString(m) }
+func (*RebuildIndexRequest) ProtoMessage()               {}
+func (*RebuildIndexRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *RebuildIndexRequest) GetVersion() uint32 {
 	if m != nil && m.Version != nil {
 		return *m.Version
 	}
 	return 0
 }
 
-func (m *BitmessageIdentity) GetAddress() string {
-	if m != nil && m.Address != nil {
-		return *m.Address
-	}
-	return ""
+type SendDraftRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Id               *uint64 `protobuf:"varint,2,opt,name=id" json:"id,omitempty"`
+	SendAt           *uint64 `protobuf:"varint,3,opt,name=send_at,json=sendAt" json:"send_at,omitempty"`
+	Ttl              *string `protobuf:"bytes,4,opt,name=ttl" json:"ttl,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
-func (m *BitmessageIdentity) GetLabel() string {
-	if m != nil && m.Label != nil {
-		return *m.Label
-	}
-	return ""
-}
+func (m *SendDraftRequest) Reset()                    { *m = SendDraftRequest{} }
+func (m *SendDraftRequest) String() string            { return proto.CompactTextString(m) }
+func (*SendDraftRequest) ProtoMessage()               {}
+func (*SendDraftRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
-func (m *BitmessageIdentity) GetBehavior() uint32 {
-	if m != nil && m.Behavior != nil {
-		return *m.Behavior
+func (m *SendDraftRequest) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
 	}
 	return 0
 }
 
-func (m *BitmessageIdentity) GetNoncetrialsperbyte() uint64 {
-	if m != nil && m.Noncetrialsperbyte != nil {
-		return *m.Noncetrialsperbyte
+func (m *SendDraftRequest) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
 	}
 	return 0
 }
 
-func (m *BitmessageIdentity) GetExtrabytes() uint64 {
-	if m != nil && m.Extrabytes != nil {
-		return *m.Extrabytes
+func (m *SendDraftRequest) GetSendAt() uint64 {
+	if m != nil && m.SendAt != nil {
+		return *m.SendAt
 	}
 	return 0
 }
 
-type Bitmessage struct {
-	Version   *uint32         `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
-	Id        *string         `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
-	Sender    *string         `protobuf:"bytes,3,opt,name=sender" json:"sender,omitempty"`
-	Recipient *string         `protobuf:"bytes,4,opt,name=recipient" json:"recipient,omitempty"`
-	Type      *BitmessageType `protobuf:"varint,5,opt,name=type,enum=rpc.BitmessageType" json:"type,omitempty"`
-	// Types that are valid to be assigned to Body:
-	//	*Bitmessage_Text
-	Body             isBitmessage_Body `protobuf_oneof:"body"`
-	XXX_unrecognized []byte            `json:"-"`
-}
-
-func (m *Bitmessage) Reset()                    { *m = Bitmessage{} }
-func (m *Bitmessage) String() string            { return proto.CompactTextString(m) }
-func (*Bitmessage) ProtoMessage()               {}
-func (*Bitmessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
-
-type isBitmessage_Body interface {
-	isBitmessage_Body()
+func (m *SendDraftRequest) GetTtl() string {
+	if m != nil && m.Ttl != nil {
+		return *m.Ttl
+	}
+	return ""
 }
 
-type Bitmessage_Text struct {
-	Text *TextBitmessage `protobuf:"bytes,6,opt,name=text,oneof"`
+type QuoteRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Id               *uint64 `protobuf:"varint,2,opt,name=id" json:"id,omitempty"`
+	Ttl              *string `protobuf:"bytes,3,opt,name=ttl" json:"ttl,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
-func (*Bitmessage_Text) isBitmessage_Body() {}
-
-func (m *Bitmessage) GetBody() isBitmessage_Body {
-	if m != nil {
-		return m.Body
-	}
-	return nil
-}
+func (m *QuoteRequest) Reset()                    { *m = QuoteRequest{} }
+func (m *QuoteRequest) String() string            { return proto.CompactTextString(m) }
+func (*QuoteRequest) ProtoMessage()               {}
+func (*QuoteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
-func (m *Bitmessage) GetVersion() uint32 {
+func (m *QuoteRequest) GetVersion() uint32 {
 	if m != nil && m.Version != nil {
 		return *m.Version
 	}
 	return 0
 }
 
-func (m *Bitmessage) GetId() string {
+func (m *QuoteRequest) GetId() uint64 {
 	if m != nil && m.Id != nil {
 		return *m.Id
 	}
-	return ""
+	return 0
 }
 
-func (m *Bitmessage) GetSender() string {
-	if m != nil && m.Sender != nil {
-		return *m.Sender
+func (m *QuoteRequest) GetTtl() string {
+	if m != nil && m.Ttl != nil {
+		return *m.Ttl
 	}
 	return ""
 }
 
-func (m *Bitmessage) GetRecipient() string {
-	if m != nil && m.Recipient != nil {
-		return *m.Recipient
-	}
-	return ""
+type PowQueueRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
-func (m *Bitmessage) GetType() BitmessageType {
-	if m != nil && m.Type != nil {
-		return *m.Type
+func (m *PowQueueRequest) Reset()                    { *m = PowQueueRequest{} }
+func (m *PowQueueRequest) String() string            { return proto.CompactTextString(m) }
+func (*PowQueueRequest) ProtoMessage()               {}
+func (*PowQueueRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *PowQueueRequest) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
 	}
-	return BitmessageType_BITMESSAGE_ERROR
+	return 0
 }
 
-func (m *Bitmessage) GetText() *TextBitmessage {
-	if x, ok := m.GetBody().(*Bitmessage_Text); ok {
-		return x.Text
-	}
-	return nil
+// Only the settings that are given are changed.
+type PowPolicyRequest struct {
+	Version          *uint32  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	DutyCycle        *uint32  `protobuf:"varint,2,opt,name=duty_cycle,json=dutyCycle" json:"duty_cycle,omitempty"`
+	PauseOnBattery   *bool    `protobuf:"varint,3,opt,name=pause_on_battery,json=pauseOnBattery" json:"pause_on_battery,omitempty"`
+	MaxLoad          *float64 `protobuf:"fixed64,4,opt,name=max_load,json=maxLoad" json:"max_load,omitempty"`
+	Windows          []string `protobuf:"bytes,5,rep,name=windows" json:"windows,omitempty"`
+	SetWindows       *bool    `protobuf:"varint,6,opt,name=set_windows,json=setWindows" json:"set_windows,omitempty"`
+	LongOrder        *uint64  `protobuf:"varint,7,opt,name=long_order,json=longOrder" json:"long_order,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *PowPolicyRequest) Reset()                    { *m = PowPolicyRequest{} }
+func (m *PowPolicyRequest) String() string            { return proto.CompactTextString(m) }
+func (*PowPolicyRequest) ProtoMessage()               {}
+func (*PowPolicyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *PowPolicyRequest) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *PowPolicyRequest) GetDutyCycle() uint32 {
+	if m != nil && m.DutyCycle != nil {
+		return *m.DutyCycle
+	}
+	return 0
+}
+
+func (m *PowPolicyRequest) GetPauseOnBattery() bool {
+	if m != nil && m.PauseOnBattery != nil {
+		return *m.PauseOnBattery
+	}
+	return false
+}
+
+func (m *PowPolicyRequest) GetMaxLoad() float64 {
+	if m != nil && m.MaxLoad != nil {
+		return *m.MaxLoad
+	}
+	return 0
+}
+
+func (m *PowPolicyRequest) GetWindows() []string {
+	if m != nil {
+		return m.Windows
+	}
+	return nil
+}
+
+func (m *PowPolicyRequest) GetSetWindows() bool {
+	if m != nil && m.SetWindows != nil {
+		return *m.SetWindows
+	}
+	return false
+}
+
+func (m *PowPolicyRequest) GetLongOrder() uint64 {
+	if m != nil && m.LongOrder != nil {
+		return *m.LongOrder
+	}
+	return 0
+}
+
+type BenchmarkRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *BenchmarkRequest) Reset()                    { *m = BenchmarkRequest{} }
+func (m *BenchmarkRequest) String() string            { return proto.CompactTextString(m) }
+func (*BenchmarkRequest) ProtoMessage()               {}
+func (*BenchmarkRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *BenchmarkRequest) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+type ApproveRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Id               *uint64 `protobuf:"varint,2,opt,name=id" json:"id,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ApproveRequest) Reset()                    { *m = ApproveRequest{} }
+func (m *ApproveRequest) String() string            { return proto.CompactTextString(m) }
+func (*ApproveRequest) ProtoMessage()               {}
+func (*ApproveRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *ApproveRequest) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *ApproveRequest) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+type SubscribeRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Address          *string `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SubscribeRequest) Reset()                    { *m = SubscribeRequest{} }
+func (m *SubscribeRequest) String() string            { return proto.CompactTextString(m) }
+func (*SubscribeRequest) ProtoMessage()               {}
+func (*SubscribeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *SubscribeRequest) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetAddress() string {
+	if m != nil && m.Address != nil {
+		return *m.Address
+	}
+	return ""
+}
+
+type NewAddressReply struct {
+	Version          *uint32             `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Address          *BitmessageIdentity `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	XXX_unrecognized []byte              `json:"-"`
+}
+
+func (m *NewAddressReply) Reset()                    { *m = NewAddressReply{} }
+func (m *NewAddressReply) String() string            { return proto.CompactTextString(m) }
+func (*NewAddressReply) ProtoMessage()               {}
+func (*NewAddressReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *NewAddressReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *NewAddressReply) GetAddress() *BitmessageIdentity {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+type ListAddressesReply struct {
+	Version          *uint32               `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Addresses        []*BitmessageIdentity `protobuf:"bytes,2,rep,name=addresses" json:"addresses,omitempty"`
+	XXX_unrecognized []byte                `json:"-"`
+}
+
+func (m *ListAddressesReply) Reset()                    { *m = ListAddressesReply{} }
+func (m *ListAddressesReply) String() string            { return proto.CompactTextString(m) }
+func (*ListAddressesReply) ProtoMessage()               {}
+func (*ListAddressesReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *ListAddressesReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *ListAddressesReply) GetAddresses() []*BitmessageIdentity {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+type GetMessagesReply struct {
+	Version          *uint32       `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Messages         []*Bitmessage `protobuf:"bytes,2,rep,name=messages" json:"messages,omitempty"`
+	XXX_unrecognized []byte        `json:"-"`
+}
+
+func (m *GetMessagesReply) Reset()                    { *m = GetMessagesReply{} }
+func (m *GetMessagesReply) String() string            { return proto.CompactTextString(m) }
+func (*GetMessagesReply) ProtoMessage()               {}
+func (*GetMessagesReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *GetMessagesReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *GetMessagesReply) GetMessages() []*Bitmessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+type SearchReply struct {
+	Version          *uint32         `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Results          []*SearchResult `protobuf:"bytes,2,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte          `json:"-"`
+}
+
+func (m *SearchReply) Reset()                    { *m = SearchReply{} }
+func (m *SearchReply) String() string            { return proto.CompactTextString(m) }
+func (*SearchReply) ProtoMessage()               {}
+func (*SearchReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *SearchReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *SearchReply) GetResults() []*SearchResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type SearchResult struct {
+	Version          *uint32     `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Score            *float64    `protobuf:"fixed64,2,opt,name=score" json:"score,omitempty"`
+	Message          *Bitmessage `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *SearchResult) Reset()                    { *m = SearchResult{} }
+func (m *SearchResult) String() string            { return proto.CompactTextString(m) }
+func (*SearchResult) ProtoMessage()               {}
+func (*SearchResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *SearchResult) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *SearchResult) GetScore() float64 {
+	if m != nil && m.Score != nil {
+		return *m.Score
+	}
+	return 0
+}
+
+func (m *SearchResult) GetMessage() *Bitmessage {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+type RebuildIndexReply struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Messages         *uint32 `protobuf:"varint,2,opt,name=messages" json:"messages,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RebuildIndexReply) Reset()                    { *m = RebuildIndexReply{} }
+func (m *RebuildIndexReply) String() string            { return proto.CompactTextString(m) }
+func (*RebuildIndexReply) ProtoMessage()               {}
+func (*RebuildIndexReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *RebuildIndexReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *RebuildIndexReply) GetMessages() uint32 {
+	if m != nil && m.Messages != nil {
+		return *m.Messages
+	}
+	return 0
+}
+
+type SendDraftReply struct {
+	Version          *uint32       `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Messages         []*Bitmessage `protobuf:"bytes,2,rep,name=messages" json:"messages,omitempty"`
+	XXX_unrecognized []byte        `json:"-"`
+}
+
+func (m *SendDraftReply) Reset()                    { *m = SendDraftReply{} }
+func (m *SendDraftReply) String() string            { return proto.CompactTextString(m) }
+func (*SendDraftReply) ProtoMessage()               {}
+func (*SendDraftReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *SendDraftReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *SendDraftReply) GetMessages() []*Bitmessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+type QuoteReply struct {
+	Version          *uint32           `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Ttl              *uint64           `protobuf:"varint,2,opt,name=ttl" json:"ttl,omitempty"`
+	Size             *uint64           `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
+	Hashrate         *float64          `protobuf:"fixed64,4,opt,name=hashrate" json:"hashrate,omitempty"`
+	Time             *uint64           `protobuf:"varint,5,opt,name=time" json:"time,omitempty"`
+	Recipients       []*RecipientQuote `protobuf:"bytes,6,rep,name=recipients" json:"recipients,omitempty"`
+	XXX_unrecognized []byte            `json:"-"`
+}
+
+func (m *QuoteReply) Reset()                    { *m = QuoteReply{} }
+func (m *QuoteReply) String() string            { return proto.CompactTextString(m) }
+func (*QuoteReply) ProtoMessage()               {}
+func (*QuoteReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *QuoteReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *QuoteReply) GetTtl() uint64 {
+	if m != nil && m.Ttl != nil {
+		return *m.Ttl
+	}
+	return 0
+}
+
+func (m *QuoteReply) GetSize() uint64 {
+	if m != nil && m.Size != nil {
+		return *m.Size
+	}
+	return 0
+}
+
+func (m *QuoteReply) GetHashrate() float64 {
+	if m != nil && m.Hashrate != nil {
+		return *m.Hashrate
+	}
+	return 0
+}
+
+func (m *QuoteReply) GetTime() uint64 {
+	if m != nil && m.Time != nil {
+		return *m.Time
+	}
+	return 0
+}
+
+func (m *QuoteReply) GetRecipients() []*RecipientQuote {
+	if m != nil {
+		return m.Recipients
+	}
+	return nil
+}
+
+type RecipientQuote struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Address          *string `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	Known            *bool   `protobuf:"varint,3,opt,name=known" json:"known,omitempty"`
+	Target           *uint64 `protobuf:"varint,4,opt,name=target" json:"target,omitempty"`
+	Time             *uint64 `protobuf:"varint,5,opt,name=time" json:"time,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RecipientQuote) Reset()                    { *m = RecipientQuote{} }
+func (m *RecipientQuote) String() string            { return proto.CompactTextString(m) }
+func (*RecipientQuote) ProtoMessage()               {}
+func (*RecipientQuote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *RecipientQuote) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *RecipientQuote) GetAddress() string {
+	if m != nil && m.Address != nil {
+		return *m.Address
+	}
+	return ""
+}
+
+func (m *RecipientQuote) GetKnown() bool {
+	if m != nil && m.Known != nil {
+		return *m.Known
+	}
+	return false
+}
+
+func (m *RecipientQuote) GetTarget() uint64 {
+	if m != nil && m.Target != nil {
+		return *m.Target
+	}
+	return 0
+}
+
+func (m *RecipientQuote) GetTime() uint64 {
+	if m != nil && m.Time != nil {
+		return *m.Time
+	}
+	return 0
+}
+
+type PowQueueReply struct {
+	Version          *uint32     `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Orders           []*PowOrder `protobuf:"bytes,2,rep,name=orders" json:"orders,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *PowQueueReply) Reset()                    { *m = PowQueueReply{} }
+func (m *PowQueueReply) String() string            { return proto.CompactTextString(m) }
+func (*PowQueueReply) ProtoMessage()               {}
+func (*PowQueueReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *PowQueueReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *PowQueueReply) GetOrders() []*PowOrder {
+	if m != nil {
+		return m.Orders
+	}
+	return nil
+}
+
+type PowPolicyReply struct {
+	Version          *uint32  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	DutyCycle        *uint32  `protobuf:"varint,2,opt,name=duty_cycle,json=dutyCycle" json:"duty_cycle,omitempty"`
+	PauseOnBattery   *bool    `protobuf:"varint,3,opt,name=pause_on_battery,json=pauseOnBattery" json:"pause_on_battery,omitempty"`
+	MaxLoad          *float64 `protobuf:"fixed64,4,opt,name=max_load,json=maxLoad" json:"max_load,omitempty"`
+	Windows          []string `protobuf:"bytes,5,rep,name=windows" json:"windows,omitempty"`
+	LongOrder        *uint64  `protobuf:"varint,6,opt,name=long_order,json=longOrder" json:"long_order,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *PowPolicyReply) Reset()                    { *m = PowPolicyReply{} }
+func (m *PowPolicyReply) String() string            { return proto.CompactTextString(m) }
+func (*PowPolicyReply) ProtoMessage()               {}
+func (*PowPolicyReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *PowPolicyReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *PowPolicyReply) GetDutyCycle() uint32 {
+	if m != nil && m.DutyCycle != nil {
+		return *m.DutyCycle
+	}
+	return 0
+}
+
+func (m *PowPolicyReply) GetPauseOnBattery() bool {
+	if m != nil && m.PauseOnBattery != nil {
+		return *m.PauseOnBattery
+	}
+	return false
+}
+
+func (m *PowPolicyReply) GetMaxLoad() float64 {
+	if m != nil && m.MaxLoad != nil {
+		return *m.MaxLoad
+	}
+	return 0
+}
+
+func (m *PowPolicyReply) GetWindows() []string {
+	if m != nil {
+		return m.Windows
+	}
+	return nil
+}
+
+func (m *PowPolicyReply) GetLongOrder() uint64 {
+	if m != nil && m.LongOrder != nil {
+		return *m.LongOrder
+	}
+	return 0
+}
+
+type BenchmarkReply struct {
+	Version            *uint32    `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Rates              []*PowRate `protobuf:"bytes,2,rep,name=rates" json:"rates,omitempty"`
+	RecommendedThreads *uint32    `protobuf:"varint,3,opt,name=recommended_threads,json=recommendedThreads" json:"recommended_threads,omitempty"`
+	XXX_unrecognized   []byte     `json:"-"`
+}
+
+func (m *BenchmarkReply) Reset()                    { *m = BenchmarkReply{} }
+func (m *BenchmarkReply) String() string            { return proto.CompactTextString(m) }
+func (*BenchmarkReply) ProtoMessage()               {}
+func (*BenchmarkReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *BenchmarkReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *BenchmarkReply) GetRates() []*PowRate {
+	if m != nil {
+		return m.Rates
+	}
+	return nil
+}
+
+func (m *BenchmarkReply) GetRecommendedThreads() uint32 {
+	if m != nil && m.RecommendedThreads != nil {
+		return *m.RecommendedThreads
+	}
+	return 0
+}
+
+type ApproveReply struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Id               *uint64 `protobuf:"varint,2,opt,name=id" json:"id,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ApproveReply) Reset()                    { *m = ApproveReply{} }
+func (m *ApproveReply) String() string            { return proto.CompactTextString(m) }
+func (*ApproveReply) ProtoMessage()               {}
+func (*ApproveReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *ApproveReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *ApproveReply) GetId() uint64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+type SubscribeReply struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Address          *string `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SubscribeReply) Reset()                    { *m = SubscribeReply{} }
+func (m *SubscribeReply) String() string            { return proto.CompactTextString(m) }
+func (*SubscribeReply) ProtoMessage()               {}
+func (*SubscribeReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *SubscribeReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *SubscribeReply) GetAddress() string {
+	if m != nil && m.Address != nil {
+		return *m.Address
+	}
+	return ""
+}
+
+type PowRate struct {
+	Version          *uint32  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Threads          *uint32  `protobuf:"varint,2,opt,name=threads" json:"threads,omitempty"`
+	Hashrate         *float64 `protobuf:"fixed64,3,opt,name=hashrate" json:"hashrate,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *PowRate) Reset()                    { *m = PowRate{} }
+func (m *PowRate) String() string            { return proto.CompactTextString(m) }
+func (*PowRate) ProtoMessage()               {}
+func (*PowRate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *PowRate) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *PowRate) GetThreads() uint32 {
+	if m != nil && m.Threads != nil {
+		return *m.Threads
+	}
+	return 0
+}
+
+func (m *PowRate) GetHashrate() float64 {
+	if m != nil && m.Hashrate != nil {
+		return *m.Hashrate
+	}
+	return 0
+}
+
+type PowOrder struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Position         *uint32 `protobuf:"varint,2,opt,name=position" json:"position,omitempty"`
+	Type             *string `protobuf:"bytes,3,opt,name=type" json:"type,omitempty"`
+	Priority         *string `protobuf:"bytes,4,opt,name=priority" json:"priority,omitempty"`
+	Target           *uint64 `protobuf:"varint,5,opt,name=target" json:"target,omitempty"`
+	Estimate         *uint64 `protobuf:"varint,6,opt,name=estimate" json:"estimate,omitempty"`
+	Elapsed          *uint64 `protobuf:"varint,7,opt,name=elapsed" json:"elapsed,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *PowOrder) Reset()                    { *m = PowOrder{} }
+func (m *PowOrder) String() string            { return proto.CompactTextString(m) }
+func (*PowOrder) ProtoMessage()               {}
+func (*PowOrder) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *PowOrder) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *PowOrder) GetPosition() uint32 {
+	if m != nil && m.Position != nil {
+		return *m.Position
+	}
+	return 0
+}
+
+func (m *PowOrder) GetType() string {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return ""
+}
+
+func (m *PowOrder) GetPriority() string {
+	if m != nil && m.Priority != nil {
+		return *m.Priority
+	}
+	return ""
+}
+
+func (m *PowOrder) GetTarget() uint64 {
+	if m != nil && m.Target != nil {
+		return *m.Target
+	}
+	return 0
+}
+
+func (m *PowOrder) GetEstimate() uint64 {
+	if m != nil && m.Estimate != nil {
+		return *m.Estimate
+	}
+	return 0
+}
+
+func (m *PowOrder) GetElapsed() uint64 {
+	if m != nil && m.Elapsed != nil {
+		return *m.Elapsed
+	}
+	return 0
+}
+
+type BitmessageIdentity struct {
+	Version            *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Address            *string `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	Label              *string `protobuf:"bytes,3,opt,name=label" json:"label,omitempty"`
+	Behavior           *uint32 `protobuf:"varint,6,opt,name=behavior" json:"behavior,omitempty"`
+	Noncetrialsperbyte *uint64 `protobuf:"varint,7,opt,name=noncetrialsperbyte" json:"noncetrialsperbyte,omitempty"`
+	Extrabytes         *uint64 `protobuf:"varint,8,opt,name=extrabytes" json:"extrabytes,omitempty"`
+	XXX_unrecognized   []byte  `json:"-"`
+}
+
+func (m *BitmessageIdentity) Reset()                    { *m = BitmessageIdentity{} }
+func (m *BitmessageIdentity) String() string            { return proto.CompactTextString(m) }
+func (*BitmessageIdentity) ProtoMessage()               {}
+func (*BitmessageIdentity) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *BitmessageIdentity) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *BitmessageIdentity) GetAddress() string {
+	if m != nil && m.Address != nil {
+		return *m.Address
+	}
+	return ""
+}
+
+func (m *BitmessageIdentity) GetLabel() string {
+	if m != nil && m.Label != nil {
+		return *m.Label
+	}
+	return ""
+}
+
+func (m *BitmessageIdentity) GetBehavior() uint32 {
+	if m != nil && m.Behavior != nil {
+		return *m.Behavior
+	}
+	return 0
+}
+
+func (m *BitmessageIdentity) GetNoncetrialsperbyte() uint64 {
+	if m != nil && m.Noncetrialsperbyte != nil {
+		return *m.Noncetrialsperbyte
+	}
+	return 0
+}
+
+func (m *BitmessageIdentity) GetExtrabytes() uint64 {
+	if m != nil && m.Extrabytes != nil {
+		return *m.Extrabytes
+	}
+	return 0
+}
+
+type Bitmessage struct {
+	Version   *uint32         `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Id        *string         `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
+	Sender    *string         `protobuf:"bytes,3,opt,name=sender" json:"sender,omitempty"`
+	Recipient *string         `protobuf:"bytes,4,opt,name=recipient" json:"recipient,omitempty"`
+	Type      *BitmessageType `protobuf:"varint,5,opt,name=type,enum=rpc.BitmessageType" json:"type,omitempty"`
+	// Types that are valid to be assigned to Body:
+	//	*Bitmessage_Text
+	Body             isBitmessage_Body `protobuf_oneof:"body"`
+	Keywords         []string          `protobuf:"bytes,7,rep,name=keywords" json:"keywords,omitempty"`
+	Folder           *string           `protobuf:"bytes,8,opt,name=folder" json:"folder,omitempty"`
+	XXX_unrecognized []byte            `json:"-"`
+}
+
+func (m *Bitmessage) Reset()                    { *m = Bitmessage{} }
+func (m *Bitmessage) String() string            { return proto.CompactTextString(m) }
+func (*Bitmessage) ProtoMessage()               {}
+func (*Bitmessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+type isBitmessage_Body interface {
+	isBitmessage_Body()
+}
+
+type Bitmessage_Text struct {
+	Text *TextBitmessage `protobuf:"bytes,6,opt,name=text,oneof"`
+}
+
+func (*Bitmessage_Text) isBitmessage_Body() {}
+
+func (m *Bitmessage) GetBody() isBitmessage_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (m *Bitmessage) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *Bitmessage) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Bitmessage) GetSender() string {
+	if m != nil && m.Sender != nil {
+		return *m.Sender
+	}
+	return ""
+}
+
+func (m *Bitmessage) GetRecipient() string {
+	if m != nil && m.Recipient != nil {
+		return *m.Recipient
+	}
+	return ""
+}
+
+func (m *Bitmessage) GetType() BitmessageType {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return BitmessageType_BITMESSAGE_ERROR
+}
+
+func (m *Bitmessage) GetText() *TextBitmessage {
+	if x, ok := m.GetBody().(*Bitmessage_Text); ok {
+		return x.Text
+	}
+	return nil
+}
+
+func (m *Bitmessage) GetKeywords() []string {
+	if m != nil {
+		return m.Keywords
+	}
+	return nil
+}
+
+func (m *Bitmessage) GetFolder() string {
+	if m != nil && m.Folder != nil {
+		return *m.Folder
+	}
+	return ""
 }
 
 // XXX_OneofFuncs is for the internal use of the proto package.
@@ -1423,16 +2892,17 @@ func _Bitmessage_OneofSizer(msg proto.Message) (n int) {
 }
 
 type TextBitmessage struct {
-	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
-	Subject          *string `protobuf:"bytes,2,opt,name=subject" json:"subject,omitempty"`
-	Contents         *string `protobuf:"bytes,3,opt,name=contents" json:"contents,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	Version          *uint32  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Subject          *string  `protobuf:"bytes,2,opt,name=subject" json:"subject,omitempty"`
+	Contents         *string  `protobuf:"bytes,3,opt,name=contents" json:"contents,omitempty"`
+	Attachments      []string `protobuf:"bytes,4,rep,name=attachments" json:"attachments,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
 }
 
 func (m *TextBitmessage) Reset()                    { *m = TextBitmessage{} }
 func (m *TextBitmessage) String() string            { return proto.CompactTextString(m) }
 func (*TextBitmessage) ProtoMessage()               {}
-func (*TextBitmessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*TextBitmessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
 
 func (m *TextBitmessage) GetVersion() uint32 {
 	if m != nil && m.Version != nil {
@@ -1455,6 +2925,13 @@ func (m *TextBitmessage) GetContents() string {
 	return ""
 }
 
+func (m *TextBitmessage) GetAttachments() []string {
+	if m != nil {
+		return m.Attachments
+	}
+	return nil
+}
+
 type HelpRequest struct {
 	Version          *uint32  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
 	Requests         []string `protobuf:"bytes,2,rep,name=requests" json:"requests,omitempty"`
@@ -1464,7 +2941,7 @@ type HelpRequest struct {
 func (m *HelpRequest) Reset()                    { *m = HelpRequest{} }
 func (m *HelpRequest) String() string            { return proto.CompactTextString(m) }
 func (*HelpRequest) ProtoMessage()               {}
-func (*HelpRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*HelpRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
 
 func (m *HelpRequest) GetVersion() uint32 {
 	if m != nil && m.Version != nil {
@@ -1489,7 +2966,7 @@ type HelpReply struct {
 func (m *HelpReply) Reset()                    { *m = HelpReply{} }
 func (m *HelpReply) String() string            { return proto.CompactTextString(m) }
 func (*HelpReply) ProtoMessage()               {}
-func (*HelpReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (*HelpReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
 
 func (m *HelpReply) GetVersion() uint32 {
 	if m != nil && m.Version != nil {
@@ -1515,8 +2992,32 @@ func init() {
 	proto.RegisterType((*BitmessageSelector)(nil), "rpc.BitmessageSelector")
 	proto.RegisterType((*SendBitmessageRequest)(nil), "rpc.SendBitmessageRequest")
 	proto.RegisterType((*ListAddressesRequest)(nil), "rpc.ListAddressesRequest")
+	proto.RegisterType((*GetMessagesRequest)(nil), "rpc.GetMessagesRequest")
+	proto.RegisterType((*SearchRequest)(nil), "rpc.SearchRequest")
+	proto.RegisterType((*RebuildIndexRequest)(nil), "rpc.RebuildIndexRequest")
+	proto.RegisterType((*SendDraftRequest)(nil), "rpc.SendDraftRequest")
+	proto.RegisterType((*QuoteRequest)(nil), "rpc.QuoteRequest")
+	proto.RegisterType((*PowQueueRequest)(nil), "rpc.PowQueueRequest")
+	proto.RegisterType((*PowPolicyRequest)(nil), "rpc.PowPolicyRequest")
+	proto.RegisterType((*BenchmarkRequest)(nil), "rpc.BenchmarkRequest")
+	proto.RegisterType((*ApproveRequest)(nil), "rpc.ApproveRequest")
+	proto.RegisterType((*SubscribeRequest)(nil), "rpc.SubscribeRequest")
 	proto.RegisterType((*NewAddressReply)(nil), "rpc.NewAddressReply")
 	proto.RegisterType((*ListAddressesReply)(nil), "rpc.ListAddressesReply")
+	proto.RegisterType((*GetMessagesReply)(nil), "rpc.GetMessagesReply")
+	proto.RegisterType((*SearchReply)(nil), "rpc.SearchReply")
+	proto.RegisterType((*SearchResult)(nil), "rpc.SearchResult")
+	proto.RegisterType((*RebuildIndexReply)(nil), "rpc.RebuildIndexReply")
+	proto.RegisterType((*SendDraftReply)(nil), "rpc.SendDraftReply")
+	proto.RegisterType((*QuoteReply)(nil), "rpc.QuoteReply")
+	proto.RegisterType((*RecipientQuote)(nil), "rpc.RecipientQuote")
+	proto.RegisterType((*PowQueueReply)(nil), "rpc.PowQueueReply")
+	proto.RegisterType((*PowPolicyReply)(nil), "rpc.PowPolicyReply")
+	proto.RegisterType((*BenchmarkReply)(nil), "rpc.BenchmarkReply")
+	proto.RegisterType((*ApproveReply)(nil), "rpc.ApproveReply")
+	proto.RegisterType((*SubscribeReply)(nil), "rpc.SubscribeReply")
+	proto.RegisterType((*PowRate)(nil), "rpc.PowRate")
+	proto.RegisterType((*PowOrder)(nil), "rpc.PowOrder")
 	proto.RegisterType((*BitmessageIdentity)(nil), "rpc.BitmessageIdentity")
 	proto.RegisterType((*Bitmessage)(nil), "rpc.Bitmessage")
 	proto.RegisterType((*TextBitmessage)(nil), "rpc.TextBitmessage")
@@ -1604,86 +3105,150 @@ var _BMAgentRPC_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1287 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x9c, 0x57, 0xcf, 0x6e, 0x22, 0x47,
-	0x13, 0x5f, 0x60, 0x6c, 0xa0, 0x6c, 0x30, 0xee, 0xb5, 0xbd, 0xb3, 0xd6, 0x6a, 0xb5, 0x1a, 0x7d,
-	0xf2, 0x7e, 0xb1, 0x14, 0x6b, 0x17, 0x29, 0xab, 0x1c, 0x22, 0x45, 0x03, 0xcc, 0x1a, 0x14, 0x0c,
-	0x4e, 0x0f, 0x6c, 0xd6, 0x39, 0x6c, 0x32, 0x40, 0xcb, 0x9e, 0x18, 0x33, 0x64, 0x66, 0x58, 0x87,
-	0x4b, 0x5e, 0x20, 0x6f, 0x93, 0x43, 0x5e, 0x20, 0x97, 0x28, 0x87, 0x3c, 0x42, 0x1e, 0x24, 0xa7,
-	0x54, 0xf7, 0xf4, 0x30, 0x7f, 0xc0, 0x58, 0xce, 0x89, 0xee, 0xaa, 0x5f, 0x55, 0x75, 0x55, 0xfd,
-	0xaa, 0x7b, 0x80, 0xa2, 0x3b, 0x1d, 0x9e, 0x4c, 0x5d, 0xc7, 0x77, 0x48, 0x0e, 0x97, 0xda, 0xdf,
-	0x19, 0x28, 0xd5, 0x6c, 0xff, 0x86, 0x79, 0x9e, 0x75, 0xc9, 0xe8, 0x79, 0x9d, 0xa8, 0x90, 0xff,
-	0xc8, 0x5c, 0xcf, 0x76, 0x26, 0x6a, 0xe6, 0x45, 0xe6, 0xff, 0x25, 0x1a, 0x6e, 0xc9, 0x31, 0x28,
-	0xfe, 0x7c, 0xca, 0xd4, 0x2c, 0x8a, 0xcb, 0xd5, 0x83, 0x13, 0xee, 0x2a, 0x61, 0xdb, 0x43, 0x2d,
-	0x15, 0x18, 0xf2, 0x29, 0xe4, 0x5d, 0xf6, 0xe3, 0x8c, 0x79, 0xbe, 0x9a, 0x43, 0xf8, 0x56, 0x75,
-	0x37, 0x80, 0x9f, 0x21, 0x8c, 0x06, 0x8a, 0xe6, 0x23, 0x1a, 0x62, 0xc8, 0x4b, 0xd8, 0x70, 0xd9,
-	0x74, 0x3c, 0x57, 0x15, 0x01, 0xde, 0x89, 0x83, 0x51, 0x8c, 0xd0, 0x40, 0x4f, 0xfe, 0x07, 0xca,
-	0x74, 0xe6, 0x5d, 0xa9, 0x1b, 0x02, 0x57, 0x8e, 0x70, 0xe7, 0x28, 0x45, 0x98, 0xd0, 0xd6, 0x8a,
-	0x90, 0x9f, 0x5a, 0xf3, 0xb1, 0x63, 0x8d, 0xb4, 0xdf, 0xb2, 0xb0, 0x1d, 0x8f, 0xba, 0x26, 0xbf,
-	0x32, 0x64, 0xed, 0x91, 0xc8, 0xae, 0x48, 0x71, 0x45, 0x0e, 0x60, 0x73, 0xe8, 0x38, 0xd7, 0x36,
-	0x13, 0x29, 0x6c, 0x53, 0xb9, 0xe3, 0xf2, 0xe9, 0x6c, 0x70, 0xcd, 0x82, 0xd3, 0xa2, 0x3c, 0xd8,
-	0x91, 0x67, 0x50, 0xf4, 0xec, 0xcb, 0x89, 0xe5, 0xcf, 0x5c, 0xa6, 0x6e, 0x0a, 0x55, 0x24, 0x20,
-	0x9f, 0x03, 0x4c, 0xd8, 0xad, 0x35, 0x1a, 0xb9, 0x58, 0x2f, 0xb5, 0x28, 0xce, 0x1f, 0xd4, 0xb0,
-	0xc3, 0x6e, 0xf5, 0x40, 0x1c, 0x55, 0x26, 0x86, 0x25, 0x47, 0xa0, 0x5c, 0xb1, 0xf1, 0x54, 0xdd,
-	0x16, 0x36, 0x15, 0x61, 0xd3, 0x44, 0x41, 0x84, 0x16, 0x7a, 0xa2, 0x43, 0x69, 0x6c, 0x7b, 0xbe,
-	0x34, 0x63, 0x9e, 0x5a, 0x12, 0x06, 0x4f, 0x85, 0x41, 0x1b, 0x35, 0x7a, 0xa8, 0x89, 0x2c, 0x93,
-	0x16, 0xbc, 0x70, 0xb2, 0x25, 0xda, 0x2f, 0x39, 0x80, 0xa8, 0x03, 0x0f, 0x28, 0x1b, 0x96, 0x41,
-	0xfa, 0x40, 0x71, 0x4e, 0x88, 0x23, 0x01, 0xa9, 0xc2, 0x26, 0x06, 0xf4, 0x67, 0x9e, 0x68, 0x61,
-	0xb9, 0x7a, 0x98, 0xa6, 0x11, 0x8f, 0x66, 0x0a, 0x04, 0x95, 0xc8, 0x7b, 0x0a, 0xfb, 0x1a, 0x80,
-	0xb9, 0xae, 0xe3, 0x0a, 0x4b, 0xb5, 0x10, 0x23, 0x90, 0xb1, 0x10, 0xf3, 0x8a, 0x46, 0x20, 0xf2,
-	0x66, 0x45, 0x2f, 0xf6, 0x96, 0x7a, 0x21, 0xed, 0x62, 0x9d, 0xf8, 0x32, 0x5d, 0x61, 0x10, 0xa6,
-	0x4f, 0x56, 0x55, 0x38, 0xb0, 0x4e, 0xe2, 0xc9, 0x09, 0x14, 0xaf, 0x44, 0xe7, 0xf8, 0x51, 0xb7,
-	0x62, 0x1c, 0x6e, 0x86, 0x52, 0xb4, 0x89, 0x20, 0xb5, 0xbc, 0x9c, 0x0b, 0xed, 0x0b, 0x80, 0x28,
-	0x9b, 0x35, 0xcd, 0xd8, 0x83, 0x0d, 0x91, 0xa7, 0xec, 0x47, 0xb0, 0xd1, 0xce, 0xa1, 0xb8, 0x18,
-	0x92, 0x35, 0xc6, 0x9f, 0x40, 0x5e, 0x76, 0x01, 0xcb, 0x98, 0x8b, 0xe6, 0x30, 0x6a, 0x4e, 0xa8,
-	0xd7, 0x7e, 0xcf, 0xc2, 0xee, 0x12, 0x6f, 0xd7, 0xb8, 0x3e, 0x82, 0xb2, 0xac, 0x42, 0x08, 0xc8,
-	0x0a, 0x40, 0x4a, 0xca, 0xcf, 0x3f, 0xb6, 0x06, 0x6c, 0x2c, 0x89, 0x13, 0x6c, 0xf8, 0xc4, 0x79,
-	0xbe, 0xcb, 0xac, 0x1b, 0x31, 0x71, 0x25, 0x2a, 0x77, 0xa4, 0x02, 0xb9, 0xa9, 0x73, 0x2b, 0x98,
-	0x54, 0xa2, 0x7c, 0x89, 0x05, 0x26, 0x13, 0x67, 0x32, 0x64, 0xbe, 0x6b, 0x5b, 0x63, 0x6f, 0xca,
-	0xdc, 0xc1, 0xdc, 0x67, 0xa2, 0xd2, 0x25, 0xba, 0x42, 0x43, 0x9e, 0x23, 0x79, 0x7e, 0xf2, 0x5d,
-	0x8b, 0x6f, 0x3c, 0xc1, 0xad, 0x12, 0x8d, 0x49, 0x78, 0x84, 0xd1, 0xcd, 0x58, 0xcd, 0xa3, 0xa2,
-	0x40, 0xf9, 0x12, 0x6f, 0xa0, 0xd2, 0x88, 0xf9, 0xcc, 0xbd, 0xb1, 0x27, 0xd8, 0x5a, 0x7b, 0x28,
-	0x18, 0x57, 0xa0, 0x49, 0x21, 0x21, 0xa0, 0x78, 0x8c, 0x8d, 0x04, 0xb7, 0xb6, 0xa9, 0x58, 0x73,
-	0x5f, 0xd6, 0xf0, 0x5a, 0x70, 0x06, 0x7d, 0xe1, 0x52, 0xfb, 0x35, 0x03, 0x24, 0xaa, 0xae, 0xc9,
-	0xc6, 0x6c, 0xe8, 0x3b, 0xee, 0x9a, 0x32, 0xa2, 0x26, 0x64, 0x6d, 0xd0, 0xe0, 0x70, 0x2b, 0xa7,
-	0x30, 0x87, 0x6d, 0x0b, 0xa6, 0xf0, 0x48, 0x5e, 0xd6, 0x8a, 0x98, 0x32, 0x22, 0x1a, 0x19, 0xcc,
-	0x96, 0x8c, 0x22, 0x2f, 0xea, 0x57, 0x50, 0xf0, 0xa4, 0x44, 0x4e, 0x64, 0x30, 0x08, 0x67, 0xc9,
-	0x33, 0xd1, 0x05, 0x4a, 0xfb, 0x2b, 0x03, 0xfb, 0x26, 0x9b, 0x8c, 0xe2, 0x33, 0x7b, 0x5f, 0xfb,
-	0x79, 0x03, 0xd1, 0x84, 0x85, 0xbc, 0x94, 0xbb, 0xe0, 0xae, 0x18, 0xda, 0x53, 0x9b, 0x4d, 0x7c,
-	0x79, 0xf8, 0x48, 0xc0, 0xb5, 0x03, 0x17, 0x2f, 0xf1, 0xa1, 0x85, 0xcf, 0x88, 0x22, 0xca, 0x16,
-	0x09, 0x78, 0x39, 0x7d, 0x7f, 0x2c, 0x0e, 0xad, 0x50, 0xbe, 0x44, 0xfe, 0x2a, 0x3e, 0xf6, 0x4e,
-	0xb4, 0x71, 0xab, 0xfa, 0x58, 0xe4, 0xd1, 0x43, 0x41, 0x74, 0x52, 0x7e, 0x57, 0x72, 0x48, 0x0d,
-	0xa0, 0x30, 0x74, 0x26, 0x3e, 0x46, 0xf1, 0xb4, 0x57, 0xb0, 0xb7, 0xea, 0x76, 0xbc, 0x3b, 0x1d,
-	0xed, 0x03, 0xec, 0xa4, 0x2e, 0x8a, 0x35, 0xb9, 0xbf, 0x4e, 0xf6, 0x2c, 0xbc, 0x2e, 0xa2, 0x43,
-	0xb5, 0x46, 0x78, 0x0c, 0xdb, 0x9f, 0x2f, 0x9a, 0xa9, 0x31, 0x20, 0xcb, 0xb7, 0xc9, 0x9a, 0x10,
-	0x9f, 0x41, 0x31, 0xba, 0x93, 0xb2, 0x62, 0x74, 0xef, 0x0c, 0x12, 0x21, 0xb5, 0x3f, 0x13, 0xf4,
-	0x0b, 0x11, 0xff, 0x89, 0x7e, 0xab, 0xe7, 0xf6, 0x10, 0x0a, 0x03, 0x76, 0x65, 0x7d, 0xb4, 0x91,
-	0x5c, 0xc1, 0x6c, 0x2d, 0xf6, 0x77, 0x4c, 0x6a, 0x5e, 0x74, 0xf3, 0xfe, 0x49, 0x2d, 0x08, 0x5c,
-	0x4c, 0xa2, 0xfd, 0x91, 0xc1, 0xf7, 0x6a, 0x91, 0xcc, 0xc3, 0x9e, 0x79, 0xc9, 0xcd, 0xdc, 0xdd,
-	0xdc, 0x54, 0xc2, 0x77, 0x2c, 0xe4, 0xe6, 0x4b, 0x39, 0x5f, 0xc1, 0xcc, 0x3c, 0x4e, 0x55, 0x3b,
-	0xf6, 0x25, 0xf4, 0x00, 0x52, 0x6e, 0x82, 0x32, 0x70, 0x46, 0x73, 0xed, 0x7b, 0x28, 0x27, 0x11,
-	0xeb, 0x5b, 0xe2, 0xcd, 0x06, 0x3f, 0xe0, 0x68, 0x86, 0x2d, 0x91, 0x5b, 0x5e, 0xfc, 0x90, 0xe2,
-	0x32, 0xb3, 0x88, 0xf2, 0x75, 0xd8, 0x8a, 0x7d, 0x41, 0xac, 0x71, 0x8f, 0x4e, 0xe4, 0xdb, 0x1d,
-	0x10, 0x0b, 0x9d, 0x84, 0x7b, 0xad, 0x05, 0xc5, 0xc5, 0xb3, 0xb5, 0xc6, 0x85, 0x06, 0xdb, 0xf6,
-	0x04, 0x2f, 0xec, 0xd9, 0xd0, 0xc7, 0x6d, 0xe8, 0x26, 0x21, 0x3b, 0xfe, 0x00, 0xbb, 0x4b, 0x5f,
-	0x92, 0x64, 0x07, 0xb6, 0xc4, 0xab, 0xf5, 0x9d, 0x41, 0x69, 0x97, 0x56, 0x1e, 0x91, 0x5d, 0xfc,
-	0x56, 0x15, 0x02, 0x6a, 0x7c, 0xdd, 0x37, 0xcc, 0x5e, 0x25, 0x13, 0x61, 0xa8, 0x71, 0xde, 0xbe,
-	0xa8, 0x64, 0x91, 0x88, 0x95, 0x40, 0x70, 0xde, 0x37, 0x9b, 0x9d, 0x6e, 0xaf, 0xf5, 0xf6, 0xa2,
-	0x92, 0x3b, 0xfe, 0x19, 0xf6, 0x57, 0x7e, 0x62, 0x90, 0x7d, 0x0c, 0xcc, 0xe1, 0x66, 0x4f, 0xef,
-	0xf5, 0xcd, 0x45, 0xa4, 0x27, 0xf0, 0x38, 0x2e, 0x36, 0xfb, 0xf5, 0xba, 0x61, 0x9a, 0x18, 0xef,
-	0x19, 0xa8, 0x71, 0x45, 0xbf, 0xa3, 0xf7, 0x7b, 0xcd, 0x2e, 0x6d, 0x7d, 0x6b, 0x34, 0x30, 0x78,
-	0xca, 0xac, 0xd5, 0x79, 0xa7, 0xb7, 0x5b, 0x0d, 0x8c, 0xff, 0x1e, 0xca, 0x49, 0x72, 0x88, 0x73,
-	0xb6, 0x7a, 0x67, 0xe8, 0x55, 0x3f, 0x35, 0x16, 0x71, 0x0f, 0x70, 0x20, 0x23, 0xa9, 0xfc, 0xc5,
-	0xb0, 0x2a, 0xec, 0xc5, 0xe4, 0x35, 0xda, 0xd5, 0x1b, 0x75, 0x1d, 0x0b, 0x90, 0x3d, 0xfe, 0x27,
-	0x03, 0x3b, 0xa9, 0xbb, 0x9a, 0x3c, 0x85, 0x7d, 0x09, 0x35, 0x8d, 0xb6, 0x51, 0xef, 0x75, 0xe9,
-	0x22, 0xc0, 0x73, 0x38, 0x4c, 0xab, 0x5a, 0x9d, 0x46, 0xeb, 0x5d, 0xab, 0xd1, 0xd7, 0xdb, 0x18,
-	0xe8, 0x10, 0x0e, 0xd2, 0xfa, 0x7e, 0x87, 0x1a, 0x3a, 0xcf, 0x0e, 0x0f, 0x91, 0xd6, 0x09, 0x4d,
-	0x8e, 0x57, 0x65, 0xd9, 0x6b, 0xbd, 0x7b, 0xd6, 0xea, 0x9c, 0x56, 0x94, 0x55, 0x76, 0xa6, 0xd1,
-	0xe9, 0x55, 0x36, 0xc8, 0x0b, 0x78, 0x96, 0xd6, 0xe8, 0xf5, 0xaf, 0x3a, 0xdd, 0x6f, 0xda, 0x46,
-	0xe3, 0x14, 0x2b, 0xba, 0xb9, 0xca, 0x73, 0xb7, 0xdf, 0x3b, 0xed, 0x72, 0xcf, 0xf9, 0xe3, 0x0b,
-	0x28, 0x25, 0xde, 0x34, 0xde, 0x00, 0x41, 0x84, 0xa5, 0xbc, 0x97, 0x14, 0x98, 0xb5, 0xf1, 0x1e,
-	0x13, 0xc6, 0x8a, 0x27, 0x15, 0x6f, 0xfb, 0xed, 0x76, 0x25, 0x5b, 0x6d, 0xf0, 0xaf, 0x5f, 0xfd,
-	0x12, 0xa7, 0x85, 0xff, 0x29, 0x7a, 0x83, 0xfd, 0x93, 0x3b, 0x39, 0x32, 0xcb, 0xff, 0x67, 0x0e,
-	0xd3, 0xff, 0x5a, 0xb4, 0x47, 0xb5, 0x6c, 0x33, 0xf7, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xb5,
-	0x8c, 0x8e, 0xb3, 0x73, 0x0d, 0x00, 0x00,
+	// 2314 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xcc, 0x59, 0x4d, 0x73, 0xdb, 0xc6,
+	0x19, 0x36, 0x48, 0x8a, 0x1f, 0xaf, 0x48, 0x0a, 0x5a, 0x49, 0x36, 0xa2, 0x71, 0x52, 0x0d, 0x26,
+	0x4d, 0x6c, 0x25, 0xb1, 0x1d, 0x79, 0x92, 0x49, 0xdb, 0x4c, 0x3b, 0x94, 0x48, 0x5b, 0x6c, 0x65,
+	0x51, 0x5e, 0x92, 0x71, 0xdc, 0x43, 0x34, 0x20, 0xb1, 0x11, 0x51, 0x91, 0x00, 0x0c, 0x2c, 0x4d,
+	0xb1, 0x87, 0x76, 0xa6, 0x87, 0x1e, 0xfb, 0x5f, 0xda, 0x1f, 0xd0, 0x4b, 0x0f, 0x9d, 0xe9, 0x4c,
+	0xfb, 0x13, 0xfa, 0x03, 0xfa, 0x13, 0x3a, 0x3d, 0x74, 0x76, 0xb1, 0xc0, 0x2e, 0x20, 0x1a, 0xfe,
+	0x98, 0x1e, 0x7a, 0x22, 0xde, 0xaf, 0xdd, 0x77, 0x3f, 0x9e, 0x77, 0x9f, 0x5d, 0x42, 0x2d, 0xf0,
+	0xc7, 0xf7, 0xfc, 0xc0, 0xa3, 0x1e, 0x2a, 0x06, 0xfe, 0xd8, 0xfc, 0xa7, 0x06, 0x8d, 0x43, 0x87,
+	0xce, 0x48, 0x18, 0x5a, 0x17, 0x04, 0x9f, 0x1d, 0x21, 0x03, 0x2a, 0x2f, 0x49, 0x10, 0x3a, 0x9e,
+	0x6b, 0x68, 0x7b, 0xda, 0x9d, 0x06, 0x8e, 0x45, 0xb4, 0x0f, 0x25, 0xba, 0xf4, 0x89, 0x51, 0xd8,
+	0xd3, 0xee, 0x34, 0x0f, 0x6e, 0xde, 0x63, 0x4d, 0xa5, 0x62, 0x07, 0x4b, 0x9f, 0x60, 0xee, 0x83,
+	0x3e, 0x83, 0x4a, 0x40, 0x5e, 0xcc, 0x49, 0x48, 0x8d, 0xe2, 0x9e, 0x76, 0x67, 0xfd, 0x60, 0x33,
+	0x72, 0x7f, 0x82, 0xcf, 0x8e, 0x70, 0x64, 0x38, 0xbe, 0x81, 0x63, 0x1f, 0xf4, 0x31, 0xac, 0x05,
+	0xc4, 0x9f, 0x2e, 0x8d, 0x12, 0x77, 0xde, 0x50, 0x9d, 0xfd, 0xe9, 0xf2, 0xf8, 0x06, 0x8e, 0xec,
+	0xe8, 0x43, 0x28, 0xf9, 0xf3, 0x70, 0x62, 0xac, 0x71, 0xbf, 0xa6, 0xf4, 0x3b, 0x9b, 0x87, 0x93,
+	0xe3, 0x1b, 0x98, 0x5b, 0x0f, 0x6b, 0x50, 0xf1, 0xad, 0xe5, 0xd4, 0xb3, 0x6c, 0xf3, 0xef, 0x65,
+	0xa8, 0xab, 0xbd, 0xe6, 0x8c, 0xaf, 0x09, 0x05, 0xc7, 0xe6, 0xa3, 0xab, 0xe1, 0x82, 0x63, 0xa3,
+	0x9b, 0x50, 0x1e, 0x7b, 0xde, 0xa5, 0x43, 0xf8, 0x10, 0xea, 0x58, 0x48, 0x4c, 0xef, 0xcf, 0x47,
+	0x97, 0x24, 0xca, 0xb6, 0x8e, 0x85, 0x84, 0x6e, 0x43, 0x2d, 0x74, 0x2e, 0x5c, 0x8b, 0xce, 0x03,
+	0x62, 0x94, 0xb9, 0x49, 0x2a, 0xd0, 0x57, 0x00, 0x2e, 0x59, 0x58, 0xb6, 0x1d, 0x90, 0x30, 0x34,
+	0x6a, 0x3c, 0xff, 0x68, 0x0e, 0x4f, 0xc9, 0xa2, 0x15, 0xa9, 0xe5, 0xcc, 0x28, 0xbe, 0xe8, 0x23,
+	0x28, 0x4d, 0xc8, 0xd4, 0x37, 0xea, 0x3c, 0x46, 0xe7, 0x31, 0xc7, 0x64, 0xea, 0x4b, 0x6f, 0x6e,
+	0x47, 0x2d, 0x68, 0x4c, 0x9d, 0x90, 0x8a, 0x30, 0x12, 0x1a, 0x0d, 0x1e, 0xf0, 0x1e, 0x0f, 0x38,
+	0x71, 0x42, 0xda, 0x8a, 0x2d, 0x32, 0x32, 0x1d, 0x81, 0x7e, 0x02, 0xeb, 0x17, 0x24, 0x5e, 0xd1,
+	0xd0, 0x68, 0xf2, 0x06, 0x6e, 0xf1, 0x06, 0x1e, 0x13, 0xfa, 0x44, 0xe8, 0x65, 0xb8, 0xea, 0x8d,
+	0x3e, 0x85, 0x72, 0x48, 0xac, 0x60, 0x3c, 0x31, 0x36, 0x78, 0x1c, 0xe2, 0x71, 0x7d, 0xae, 0x92,
+	0x21, 0xc2, 0x07, 0xfd, 0x14, 0xea, 0x01, 0x19, 0xcd, 0x9d, 0xa9, 0xed, 0xb8, 0x36, 0xb9, 0x32,
+	0x74, 0x1e, 0x63, 0xf0, 0x18, 0x1c, 0x19, 0xba, 0xcc, 0x20, 0x23, 0x53, 0xfe, 0xe8, 0x0b, 0xa8,
+	0x85, 0xc4, 0xb5, 0xed, 0xc0, 0xfa, 0x9e, 0x1a, 0x9b, 0x3c, 0x78, 0x47, 0x74, 0xe8, 0xda, 0x6d,
+	0xa6, 0x95, 0x91, 0xd2, 0x13, 0xdd, 0x85, 0xb5, 0x17, 0x73, 0x8f, 0x12, 0x03, 0x29, 0xdb, 0xf2,
+	0x29, 0xd3, 0x48, 0xf7, 0xc8, 0x03, 0x1d, 0x40, 0xd5, 0xf7, 0x16, 0x2f, 0xe6, 0x64, 0x4e, 0x8c,
+	0x2d, 0xee, 0xbd, 0xcd, 0xbd, 0xcf, 0xbc, 0xc5, 0x53, 0xa6, 0x94, 0x01, 0x89, 0x1f, 0xcb, 0xca,
+	0xf7, 0x16, 0xbe, 0x37, 0x75, 0xc6, 0x4b, 0x63, 0x5b, 0xc9, 0xea, 0xcc, 0x5b, 0x9c, 0x71, 0xad,
+	0x92, 0x55, 0xe2, 0xc9, 0xc2, 0x46, 0xc4, 0x1d, 0x4f, 0x66, 0x56, 0x70, 0x69, 0xec, 0x28, 0x61,
+	0x87, 0xb1, 0x56, 0x09, 0x4b, 0x3c, 0xd1, 0x7d, 0xa8, 0x58, 0xbe, 0x1f, 0x78, 0x2f, 0x89, 0x71,
+	0x93, 0x07, 0x6d, 0xf1, 0xa0, 0x56, 0xa4, 0x53, 0x70, 0x26, 0xbc, 0xf8, 0xa4, 0xcd, 0x47, 0xe1,
+	0x38, 0x70, 0x46, 0xc4, 0xb8, 0xa5, 0x4e, 0x5a, 0xac, 0x55, 0x27, 0x2d, 0xd6, 0x31, 0x3c, 0x09,
+	0xa4, 0x9a, 0x7f, 0xa8, 0x00, 0x48, 0x60, 0xbe, 0x05, 0x9a, 0x6e, 0x43, 0x4d, 0xb4, 0xe1, 0xd8,
+	0x1c, 0x50, 0x35, 0x2c, 0x15, 0xe8, 0x00, 0xca, 0x21, 0xb5, 0xe8, 0x3c, 0xe4, 0xc8, 0x6e, 0x1e,
+	0xec, 0x66, 0xab, 0x0b, 0xeb, 0xad, 0xcf, 0x3d, 0xb0, 0xf0, 0x7c, 0x0d, 0xde, 0x3e, 0x07, 0x20,
+	0x41, 0xe0, 0x05, 0x3c, 0xd2, 0xa8, 0x2a, 0x75, 0xa5, 0x93, 0xa8, 0x19, 0xd0, 0xa4, 0x13, 0xfa,
+	0x72, 0x05, 0x44, 0xb7, 0xaf, 0x41, 0x54, 0xc4, 0x49, 0x4f, 0xf4, 0xb3, 0x2c, 0xf0, 0x40, 0xc1,
+	0x4d, 0x06, 0x78, 0x51, 0x74, 0x06, 0x76, 0xf7, 0xa0, 0x36, 0xe1, 0x80, 0x66, 0xa9, 0xae, 0x2b,
+	0xa5, 0xed, 0x38, 0xd6, 0xb2, 0xf5, 0x48, 0x5c, 0xd0, 0x8f, 0xd2, 0x30, 0xad, 0x2b, 0x0b, 0x99,
+	0x82, 0x69, 0x14, 0x98, 0x02, 0xe9, 0x7e, 0x02, 0xd2, 0x86, 0x52, 0x4e, 0x62, 0x90, 0x46, 0x01,
+	0x31, 0x44, 0xbf, 0xce, 0x40, 0xb4, 0xa9, 0x14, 0xad, 0x34, 0x44, 0xa3, 0xb8, 0x34, 0x40, 0x1f,
+	0xaa, 0x00, 0xdd, 0x50, 0xb6, 0xa7, 0x02, 0x50, 0x31, 0x32, 0x09, 0xcf, 0x8f, 0x63, 0x78, 0xea,
+	0xca, 0x82, 0x09, 0x78, 0x8a, 0x83, 0x20, 0x02, 0xe7, 0x03, 0x05, 0x9c, 0x9b, 0x4a, 0xb9, 0x91,
+	0xe0, 0x8c, 0xdc, 0x25, 0x34, 0x1f, 0xaa, 0xd0, 0x44, 0x4a, 0x3e, 0x0a, 0x34, 0x45, 0x3e, 0x12,
+	0x98, 0x0f, 0x55, 0x60, 0x6e, 0x29, 0x41, 0x0a, 0x30, 0x45, 0x90, 0x84, 0xe5, 0x67, 0x12, 0x96,
+	0xdb, 0x4a, 0x95, 0x49, 0x60, 0x19, 0x05, 0x24, 0xa0, 0x7c, 0xa8, 0x82, 0x72, 0x47, 0x9d, 0x28,
+	0x09, 0xca, 0x78, 0xa2, 0x12, 0x48, 0x56, 0xc4, 0x89, 0x69, 0x7e, 0x0d, 0x20, 0x37, 0x74, 0x0e,
+	0x1e, 0xb7, 0x61, 0x8d, 0x6f, 0x75, 0x01, 0xc9, 0x48, 0x30, 0xcf, 0xa0, 0x96, 0x1c, 0x9f, 0x39,
+	0xc1, 0x77, 0xa1, 0x22, 0x76, 0x90, 0x51, 0xdd, 0x2b, 0xca, 0x13, 0x5a, 0xe2, 0x33, 0xb6, 0x9b,
+	0x7f, 0x29, 0xc0, 0xe6, 0xb5, 0x13, 0x2d, 0xa7, 0xe9, 0x8f, 0xa0, 0x29, 0x80, 0x10, 0x3b, 0x14,
+	0xb8, 0x43, 0x46, 0xcb, 0xf2, 0x9f, 0x5a, 0x23, 0x32, 0x15, 0xb5, 0x23, 0x12, 0xd8, 0x59, 0x1c,
+	0xd2, 0x80, 0x58, 0x33, 0x7e, 0x16, 0x37, 0xb0, 0x90, 0x90, 0x0e, 0x45, 0xdf, 0x5b, 0xf0, 0x62,
+	0xd2, 0xc0, 0xec, 0x13, 0xdd, 0x03, 0xe4, 0x7a, 0xee, 0x98, 0xd0, 0xc0, 0xb1, 0xa6, 0xa1, 0x4f,
+	0x82, 0xd1, 0x92, 0x12, 0x0e, 0xb6, 0x06, 0x5e, 0x61, 0x41, 0x1f, 0x00, 0x90, 0x2b, 0x1a, 0x58,
+	0x4c, 0x08, 0x79, 0x79, 0x69, 0x60, 0x45, 0xc3, 0x7a, 0xb0, 0x67, 0x53, 0xa3, 0xb2, 0xa7, 0xdd,
+	0xa9, 0x62, 0xf6, 0x89, 0x3e, 0x84, 0x86, 0x4d, 0x28, 0x09, 0x66, 0x8e, 0xeb, 0x84, 0xd4, 0x19,
+	0xf3, 0xa2, 0x53, 0xc5, 0x69, 0x25, 0x42, 0x50, 0x0a, 0x09, 0xb1, 0x79, 0x79, 0xa9, 0x63, 0xfe,
+	0xcd, 0xda, 0xb2, 0xc6, 0x97, 0xbc, 0x6c, 0x54, 0x31, 0xfb, 0x34, 0xff, 0xa4, 0x01, 0x92, 0xb3,
+	0xdb, 0x27, 0x53, 0x32, 0xa6, 0x5e, 0x90, 0x33, 0x8d, 0x06, 0x54, 0xe2, 0xc2, 0x15, 0x2d, 0x70,
+	0x2c, 0x8a, 0x42, 0x5c, 0xdc, 0x2b, 0x8a, 0x42, 0xfc, 0x91, 0xa0, 0x71, 0x25, 0x5e, 0x68, 0x91,
+	0x40, 0x33, 0x2b, 0xaf, 0xa2, 0x17, 0x41, 0xe1, 0x1e, 0x40, 0x35, 0x14, 0x1a, 0x51, 0x94, 0xa3,
+	0x5a, 0xf8, 0x24, 0x9d, 0x13, 0x4e, 0xbc, 0xcc, 0x7f, 0x68, 0xb0, 0xc3, 0xc0, 0xad, 0x96, 0xed,
+	0xd7, 0x2d, 0x3f, 0x5b, 0x40, 0xe2, 0xda, 0x24, 0xde, 0x97, 0x42, 0x8a, 0x8e, 0x8b, 0xb1, 0xe3,
+	0x3b, 0xc4, 0xa5, 0x22, 0x79, 0xa9, 0x60, 0xd6, 0x51, 0xe0, 0x59, 0xf6, 0xd8, 0x0a, 0x29, 0x1f,
+	0x48, 0x15, 0x4b, 0x05, 0x9b, 0x4e, 0x4a, 0xa7, 0x3c, 0xe9, 0x12, 0x66, 0x9f, 0xe8, 0x2e, 0x94,
+	0x28, 0xb9, 0xa2, 0x46, 0x59, 0x41, 0xd7, 0x80, 0x5c, 0x51, 0x99, 0x29, 0x63, 0x51, 0xcc, 0xe5,
+	0x10, 0xa0, 0x3a, 0xf6, 0x5c, 0x4a, 0x5c, 0x1a, 0x9a, 0x0f, 0x60, 0x7b, 0x15, 0x6f, 0x7a, 0xf5,
+	0x70, 0xcc, 0x11, 0xa0, 0xeb, 0x44, 0x29, 0x7f, 0xf8, 0xdf, 0x7b, 0x53, 0x65, 0xf8, 0x91, 0x84,
+	0x76, 0xa1, 0x7a, 0x49, 0x96, 0x0b, 0x2f, 0xb0, 0x43, 0x31, 0xfa, 0x44, 0x36, 0x87, 0xd0, 0x48,
+	0x91, 0xaa, 0x7c, 0xd0, 0xbf, 0x98, 0x93, 0x60, 0x19, 0x83, 0x9e, 0x0b, 0x1c, 0x4a, 0xce, 0xcc,
+	0x89, 0xa8, 0x79, 0x03, 0x47, 0x82, 0x79, 0x1f, 0xb6, 0x56, 0xf0, 0xae, 0x9c, 0xb1, 0x5e, 0x80,
+	0x9e, 0xe5, 0x5a, 0x6f, 0xc4, 0x07, 0x4a, 0x7c, 0x1b, 0xde, 0x82, 0x0a, 0x5b, 0xea, 0x73, 0x2b,
+	0x4a, 0xa3, 0x14, 0xad, 0x7c, 0x2b, 0x59, 0xbd, 0x12, 0xcf, 0x98, 0x7d, 0x9a, 0x3f, 0x87, 0xba,
+	0xca, 0xd0, 0xde, 0xa2, 0x13, 0xd1, 0x56, 0x51, 0xb6, 0xf5, 0x09, 0x6c, 0x64, 0xf8, 0x5b, 0xce,
+	0x08, 0xff, 0xa5, 0x81, 0x9e, 0x25, 0x6e, 0x39, 0xbd, 0xbf, 0x0f, 0x60, 0xcf, 0xe9, 0xf2, 0x7c,
+	0xbc, 0x1c, 0x4f, 0x89, 0x28, 0x63, 0x35, 0xa6, 0x39, 0x62, 0x0a, 0x74, 0x07, 0x74, 0xdf, 0x9a,
+	0x87, 0xe4, 0xdc, 0x73, 0xcf, 0x47, 0x16, 0xa5, 0x6c, 0x5d, 0x8a, 0x7c, 0xef, 0x36, 0xb9, 0xbe,
+	0xe7, 0x1e, 0x46, 0x5a, 0xf4, 0x1e, 0x54, 0x67, 0xd6, 0xd5, 0x39, 0xbb, 0xc0, 0xf0, 0x79, 0xd0,
+	0x70, 0x65, 0x66, 0x5d, 0x9d, 0x78, 0x96, 0xcd, 0x7a, 0x5f, 0x38, 0xae, 0xed, 0x2d, 0x18, 0x53,
+	0x62, 0xfb, 0x22, 0x16, 0xd1, 0x0f, 0x60, 0x3d, 0x24, 0xf4, 0x3c, 0xb6, 0x96, 0x79, 0xcb, 0x10,
+	0x12, 0xfa, 0x4c, 0x38, 0xbc, 0x0f, 0x30, 0xf5, 0xdc, 0x8b, 0x73, 0x2f, 0x60, 0xfb, 0xad, 0xc2,
+	0x27, 0xa9, 0xc6, 0x34, 0x3d, 0xa6, 0x30, 0x3f, 0x05, 0x3d, 0xcb, 0x36, 0x73, 0xa6, 0xe6, 0xc7,
+	0xd0, 0x4c, 0xd3, 0xcc, 0x37, 0x5f, 0x15, 0xf3, 0x11, 0xe8, 0x59, 0xbe, 0xf9, 0x2e, 0x95, 0xcd,
+	0xfc, 0x0e, 0x36, 0x32, 0xc4, 0x2c, 0xa7, 0x99, 0xcf, 0xd3, 0xcd, 0xc4, 0xf4, 0x4c, 0x56, 0x80,
+	0xae, 0x4d, 0x5c, 0xea, 0xd0, 0xa5, 0x6c, 0x9f, 0x00, 0xba, 0xce, 0xde, 0x72, 0xba, 0xf8, 0x02,
+	0x6a, 0x92, 0x03, 0x16, 0xf6, 0x8a, 0x79, 0x9d, 0x48, 0x4f, 0xf3, 0x39, 0xe8, 0x59, 0xd6, 0x96,
+	0xd3, 0xc9, 0x27, 0x50, 0x4d, 0x88, 0x5f, 0x61, 0xf5, 0x59, 0x9c, 0x38, 0x98, 0x03, 0x58, 0x57,
+	0xa8, 0x5d, 0x6e, 0xab, 0x95, 0x80, 0x84, 0xf3, 0x29, 0x8d, 0x1b, 0xdd, 0x4c, 0xf1, 0x42, 0x66,
+	0xc1, 0xb1, 0x87, 0xe9, 0x40, 0x5d, 0x35, 0xe4, 0xd7, 0x9f, 0x70, 0xec, 0x05, 0x11, 0x18, 0x34,
+	0x1c, 0x09, 0x2a, 0x9b, 0x28, 0xee, 0x69, 0xab, 0x46, 0x90, 0xb0, 0x89, 0x2e, 0x6c, 0x5e, 0x63,
+	0x9a, 0x39, 0xfd, 0xed, 0xa6, 0x26, 0x87, 0x99, 0xe4, 0x5c, 0x3c, 0x83, 0x66, 0x9a, 0x79, 0xfe,
+	0xaf, 0x26, 0xf9, 0x8f, 0x1a, 0x80, 0xa4, 0xa8, 0x39, 0xad, 0x8a, 0x6a, 0x54, 0x90, 0xe7, 0x12,
+	0x23, 0x03, 0xce, 0xaf, 0x89, 0xa8, 0x80, 0xfc, 0x9b, 0x8d, 0x61, 0x62, 0x85, 0x93, 0xc0, 0xa2,
+	0x44, 0x80, 0x3f, 0x91, 0x99, 0x3f, 0x75, 0x66, 0x44, 0x1c, 0x6d, 0xfc, 0x1b, 0x3d, 0x04, 0x48,
+	0x0e, 0x46, 0x06, 0xfb, 0x62, 0x72, 0xc2, 0xe1, 0x58, 0x1d, 0x65, 0xa7, 0xb8, 0x99, 0xbf, 0xd7,
+	0xa0, 0x99, 0x36, 0xbf, 0x13, 0xb7, 0xd8, 0x86, 0xb5, 0x4b, 0xd7, 0x5b, 0xb8, 0xa2, 0x8e, 0x45,
+	0x02, 0x3b, 0xd4, 0xa8, 0x15, 0x5c, 0x90, 0xe8, 0x68, 0x2e, 0x61, 0x21, 0xad, 0xca, 0xde, 0x3c,
+	0x83, 0x46, 0x8a, 0xb2, 0xe7, 0xa4, 0xf1, 0x43, 0x28, 0xf3, 0xd2, 0x15, 0x2f, 0x49, 0x23, 0x66,
+	0xef, 0xbc, 0x7e, 0x61, 0x61, 0x34, 0xff, 0xaa, 0x41, 0x33, 0x4d, 0xe9, 0xff, 0x6f, 0x4b, 0x76,
+	0xba, 0x22, 0x97, 0xb3, 0x15, 0xf9, 0xb7, 0xd0, 0x4c, 0x5f, 0x33, 0x72, 0x06, 0x62, 0xc2, 0x1a,
+	0xdb, 0x21, 0xf1, 0xdc, 0xd4, 0xe3, 0xb9, 0xc1, 0x16, 0x25, 0x38, 0x32, 0xa1, 0xfb, 0xb0, 0x15,
+	0x90, 0xb1, 0x37, 0x9b, 0x31, 0x8a, 0x65, 0x9f, 0xd3, 0x49, 0x40, 0x2c, 0xce, 0x2f, 0x58, 0x4b,
+	0x48, 0x31, 0x0d, 0x22, 0x8b, 0xf9, 0x15, 0xd4, 0xd5, 0x4b, 0xcb, 0x5b, 0x94, 0xf8, 0x36, 0x34,
+	0xd3, 0xb7, 0x97, 0x77, 0x2a, 0xf0, 0xcf, 0xa1, 0x22, 0x86, 0x90, 0x1f, 0x1e, 0x8f, 0x24, 0x5a,
+	0xbf, 0x58, 0x4c, 0x21, 0xa9, 0x98, 0x46, 0x92, 0xf9, 0x67, 0x0d, 0xaa, 0xf1, 0xd6, 0xc9, 0x2f,
+	0x28, 0xbe, 0x17, 0x3a, 0x54, 0xde, 0x4b, 0x12, 0x19, 0x21, 0x41, 0xa4, 0x23, 0x76, 0xc1, 0xbf,
+	0xb9, 0x7f, 0xe0, 0x78, 0x81, 0x43, 0x97, 0x82, 0xc1, 0x24, 0xb2, 0x02, 0x8b, 0xb5, 0x14, 0x2c,
+	0x76, 0xa1, 0x4a, 0x42, 0xea, 0xcc, 0x58, 0x9a, 0xd1, 0x1e, 0x48, 0x64, 0x96, 0x19, 0x99, 0x5a,
+	0x7e, 0x48, 0x6c, 0x71, 0x60, 0xc7, 0xa2, 0xf9, 0xb7, 0xd4, 0x0d, 0x21, 0x3e, 0x57, 0xde, 0x15,
+	0xc5, 0x2b, 0xae, 0x56, 0xbb, 0x50, 0x1d, 0x91, 0x89, 0xf5, 0xd2, 0xf1, 0x02, 0x71, 0xfd, 0x49,
+	0xe4, 0x57, 0x5c, 0xa6, 0xa2, 0x0c, 0x5f, 0x7f, 0x99, 0xaa, 0x72, 0x3f, 0x45, 0x63, 0xfe, 0x47,
+	0x03, 0x90, 0x83, 0x79, 0xbb, 0x37, 0x5a, 0x71, 0x7d, 0x28, 0xbe, 0xfa, 0xfa, 0x50, 0x8a, 0x5f,
+	0x9b, 0x84, 0x02, 0x7d, 0x2c, 0x56, 0x2e, 0xba, 0xd6, 0x6c, 0x65, 0x4a, 0xbb, 0xf2, 0x8c, 0xfd,
+	0xe6, 0xf7, 0x86, 0x14, 0x63, 0xaf, 0xa4, 0x19, 0xbb, 0xc2, 0xf2, 0xab, 0x2a, 0xcb, 0x3f, 0x2c,
+	0x43, 0x69, 0xe4, 0xd9, 0x4b, 0xf3, 0x77, 0x1a, 0x34, 0xd3, 0xcd, 0xe6, 0xaf, 0x63, 0x38, 0x1f,
+	0xfd, 0x8a, 0x8c, 0x69, 0xbc, 0x8e, 0x42, 0x44, 0xbb, 0xf2, 0xea, 0x22, 0xa6, 0x23, 0x91, 0xd1,
+	0x1e, 0xac, 0x5b, 0x94, 0x5a, 0xe3, 0xc9, 0x8c, 0x9b, 0x4b, 0x3c, 0x43, 0x55, 0x65, 0x1e, 0xc1,
+	0xba, 0xf2, 0xaa, 0x9c, 0x8f, 0x09, 0xf1, 0x70, 0x17, 0x55, 0x9b, 0x1a, 0x4e, 0x64, 0xb3, 0x0b,
+	0xb5, 0xe4, 0xcd, 0x2a, 0xb7, 0x5a, 0xd5, 0x1d, 0x37, 0xa4, 0xc1, 0x7c, 0xcc, 0x90, 0x14, 0x37,
+	0x93, 0xd2, 0xed, 0x7f, 0x07, 0x9b, 0xd7, 0xfe, 0x5d, 0x40, 0x1b, 0xb0, 0xce, 0xdf, 0x2b, 0xce,
+	0x3b, 0x18, 0xf7, 0xb0, 0x7e, 0x03, 0x6d, 0x42, 0x23, 0x52, 0xe0, 0xce, 0xd3, 0x61, 0xa7, 0x3f,
+	0xd0, 0x35, 0xe9, 0x83, 0x3b, 0x67, 0x27, 0xcf, 0xf5, 0x02, 0xda, 0x06, 0x3d, 0x52, 0x9c, 0x0d,
+	0xfb, 0xc7, 0xa7, 0xbd, 0x41, 0xf7, 0xd1, 0x73, 0xbd, 0xb8, 0xff, 0x1b, 0xd8, 0x59, 0xf9, 0xbe,
+	0x88, 0x76, 0x60, 0x93, 0xbb, 0xf7, 0x07, 0xad, 0xc1, 0xb0, 0x9f, 0xf4, 0x74, 0x0b, 0xb6, 0x54,
+	0x75, 0x7f, 0x78, 0x74, 0xd4, 0xe9, 0xf7, 0x75, 0x0d, 0xdd, 0x06, 0x43, 0x35, 0x0c, 0x4f, 0x5b,
+	0xc3, 0xc1, 0x71, 0x0f, 0x77, 0x7f, 0xd9, 0x69, 0xeb, 0x85, 0x6c, 0x58, 0xf7, 0xf4, 0x9b, 0xd6,
+	0x49, 0xb7, 0xad, 0x17, 0xf7, 0xbf, 0x85, 0x66, 0x7a, 0xcf, 0xf1, 0x3c, 0xbb, 0x83, 0x27, 0x9d,
+	0x7e, 0xbf, 0xf5, 0xb8, 0x93, 0xf4, 0x7b, 0x13, 0x90, 0xa2, 0x15, 0xbf, 0xba, 0x86, 0x0c, 0xd8,
+	0x56, 0xf4, 0x87, 0xb8, 0xd7, 0x6a, 0x1f, 0xb5, 0xfa, 0x03, 0xbd, 0xb0, 0xff, 0x6f, 0x0d, 0x36,
+	0x32, 0xb7, 0x74, 0xf4, 0x1e, 0xec, 0x08, 0xd7, 0x7e, 0xe7, 0xa4, 0x73, 0x34, 0xe8, 0xe1, 0xa4,
+	0x83, 0x0f, 0x60, 0x37, 0x6b, 0xea, 0x9e, 0xb6, 0xbb, 0xdf, 0x74, 0xdb, 0xc3, 0xd6, 0x89, 0xae,
+	0xa1, 0x5d, 0xb8, 0x99, 0xb5, 0x0f, 0x4f, 0x71, 0xa7, 0xc5, 0x46, 0x67, 0xc0, 0x76, 0xd6, 0xc6,
+	0x2d, 0x45, 0x36, 0x2b, 0xd7, 0x5b, 0x3d, 0xea, 0x3d, 0xe9, 0x9e, 0x3e, 0xd6, 0x4b, 0xab, 0xe2,
+	0xfa, 0x9d, 0xd3, 0x81, 0xbe, 0x86, 0xf6, 0xe0, 0x76, 0xd6, 0xd2, 0x3a, 0xfa, 0xc5, 0x69, 0xef,
+	0xd9, 0x49, 0xa7, 0xfd, 0xb8, 0xd3, 0xd6, 0xcb, 0xab, 0x5a, 0xee, 0x0d, 0x07, 0x8f, 0x7b, 0xac,
+	0xe5, 0xca, 0xfe, 0x73, 0x68, 0xa4, 0x5e, 0x33, 0xd8, 0x02, 0xf0, 0x8d, 0x70, 0x6d, 0xdc, 0xd7,
+	0x0c, 0xdd, 0xd3, 0x76, 0xe7, 0x5b, 0x5d, 0x63, 0x33, 0x9e, 0x36, 0x3c, 0x1a, 0x9e, 0x9c, 0xe8,
+	0x85, 0x83, 0x36, 0x7b, 0xfa, 0x6e, 0x5d, 0x10, 0x97, 0xb2, 0x3f, 0xca, 0xbe, 0x84, 0x66, 0x2c,
+	0x09, 0xc8, 0x5c, 0xff, 0x8f, 0x6b, 0x37, 0xfb, 0x4f, 0x96, 0x79, 0xe3, 0xb0, 0x70, 0x5c, 0xfc,
+	0xef, 0x00, 0xb0, 0xba, 0xae, 0x6c, 0x87, 0x1b, 0x00, 0x00,
 }
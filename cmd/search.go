@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+// DefaultSearchLimit is the number of results returned by a search if no
+// limit is given.
+const DefaultSearchLimit = 20
+
+// SearchResult is a message found by a full-text search.
+type SearchResult struct {
+	Message *email.Bmail
+	Score   float64
+}
+
+type searchResponse struct {
+	results []SearchResult
+}
+
+type searchCommand struct {
+	query string
+	limit uint32
+}
+
+func (r *searchCommand) Execute(u User) (Response, error) {
+	results, err := u.Search(r.query, r.limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &searchResponse{
+		results: results,
+	}, nil
+}
+
+func (r *searchCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Search{
+			Search: &rpc.SearchRequest{
+				Version: &version,
+				Query:   &r.query,
+				Limit:   &r.limit,
+			},
+		},
+	}, nil
+}
+
+func readSearchCommand(param []string) (Command, error) {
+	var query string
+	var limit uint64
+	var err error
+	switch len(param) {
+	default:
+		return nil, &ErrInvalidNumberOfParameters{
+			params:   param,
+			expected: 2,
+		}
+	case 1:
+		limit = DefaultSearchLimit
+		err = ReadPattern(param, &query)
+	case 2:
+		err = ReadPattern(param, &query, &limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &searchCommand{
+		query: query,
+		limit: uint32(limit),
+	}, nil
+}
+
+func buildSearchCommand(r *rpc.SearchRequest) (Command, error) {
+	limit := uint32(DefaultSearchLimit)
+	if r.Limit != nil {
+		limit = *r.Limit
+	}
+
+	return &searchCommand{
+		query: r.GetQuery(),
+		limit: limit,
+	}, nil
+}
+
+var search = command{
+	help: "search the messages in every folder.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{KeyString},
+			help: "list the messages that best match the query.",
+			read: readSearchCommand,
+		},
+		Pattern{
+			key:  []Key{KeyString, KeyNatural},
+			help: "list at most the given number of messages that best match the query.",
+			read: readSearchCommand,
+		},
+	},
+}
+
+// String writes the search response as a string.
+func (r *searchResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *searchResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	results := make([]*rpc.SearchResult, len(r.results))
+	for i := range r.results {
+		results[i] = &rpc.SearchResult{
+			Version: &version,
+			Score:   &r.results[i].Score,
+			Message: BitmessageToRPC(r.results[i].Message),
+		}
+	}
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Search{
+			Search: &rpc.SearchReply{
+				Version: &version,
+				Results: results,
+			},
+		},
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+type sendDraftResponse struct {
+	messages []*email.Bmail
+}
+
+type sendDraftCommand struct {
+	id     uint64
+	sendAt uint64        // Unix time, or zero to send right away.
+	ttl    time.Duration // Zero for the default.
+}
+
+func (r *sendDraftCommand) Execute(u User) (Response, error) {
+	var sendAt time.Time
+	if r.sendAt != 0 {
+		sendAt = time.Unix(int64(r.sendAt), 0)
+	}
+
+	messages, err := u.SendDraft(r.id, sendAt, r.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sendDraftResponse{
+		messages: messages,
+	}, nil
+}
+
+func (r *sendDraftCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	request := &rpc.SendDraftRequest{
+		Version: &version,
+		Id:      &r.id,
+	}
+	if r.sendAt != 0 {
+		request.SendAt = &r.sendAt
+	}
+	if r.ttl != 0 {
+		ttl := email.FormatTTL(r.ttl)
+		request.Ttl = &ttl
+	}
+
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Senddraft{
+			Senddraft: request,
+		},
+	}, nil
+}
+
+func readSendDraftCommand(param []string) (Command, error) {
+	c := &sendDraftCommand{}
+	var ttl string
+	var err error
+	switch len(param) {
+	case 3:
+		err = ReadPattern(param, &c.id, &c.sendAt, &ttl)
+	case 2:
+		err = ReadPattern(param, &c.id, &c.sendAt)
+	default:
+		err = ReadPattern(param, &c.id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl != "" {
+		c.ttl, err = email.ParseTTL(ttl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func buildSendDraftCommand(r *rpc.SendDraftRequest) (Command, error) {
+	if r.Id == nil {
+		return nil, ErrInvalidRPCRequest
+	}
+
+	c := &sendDraftCommand{
+		id:     r.GetId(),
+		sendAt: r.GetSendAt(),
+	}
+
+	if r.Ttl != nil {
+		var err error
+		c.ttl, err = email.ParseTTL(r.GetTtl())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+var sendDraft = command{
+	help: "send a message from the Drafts folder.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{KeyNatural},
+			help: "move the draft with the given id to the Outbox and send it to each of its recipients.",
+			read: readSendDraftCommand,
+		},
+		Pattern{
+			key:  []Key{KeyNatural, KeyNatural},
+			help: "schedule the draft with the given id to be sent at the given Unix time.",
+			read: readSendDraftCommand,
+		},
+		Pattern{
+			key:  []Key{KeyNatural, KeyNatural, KeyString},
+			help: "send the draft at the given Unix time, or now if it is zero, to live in the network for the given time, such as \"4d\".",
+			read: readSendDraftCommand,
+		},
+	},
+}
+
+// String writes the senddraft response as a string.
+func (r *sendDraftResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *sendDraftResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	messages := make([]*rpc.Bitmessage, len(r.messages))
+	for i, bm := range r.messages {
+		messages[i] = BitmessageToRPC(bm)
+	}
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Senddraft{
+			Senddraft: &rpc.SendDraftReply{
+				Version:  &version,
+				Messages: messages,
+			},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"github.com/DanielKrawisz/bmagent/cmd/rpc"
+)
+
+type subscribeResponse struct {
+	address string
+}
+
+type subscribeCommand struct {
+	address string
+}
+
+func (r *subscribeCommand) Execute(u User) (Response, error) {
+	if err := u.Subscribe(r.address); err != nil {
+		return nil, err
+	}
+
+	return &subscribeResponse{
+		address: r.address,
+	}, nil
+}
+
+func (r *subscribeCommand) RPC() (*rpc.BMRPCRequest, error) {
+	version := uint32(1)
+	return &rpc.BMRPCRequest{
+		Version: &version,
+		Request: &rpc.BMRPCRequest_Subscribe{
+			Subscribe: &rpc.SubscribeRequest{
+				Version: &version,
+				Address: &r.address,
+			},
+		},
+	}, nil
+}
+
+func readSubscribeCommand(param []string) (Command, error) {
+	c := &subscribeCommand{}
+	if err := ReadPattern(param, &c.address); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func buildSubscribeCommand(r *rpc.SubscribeRequest) (Command, error) {
+	if r.Address == nil {
+		return nil, ErrInvalidRPCRequest
+	}
+
+	return &subscribeCommand{
+		address: r.GetAddress(),
+	}, nil
+}
+
+var subscribe = command{
+	help: "listen to the broadcasts sent from an address.",
+	patterns: []Pattern{
+		Pattern{
+			key:  []Key{KeyString},
+			help: "subscribe to the given Bitmessage address.",
+			read: readSubscribeCommand,
+		},
+	},
+}
+
+// String writes the subscribe response as a string.
+func (r *subscribeResponse) String() string {
+	return rpc.Message(r.RPC())
+}
+
+// RPC converts the response into an RPC protobuf message.
+func (r *subscribeResponse) RPC() *rpc.BMRPCReply {
+	version := uint32(1)
+	return &rpc.BMRPCReply{
+		Version: &version,
+		Reply: &rpc.BMRPCReply_Subscribe{
+			Subscribe: &rpc.SubscribeReply{
+				Version: &version,
+				Address: &r.address,
+			},
+		},
+	}
+}
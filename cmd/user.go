@@ -1,8 +1,26 @@
 package cmd
 
+import (
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/powmgr"
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
 // User represents an implementation of lower-level functions
 // to be performed as commands are executed.
 type User interface {
 	NewAddress(tag string, sendAck bool) PublicID
 	ListAddresses() []PublicID
+	GetMessages(folder string, keywords []string) ([]*email.Bmail, error)
+	Search(query string, limit uint32) ([]SearchResult, error)
+	RebuildIndex() (uint32, error)
+	SendDraft(uid uint64, sendAt time.Time, ttl time.Duration) ([]*email.Bmail, error)
+	Quote(uid uint64, ttl time.Duration) (*Quote, error)
+	PowQueue() []powmgr.OrderInfo
+	PowPolicy() powmgr.Policy
+	SetPowPolicy(policy powmgr.Policy) error
+	Benchmark() (*powmgr.Calibration, error)
+	Approve(uid uint64) error
+	Subscribe(address string) error
 }
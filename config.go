@@ -20,7 +20,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/DanielKrawisz/bmagent/powmgr"
+	"github.com/DanielKrawisz/bmagent/user"
 	"github.com/DanielKrawisz/bmd/rpc"
+	"github.com/DanielKrawisz/bmutil"
 	"github.com/DanielKrawisz/bmutil/pow"
 	"github.com/btcsuite/btcutil"
 	flags "github.com/jessevdk/go-flags"
@@ -41,11 +44,17 @@ const (
 	defaultIMAPPort = 1143
 	defaultSMTPPort = 1587
 
-	keyfileName = "keys.dat"
-	storeDbName = "store.db"
+	keyfileName         = "keys.dat"
+	storeDbName         = "store.db"
+	calibrationFileName = "powcalibration.json"
 
 	defaultPowHandler = "parallel"
 
+	// Messages to recipients who demand more than twenty times the network
+	// difficulty are held until they are approved.
+	defaultPowMaxNonceTrials = 20 * pow.DefaultNonceTrialsPerByte
+	defaultPowMaxExtraBytes  = 20 * pow.DefaultExtraBytes
+
 	defaultMsgExpiry        = time.Hour * 60      // 2.5 days
 	defaultBroadcastExpiry  = time.Hour * 48      // 2 days
 	defaultPubkeyExpiry     = time.Hour * 24 * 14 // 14 days
@@ -81,6 +90,7 @@ type Config struct {
 	ImportKeyFile string `long:"importkeyfile" description:"Path to keys.db from PyBitmessage. If set, private keys from this file are imported into bmagent"`
 	NoPass        bool   `long:"nopass" description:"Keyfile and database are created unencrypted"`
 	Seed          string `long:"seed" description:"Used with --create. Used to specify the seed for a new keyset"`
+	Benchmark     bool   `long:"benchmark" description:"Measure how fast proof-of-work is done with different numbers of threads, save the result and exit"`
 
 	EnableRPC     bool     `long:"rpc" description:"Enable built-in RPC server -- NOTE: The RPC server is disabled by default"`
 	RPCListeners  []string `long:"rpclisten" description:"Listen for RPC/websocket connections on this interface/port (default port: 8446)"`
@@ -107,18 +117,38 @@ type Config struct {
 
 	Profile string `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 
-	ProofOfWork     string        `long:"pow" description:"Choose proof-of-work handler. Options: {sequential, parallel}"`
-	PowThreads      int           `long:"powthreads" description:"Number of threads to use for parallel proof-of-work calculation. It should not be greater than the number of cores"`
-	MsgExpiry       time.Duration `long:"msgexpiry" description:"Time after which a message sent out should expire, more means more time for POW calculations"`
-	BroadcastExpiry time.Duration `long:"broadcastexpiry" description:"Time after which a broadcast sent out should expire, more means more time for POW calculations"`
+	ProofOfWork       string        `long:"pow" description:"Choose proof-of-work handler. Options: {sequential, parallel}"`
+	PowThreads        int           `long:"powthreads" description:"Number of threads to use for parallel proof-of-work calculation. It should not be greater than the number of cores"`
+	PowWorkers        []string      `long:"powworker" description:"Send proof-of-work to an external worker at this interface/port (default port: 8447). If no worker can do the work, it is done locally with the chosen handler"`
+	PowWorkerCAFile   string        `long:"powworkercafile" description:"File containing the certificate of the proof-of-work workers -- NOTE: If it is not given, connections to them are not encrypted"`
+	PowDutyCycle      uint32        `long:"powdutycycle" description:"Percentage of the time that proof-of-work is done"`
+	PowBatteryPause   bool          `long:"powpauseonbattery" description:"Pause proof-of-work while running on battery"`
+	PowMaxLoad        float64       `long:"powmaxload" description:"Pause proof-of-work while the load average, not counting the proof-of-work itself, is above this value. Zero means there is no limit"`
+	PowWindows        []string      `long:"powwindow" description:"Only do low-priority proof-of-work, such as for broadcasts, in this time of day, such as 22:00-07:00"`
+	PowLongOrder      time.Duration `long:"powlongorder" description:"Give low priority to proof-of-work for messages that is expected to take longer than this, such as 10m. Zero means that only broadcasts get low priority"`
+	PowMaxNonceTrials uint64        `long:"powmaxnoncetrials" description:"Hold messages to recipients who demand more nonce trials per byte than this until they are approved. Zero means there is no limit"`
+	PowMaxExtraBytes  uint64        `long:"powmaxextrabytes" description:"Hold messages to recipients who demand more payload length extra bytes than this until they are approved. Zero means there is no limit"`
+	Contacts          []string      `long:"contact" description:"Bitmessage address of a contact, optionally followed by the greatest nonce trials per byte and extra bytes to do for messages to it without approval, such as BM-...:40000:20000. Messages from contacts need not meet the proof-of-work required by our identities"`
+	PowQuarantine     bool          `long:"powquarantine" description:"Keep incoming messages which do not meet the proof-of-work required by our identity in a Quarantine folder instead of dropping them"`
+	MsgExpiry         time.Duration `long:"msgexpiry" description:"Time after which a message sent out should expire, more means more time for POW calculations"`
+	BroadcastExpiry   time.Duration `long:"broadcastexpiry" description:"Time after which a broadcast sent out should expire, more means more time for POW calculations"`
 
 	LogConsole bool `long:"logconsole" description:"display logs to console."`
 
 	GenKeys int16 `long:"genkeys" description:"number of new keys to generate."`
 
-	powHandler func(target pow.Target, hash []byte) pow.Nonce
+	VirtualFolders bool `long:"virtualfolders" description:"Show a folder for each identity, chan and subscription containing the messages sent to it"`
+
+	powHandler powmgr.Backend
+	powPolicy  powmgr.Policy
+	powLimits  *user.PowLimits
 	storePath  string
 
+	// powThreads is the number of threads of the local proof-of-work
+	// handler, which external workers fall back on.
+	powThreads      int
+	calibrationPath string
+
 	// TODO there should not be a global path for a single key file.
 	keyfilePath string
 	keyfilePass []byte
@@ -184,22 +214,75 @@ func (cfg *Config) Validate() error {
 	// Verify proof-of-work parameters.
 	switch cfg.ProofOfWork {
 	case "sequential":
-		cfg.powHandler = pow.DoSequential
+		cfg.powHandler = powmgr.Func(powmgr.Sequential)
+		cfg.powThreads = 1
 	case "parallel":
 		if cfg.PowThreads < 2 {
 			err := errors.New("Number of threads for proof-of-work cannot be less than 2")
 			fmt.Fprintln(os.Stderr, err)
 			return err
 		}
-		cfg.powHandler = func(target pow.Target, hash []byte) pow.Nonce {
-			return pow.DoParallel(target, hash, cfg.PowThreads)
-		}
+		cfg.powHandler = powmgr.Parallel(cfg.PowThreads)
+		cfg.powThreads = cfg.PowThreads
 	default:
 		err := errors.New("Unknown proof-of-work handler")
 		fmt.Fprintln(os.Stderr, err)
 		return err
 	}
 
+	// Use external proof-of-work workers if any are given, with the local
+	// handler as a fallback.
+	if len(cfg.PowWorkers) > 0 {
+		cfg.PowWorkers = normalizeAddresses(cfg.PowWorkers, powmgr.DefaultWorkerPort)
+		remote, err := powmgr.NewRemote(cfg.PowWorkers, cfg.PowWorkerCAFile, cfg.powHandler)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", "loadConfig", err.Error())
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		cfg.powHandler = remote
+	}
+
+	// Verify the proof-of-work policy.
+	cfg.powPolicy = powmgr.Policy{
+		DutyCycle:      cfg.PowDutyCycle,
+		PauseOnBattery: cfg.PowBatteryPause,
+		MaxLoad:        cfg.PowMaxLoad,
+		LongOrder:      cfg.PowLongOrder,
+	}
+	for _, w := range cfg.PowWindows {
+		window, err := powmgr.ParseWindow(w)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		cfg.powPolicy.Windows = append(cfg.powPolicy.Windows, window)
+	}
+	if err := cfg.powPolicy.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	// Read the limits on the difficulty of proof-of-work for messages.
+	cfg.powLimits = &user.PowLimits{
+		Default: pow.Data{
+			NonceTrialsPerByte: cfg.PowMaxNonceTrials,
+			ExtraBytes:         cfg.PowMaxExtraBytes,
+		},
+		Contacts: make(map[string]pow.Data),
+	}
+	for i, c := range cfg.Contacts {
+		addr, limit, err := parseContact(c)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		cfg.Contacts[i] = addr
+		if limit != nil {
+			cfg.powLimits.Contacts[addr] = *limit
+		}
+	}
+
 	// Username and password must be specified.
 	if cfg.Username == "" || cfg.Password == "" {
 		err := errors.New("Username and password cannot be left blank.")
@@ -474,6 +557,36 @@ func normalizeAddresses(addrs []string, defaultPort int) []string {
 	return removeDuplicateAddresses(addrs)
 }
 
+// parseContact reads a contact of the form address[:noncetrials:extrabytes]
+// and returns its address and the limit on the difficulty for it, if any.
+func parseContact(contact string) (string, *pow.Data, error) {
+	parts := strings.Split(contact, ":")
+	addr, err := bmutil.DecodeAddress(parts[0])
+	if err != nil {
+		return "", nil, fmt.Errorf("Invalid contact address %s: %v", parts[0], err)
+	}
+
+	switch len(parts) {
+	case 1:
+		return addr.String(), nil, nil
+	case 3:
+		nonceTrials, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("Invalid nonce trials per byte for contact %s: %v", parts[0], err)
+		}
+		extraBytes, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("Invalid extra bytes for contact %s: %v", parts[0], err)
+		}
+		return addr.String(), &pow.Data{
+			NonceTrialsPerByte: nonceTrials,
+			ExtraBytes:         extraBytes,
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("Contact %s must be of the form address[:noncetrials:extrabytes]", contact)
+	}
+}
+
 // filesExists reports whether the named file or directory exists.
 func fileExists(name string) bool {
 	if _, err := os.Stat(name); err != nil {
@@ -519,18 +632,21 @@ func newConfigParser(cfg *Config, appName string, options flags.Options) *flags.
 func DefaultConfig() *Config {
 	// Default config.
 	return &Config{
-		DebugLevel:      defaultLogLevel,
-		ConfigFile:      defaultConfigFilename,
-		DataDir:         defaultDataDir,
-		LogDir:          defaultLogDir,
-		RPCKey:          defaultTLSKeyFile,
-		RPCCert:         defaultTLSCertFile,
-		PowThreads:      runtime.NumCPU(),
-		ProofOfWork:     defaultPowHandler,
-		MsgExpiry:       defaultMsgExpiry,
-		BroadcastExpiry: defaultBroadcastExpiry,
-		LogConsole:      defaultLogConsole,
-		GenKeys:         defaultGenKeys,
+		DebugLevel:        defaultLogLevel,
+		ConfigFile:        defaultConfigFilename,
+		DataDir:           defaultDataDir,
+		LogDir:            defaultLogDir,
+		RPCKey:            defaultTLSKeyFile,
+		RPCCert:           defaultTLSCertFile,
+		PowThreads:        runtime.NumCPU(),
+		ProofOfWork:       defaultPowHandler,
+		PowDutyCycle:      powmgr.DefaultPolicy.DutyCycle,
+		PowMaxNonceTrials: defaultPowMaxNonceTrials,
+		PowMaxExtraBytes:  defaultPowMaxExtraBytes,
+		MsgExpiry:         defaultMsgExpiry,
+		BroadcastExpiry:   defaultBroadcastExpiry,
+		LogConsole:        defaultLogConsole,
+		GenKeys:           defaultGenKeys,
 	}
 }
 
@@ -562,6 +678,17 @@ func loadConfig() (*Config, []string, error) {
 	// flag is set.
 	cfg.keyfilePath = filepath.Join(cfg.DataDir, keyfileName)
 	cfg.storePath = filepath.Join(cfg.DataDir, storeDbName)
+	cfg.calibrationPath = filepath.Join(cfg.DataDir, calibrationFileName)
+
+	if cfg.Benchmark {
+		if err := benchmarkPow(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "Unable to benchmark proof-of-work:", err)
+			return nil, nil, err
+		}
+
+		// Benchmarked successfully, so exit now with success.
+		os.Exit(0)
+	}
 
 	if cfg.Create {
 		// Error if the create flag is set and the key file or data store
@@ -0,0 +1,139 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"crypto/rand"
+	"math"
+	"time"
+
+	"github.com/DanielKrawisz/bmutil/hash"
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+const (
+	// benchmarkTrials is the expected number of hashes needed to meet the
+	// target used in the benchmark.
+	benchmarkTrials = 1 << 18
+
+	// benchmarkDuration is how long the benchmark runs.
+	benchmarkDuration = time.Second
+)
+
+// benchmark measures how many hashes per second the backend can compute by
+// repeatedly doing proof-of-work with an easy target.
+func benchmark(backend Backend) float64 {
+	target := pow.Target(math.MaxUint64 / benchmarkTrials)
+	data := make([]byte, 64)
+
+	var runs int
+	start := time.Now()
+	for runs == 0 || time.Since(start) < benchmarkDuration {
+		rand.Read(data)
+		backend.Do(target, hash.Sha512(data), nil, nil)
+		runs++
+	}
+
+	return float64(runs) * benchmarkTrials / time.Since(start).Seconds()
+}
+
+// local returns the backend that does the work on this computer. If the
+// work is sent to external workers, it is the backend that they fall back on.
+func (q *Pow) local() Backend {
+	if r, ok := q.backend.(*Remote); ok {
+		return r.local
+	}
+	return q.backend
+}
+
+// setHashrate sets the hashrate of the local backend. It must be called
+// while the queue is locked.
+func (q *Pow) setHashrate(hashrate float64) {
+	q.hashrate = hashrate
+
+	// External workers are given time according to the local hashrate.
+	if r, ok := q.backend.(*Remote); ok {
+		r.SetHashrate(hashrate)
+	}
+}
+
+// Hashrate returns the number of hashes per second that the proof-of-work
+// manager computes on this computer. External workers are expected to be at
+// least as fast. If the hashrate was not calibrated, it is measured the first
+// time that it is needed, which takes about a second, unless SetCalibration
+// has started measuring it already.
+func (q *Pow) Hashrate() float64 {
+	q.benchmarkMtx.Lock()
+	defer q.benchmarkMtx.Unlock()
+
+	return q.measure()
+}
+
+// measure measures the hashrate of the local backend if it is not known yet
+// and adds it to the calibration. benchmarkMtx must be locked.
+func (q *Pow) measure() float64 {
+	q.mtx.Lock()
+	hashrate := q.hashrate
+	q.mtx.Unlock()
+	if hashrate != 0 {
+		return hashrate
+	}
+
+	hashrate = benchmark(q.local())
+	log.Infof("Measured a hashrate of %.0f hashes per second.", hashrate)
+
+	q.mtx.Lock()
+	q.setHashrate(hashrate)
+	q.mtx.Unlock()
+
+	if q.threads == 0 || q.calibrationFile == "" {
+		return hashrate
+	}
+
+	// Save the measurement so that it is not needed again.
+	c, err := LoadCalibration(q.calibrationFile)
+	if err != nil {
+		c = &Calibration{Time: time.Now()}
+	}
+	c.Rates = append(c.Rates, Rate{
+		Threads:  q.threads,
+		Hashrate: hashrate,
+	})
+	if err := c.Save(q.calibrationFile); err != nil {
+		log.Warnf("Could not save proof-of-work calibration: %v", err)
+	}
+
+	return hashrate
+}
+
+// estimate returns how long the proof-of-work for the given target is
+// expected to take at the given hashrate and duty cycle.
+func estimate(target pow.Target, hashrate float64, dutyCycle uint32) time.Duration {
+	if target == 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	// On average, 2^64 / target hashes are tried before one is found which
+	// meets the target.
+	seconds := math.Exp2(64) / float64(target) / hashrate * 100 / float64(dutyCycle)
+	if seconds >= float64(math.MaxInt64/int64(time.Second)) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Estimate returns how long the proof-of-work for the given target is
+// expected to take, given the duty cycle.
+func (q *Pow) Estimate(target pow.Target) time.Duration {
+	if target == 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	q.mtx.Lock()
+	dutyCycle := q.policy.DutyCycle
+	q.mtx.Unlock()
+
+	return estimate(target, q.Hashrate(), dutyCycle)
+}
@@ -0,0 +1,205 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"time"
+)
+
+// recommendMargin is how close to the fastest hashrate another one must be
+// for its smaller number of threads to be recommended instead.
+const recommendMargin = 0.95
+
+// Rate is the number of hashes per second that are computed with some
+// number of threads. One thread means the sequential handler.
+type Rate struct {
+	Threads  int
+	Hashrate float64
+}
+
+// Calibration records how fast proof-of-work is done on this computer.
+type Calibration struct {
+	Time  time.Time
+	Rates []Rate
+}
+
+// threadCounts returns the numbers of threads to try, up to max.
+func threadCounts(max int) []int {
+	counts := []int{1}
+	for n := 2; n <= max; {
+		counts = append(counts, n)
+		if n < 8 {
+			n++
+		} else {
+			n *= 2
+		}
+	}
+	if last := counts[len(counts)-1]; last < max {
+		counts = append(counts, max)
+	}
+	return counts
+}
+
+// Calibrate measures the hashrate of the sequential handler and of the
+// parallel handler with different numbers of threads up to maxThreads.
+// Each measurement takes about a second.
+func Calibrate(maxThreads int) *Calibration {
+	c := &Calibration{
+		Time: time.Now(),
+	}
+
+	for _, threads := range threadCounts(maxThreads) {
+		var backend Backend
+		if threads == 1 {
+			backend = Func(Sequential)
+		} else {
+			backend = Parallel(threads)
+		}
+
+		hashrate := benchmark(backend)
+		log.Infof("Measured a hashrate of %.0f hashes per second with %d threads.",
+			hashrate, threads)
+
+		c.Rates = append(c.Rates, Rate{
+			Threads:  threads,
+			Hashrate: hashrate,
+		})
+	}
+
+	return c
+}
+
+// Hashrate returns the hashrate that was measured with the given number of
+// threads, if there is one.
+func (c *Calibration) Hashrate(threads int) (float64, bool) {
+	for _, r := range c.Rates {
+		if r.Threads == threads {
+			return r.Hashrate, true
+		}
+	}
+	return 0, false
+}
+
+// Recommend returns the number of threads to use for proof-of-work. It is
+// the smallest number that is nearly as fast as the fastest, so that no more
+// of the computer is used than is useful.
+func (c *Calibration) Recommend() int {
+	var best float64
+	for _, r := range c.Rates {
+		if r.Hashrate > best {
+			best = r.Hashrate
+		}
+	}
+
+	recommended := 0
+	for _, r := range c.Rates {
+		if r.Hashrate >= best*recommendMargin &&
+			(recommended == 0 || r.Threads < recommended) {
+			recommended = r.Threads
+		}
+	}
+
+	if recommended == 0 {
+		return runtime.NumCPU()
+	}
+	return recommended
+}
+
+// LoadCalibration reads a calibration from a file.
+func LoadCalibration(file string) (*Calibration, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Calibration{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the calibration to a file.
+func (c *Calibration) Save(file string) error {
+	b, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, b, 0600)
+}
+
+// SetCalibration tells the proof-of-work manager where the calibration is
+// stored and how many threads its local backend uses, or zero if it is not
+// known. If there is a measurement for that number of threads, it is used as
+// the hashrate from now on. Otherwise, the hashrate is measured right away in
+// the background and added to the calibration, so that it is known before it
+// is needed.
+func (q *Pow) SetCalibration(file string, threads int) {
+	q.benchmarkMtx.Lock()
+	defer q.benchmarkMtx.Unlock()
+
+	q.calibrationFile = file
+	q.threads = threads
+
+	q.mtx.Lock()
+	q.localThreads = threads
+	q.mtx.Unlock()
+
+	c, err := LoadCalibration(file)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not read proof-of-work calibration: %v", err)
+	}
+	if err == nil && q.useCalibration(c) {
+		return
+	}
+
+	go q.Hashrate()
+}
+
+// useCalibration sets the hashrate from the calibration if it applies and
+// returns whether it did. benchmarkMtx must be locked.
+func (q *Pow) useCalibration(c *Calibration) bool {
+	if q.threads == 0 {
+		return false
+	}
+
+	hashrate, ok := c.Hashrate(q.threads)
+	if !ok {
+		return false
+	}
+
+	q.mtx.Lock()
+	q.setHashrate(hashrate)
+	q.mtx.Unlock()
+
+	log.Infof("Using the calibrated hashrate of %.0f hashes per second.", hashrate)
+	return true
+}
+
+// Calibrate measures the hashrate with different numbers of threads, which
+// takes a few seconds, and saves the result. Proof-of-work that is being done
+// meanwhile makes the measurements lower than they should be.
+func (q *Pow) Calibrate() (*Calibration, error) {
+	q.benchmarkMtx.Lock()
+	defer q.benchmarkMtx.Unlock()
+
+	max := runtime.NumCPU()
+	if q.threads > max {
+		max = q.threads
+	}
+
+	c := Calibrate(max)
+	q.useCalibration(c)
+
+	if q.calibrationFile == "" {
+		return c, nil
+	}
+	return c, c.Save(q.calibrationFile)
+}
@@ -0,0 +1,36 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/DanielKrawisz/bmutil/hash"
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+// minTTL is the least time to live in seconds that is used to calculate the
+// target of an object, so that objects which are about to expire are not
+// accepted with almost no work done on them.
+const minTTL = 300
+
+// Check returns whether the nonce of an encoded object meets the target for
+// the given difficulty. The object begins with its eight byte nonce and
+// expires at the given time.
+func Check(object []byte, expiration time.Time, data pow.Data) bool {
+	if len(object) < 8 {
+		return false
+	}
+
+	ttl := int64(expiration.Sub(time.Now()) / time.Second)
+	if ttl < minTTL {
+		ttl = minTTL
+	}
+
+	payload := object[8:]
+	target := pow.CalculateTarget(uint64(len(payload)), uint64(ttl), data)
+	return trialValue(binary.BigEndian.Uint64(object[:8]), hash.Sha512(payload)) <= uint64(target)
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+// checkInterval is the number of nonces that are tried between checks of
+// whether the proof-of-work has been cancelled.
+const checkInterval = 1 << 12
+
+// Backend calculates proof-of-work for the proof-of-work manager.
+type Backend interface {
+	// Do returns a nonce which meets the target for the given hash. It
+	// returns false without a nonce if cancel is closed before it finishes.
+	// Work done on this computer must be throttled with throttle, which
+	// may be nil. cancel may be nil if the work is never to be cancelled.
+	Do(target pow.Target, hash []byte, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool)
+}
+
+// Func is a function which calculates proof-of-work in the same way as
+// Backend.Do.
+type Func func(target pow.Target, hash []byte, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool)
+
+// Do calls the function.
+func (f Func) Do(target pow.Target, hash []byte, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+	return f(target, hash, throttle, cancel)
+}
+
+// trialValue returns the value which must not exceed the target for the
+// given nonce to be valid.
+func trialValue(nonce uint64, hash []byte) uint64 {
+	b := make([]byte, 8, 8+len(hash))
+	binary.BigEndian.PutUint64(b, nonce)
+	first := sha512.Sum512(append(b, hash...))
+	second := sha512.Sum512(first[:])
+	return binary.BigEndian.Uint64(second[:8])
+}
+
+// search tries every nonce start, start + step, start + 2 * step and so on
+// until it finds one that meets the target. It stops early if cancel or
+// found is closed.
+func search(target pow.Target, hash []byte, start, step uint64,
+	throttle Throttle, cancel, found <-chan struct{}) (pow.Nonce, bool) {
+
+	busy := time.Now()
+	for nonce := start; ; nonce += step {
+		if (nonce/step)%checkInterval == 0 {
+			select {
+			case <-cancel:
+				return 0, false
+			case <-found:
+				return 0, false
+			default:
+			}
+
+			if throttle != nil {
+				if !throttle.Wait(time.Since(busy), cancel) {
+					return 0, false
+				}
+				busy = time.Now()
+			}
+		}
+
+		if trialValue(nonce, hash) <= uint64(target) {
+			return pow.Nonce(nonce), true
+		}
+	}
+}
+
+// searchParallel divides the nonces start, start + step, start + 2 * step
+// and so on between the given number of threads and searches them until
+// one of the threads finds a nonce that meets the target.
+func searchParallel(target pow.Target, hash []byte, start, step uint64,
+	threads int, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+
+	found := make(chan struct{})
+	var once sync.Once
+	var nonce pow.Nonce
+	var ok bool
+
+	var wg sync.WaitGroup
+	wg.Add(threads)
+	for i := 0; i < threads; i++ {
+		go func(start uint64) {
+			defer wg.Done()
+			n, success := search(target, hash, start, step*uint64(threads), throttle, cancel, found)
+			if success {
+				once.Do(func() {
+					nonce, ok = n, true
+					close(found)
+				})
+			}
+		}(start + uint64(i)*step)
+	}
+	wg.Wait()
+
+	return nonce, ok
+}
+
+// Sequential calculates proof-of-work in a single thread.
+func Sequential(target pow.Target, hash []byte, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+	return search(target, hash, 0, 1, throttle, cancel, nil)
+}
+
+// Parallel returns a Func which calculates proof-of-work with the given
+// number of threads.
+func Parallel(threads int) Func {
+	return func(target pow.Target, hash []byte, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+		return searchParallel(target, hash, 0, 1, threads, throttle, cancel)
+	}
+}
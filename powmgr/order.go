@@ -0,0 +1,165 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"time"
+
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+// OrderType is the kind of object that proof-of-work is being done for.
+type OrderType int
+
+const (
+	// TypeAck is an acknowledgement that is sent along with a message.
+	TypeAck OrderType = iota
+
+	// TypeGetPubKey is a request for a public key.
+	TypeGetPubKey
+
+	// TypePubKey is one of our own public keys.
+	TypePubKey
+
+	// TypeMessage is a message to an individual address.
+	TypeMessage
+
+	// TypeBroadcast is a broadcast.
+	TypeBroadcast
+)
+
+var orderTypeStrings = map[OrderType]string{
+	TypeAck:       "ack",
+	TypeGetPubKey: "getpubkey",
+	TypePubKey:    "pubkey",
+	TypeMessage:   "message",
+	TypeBroadcast: "broadcast",
+}
+
+// String returns the name of the order type.
+func (t OrderType) String() string {
+	if s, ok := orderTypeStrings[t]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// Priority determines which order is run first. Orders with a higher priority
+// are run before those with a lower one, and orders with the same priority
+// are run in the order that they were given.
+type Priority int
+
+const (
+	// PriorityLow is for work that nobody is waiting for.
+	PriorityLow Priority = iota - 1
+
+	// PriorityNormal is for ordinary messages.
+	PriorityNormal
+
+	// PriorityHigh is for objects that other work depends on.
+	PriorityHigh
+)
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	switch {
+	case p < PriorityNormal:
+		return "low"
+	case p > PriorityNormal:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// Priority returns the priority that orders of the given type get by
+// default. A getpubkey request holds up a message until it is answered,
+// so it goes first, and so do pubkeys and acks, which others are waiting
+// for. Broadcasts have no particular recipient and can wait.
+func (t OrderType) Priority() Priority {
+	switch t {
+	case TypeAck, TypeGetPubKey, TypePubKey:
+		return PriorityHigh
+	case TypeBroadcast:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// OrderInfo describes an order in the proof-of-work queue.
+type OrderInfo struct {
+	Type     OrderType
+	Priority Priority
+	Target   pow.Target
+
+	// Position is the place of the order in the queue. The order that is
+	// being worked on has position zero.
+	Position int
+
+	// Estimate is how long it is expected to take until the order is done,
+	// including the time needed for the orders ahead of it.
+	Estimate time.Duration
+
+	// Elapsed is how long ago the order was given.
+	Elapsed time.Duration
+}
+
+// Queue returns information about the order that is currently being worked
+// on, if any, followed by every order that is waiting, in the order in which
+// they will be run.
+func (q *Pow) Queue() []OrderInfo {
+	q.mtx.Lock()
+	orders := make([]*powOrder, 0)
+	first := 1
+	if q.running != nil {
+		orders = append(orders, q.running)
+		first = 0
+	}
+	for node := q.head; node != nil; node = node.next {
+		orders = append(orders, node.order)
+	}
+	q.mtx.Unlock()
+
+	if len(orders) == 0 {
+		return nil
+	}
+
+	// Estimate may need to measure the hashrate, so it must not be called
+	// while the queue is locked.
+	now := time.Now()
+	info := make([]OrderInfo, len(orders))
+	var total time.Duration
+	for i, order := range orders {
+		estimate := q.Estimate(order.target)
+		if i == 0 && first == 0 {
+			// Part of the work for the running order is done already.
+			estimate -= now.Sub(order.started)
+			if estimate < 0 {
+				estimate = 0
+			}
+		}
+		total = addDuration(total, estimate)
+
+		info[i] = OrderInfo{
+			Type:     order.orderType,
+			Priority: order.priority,
+			Target:   order.target,
+			Position: first + i,
+			Estimate: total,
+			Elapsed:  now.Sub(order.submitted),
+		}
+	}
+
+	return info
+}
+
+// addDuration adds two durations without overflowing.
+func addDuration(a, b time.Duration) time.Duration {
+	if a+b < a {
+		return time.Duration(1<<63 - 1)
+	}
+	return a + b
+}
@@ -0,0 +1,366 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+const (
+	// statusInterval is how often the battery and the load average are
+	// checked while proof-of-work is being done.
+	statusInterval = 5 * time.Second
+
+	// pauseInterval is how often paused work checks whether it may go on.
+	pauseInterval = 5 * time.Second
+
+	// windowInterval is the longest that the work function sleeps while
+	// every order that is waiting must wait for a window.
+	windowInterval = time.Minute
+
+	// loadPeriod is the period over which the load average is taken.
+	loadPeriod = time.Minute
+)
+
+var (
+	// ErrInvalidDutyCycle is returned for a duty cycle that is not between
+	// 1 and 100 percent.
+	ErrInvalidDutyCycle = errors.New("the duty cycle must be between 1 and 100 percent")
+
+	// ErrInvalidMaxLoad is returned for a negative maximum load average.
+	ErrInvalidMaxLoad = errors.New("the maximum load average must not be negative")
+
+	// ErrInvalidWindow is returned for a time window that cannot be read.
+	ErrInvalidWindow = errors.New("time windows must be written like 22:00-07:00")
+
+	// ErrInvalidLongOrder is returned for a negative time after which
+	// orders are given low priority.
+	ErrInvalidLongOrder = errors.New("the time for a long order must not be negative")
+)
+
+// Window is a time of day, such as 22:00-07:00.
+type Window struct {
+	// Start and End are the time since midnight. If End is before Start,
+	// the window goes over midnight.
+	Start, End time.Duration
+}
+
+// ParseWindow reads a window written like 22:00-07:00.
+func ParseWindow(s string) (Window, error) {
+	times := strings.Split(strings.TrimSpace(s), "-")
+	if len(times) != 2 {
+		return Window{}, ErrInvalidWindow
+	}
+
+	var w Window
+	for i, t := range times {
+		var hours, minutes int
+		n, err := fmt.Sscanf(strings.TrimSpace(t), "%d:%d", &hours, &minutes)
+		if err != nil || n != 2 || hours < 0 || hours > 24 ||
+			minutes < 0 || minutes > 59 || (hours == 24 && minutes != 0) {
+			return Window{}, ErrInvalidWindow
+		}
+
+		d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+		if i == 0 {
+			w.Start = d
+		} else {
+			w.End = d
+		}
+	}
+
+	if w.Start == w.End {
+		return Window{}, ErrInvalidWindow
+	}
+
+	return w, nil
+}
+
+// String writes the window like 22:00-07:00.
+func (w Window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", d/time.Hour, d%time.Hour/time.Minute)
+	}
+	return clock(w.Start) + "-" + clock(w.End)
+}
+
+// sinceMidnight returns how much time has passed since midnight.
+func sinceMidnight(t time.Time) time.Duration {
+	hour, min, sec := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute +
+		time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// contains returns whether the given time is inside the window.
+func (w Window) contains(t time.Time) bool {
+	d := sinceMidnight(t)
+	if w.Start < w.End {
+		return d >= w.Start && d < w.End
+	}
+	return d >= w.Start || d < w.End
+}
+
+// until returns how long it is from the given time until the window opens.
+func (w Window) until(t time.Time) time.Duration {
+	d := w.Start - sinceMidnight(t)
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	return d
+}
+
+// Policy limits how much of the computer the proof-of-work manager uses.
+// It does not apply to external workers.
+type Policy struct {
+	// DutyCycle is the percentage of the time that proof-of-work is done.
+	// 100 means there is no limit.
+	DutyCycle uint32
+
+	// PauseOnBattery is whether to pause work while the computer runs on
+	// battery.
+	PauseOnBattery bool
+
+	// MaxLoad is the load average above which work is paused. The load
+	// that the work itself causes does not count. Zero means there is no
+	// limit.
+	MaxLoad float64
+
+	// Windows are the times of day in which low-priority orders may be
+	// worked on. Outside of them, such orders are not started and work on
+	// them is paused. If there are none, they may be worked on at any time.
+	Windows []Window
+
+	// LongOrder is how long an order of normal priority must be expected
+	// to take for it to be given low priority instead, so that large
+	// messages wait for the windows. Zero means that none are.
+	LongOrder time.Duration
+}
+
+// DefaultPolicy is the policy of a new proof-of-work manager, which puts no
+// limits on it.
+var DefaultPolicy = Policy{
+	DutyCycle: 100,
+}
+
+// Validate returns an error if the policy cannot be followed.
+func (p *Policy) Validate() error {
+	if p.DutyCycle < 1 || p.DutyCycle > 100 {
+		return ErrInvalidDutyCycle
+	}
+	if p.MaxLoad < 0 {
+		return ErrInvalidMaxLoad
+	}
+	if p.LongOrder < 0 {
+		return ErrInvalidLongOrder
+	}
+	for _, w := range p.Windows {
+		if w.Start < 0 || w.Start > 24*time.Hour || w.End < 0 ||
+			w.End > 24*time.Hour || w.Start == w.End {
+			return ErrInvalidWindow
+		}
+	}
+	return nil
+}
+
+// allows returns whether an order with the given priority may be started at
+// the given time, and if not, how long it is until it may be.
+func (p *Policy) allows(priority Priority, t time.Time) (bool, time.Duration) {
+	if priority >= PriorityNormal || len(p.Windows) == 0 {
+		return true, 0
+	}
+
+	var wait time.Duration
+	for i, w := range p.Windows {
+		if w.contains(t) {
+			return true, 0
+		}
+		if until := w.until(t); i == 0 || until < wait {
+			wait = until
+		}
+	}
+	return false, wait
+}
+
+// priority returns the priority that an order with the given target gets,
+// which is low instead of normal if it is expected to take longer than the
+// LongOrder of the policy.
+//
+// The hashrate is not measured here, because that would hold up whoever gave
+// the order. Until it is known, no order is given low priority.
+func (q *Pow) priority(target pow.Target, priority Priority) Priority {
+	if priority != PriorityNormal {
+		return priority
+	}
+
+	q.mtx.Lock()
+	long := q.policy.LongOrder
+	hashrate := q.hashrate
+	dutyCycle := q.policy.DutyCycle
+	q.mtx.Unlock()
+
+	if long == 0 || hashrate == 0 || estimate(target, hashrate, dutyCycle) <= long {
+		return priority
+	}
+	return PriorityLow
+}
+
+// Policy returns the policy of the proof-of-work manager.
+func (q *Pow) Policy() Policy {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	p := q.policy
+	p.Windows = append([]Window(nil), q.policy.Windows...)
+	return p
+}
+
+// SetPolicy changes the policy of the proof-of-work manager. It takes effect
+// right away, including for work that is being done already.
+func (q *Pow) SetPolicy(p Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	q.mtx.Lock()
+	q.policy = p
+	q.policy.Windows = append([]Window(nil), p.Windows...)
+	q.checked = time.Time{}
+	q.mtx.Unlock()
+
+	log.Infof("Proof-of-work policy: duty cycle %d%%, pause on battery %t, "+
+		"max load %.2f, windows %v, long order %s.", p.DutyCycle, p.PauseOnBattery,
+		p.MaxLoad, p.Windows, p.LongOrder)
+
+	// A window may have opened for orders that were waiting.
+	q.wake()
+	return nil
+}
+
+// updateOwnLoad works out how much of the load average is caused by the
+// work itself, in the same way as the load average, and records that from
+// now on the given share of the threads of the backend are working. It must
+// be called while the queue is locked.
+func (q *Pow) updateOwnLoad(now time.Time, share float64) {
+	if !q.loadTime.IsZero() {
+		decay := math.Exp(-now.Sub(q.loadTime).Seconds() / loadPeriod.Seconds())
+		q.ownLoad = q.ownLoad*decay + float64(q.localThreads)*q.ownShare*(1-decay)
+	}
+	q.loadTime = now
+	q.ownShare = share
+}
+
+// throttleState returns the policy and whether work is paused at the
+// moment, checking the battery and the load average if needed. Work on a
+// low-priority order is also paused outside of the windows.
+func (q *Pow) throttleState() (Policy, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	now := time.Now()
+	if now.Sub(q.checked) >= statusInterval {
+		q.checkStatus(now)
+	}
+
+	paused := q.paused
+	if q.running != nil {
+		if ok, _ := q.policy.allows(q.running.priority, now); !ok {
+			paused = true
+		}
+	}
+
+	return q.policy, paused
+}
+
+// checkStatus checks the battery and the load average and works out whether
+// work must be paused. It must be called while the queue is locked.
+func (q *Pow) checkStatus(now time.Time) {
+	q.checked = now
+	wasPaused := q.paused
+	q.paused = false
+	if q.policy.PauseOnBattery {
+		if battery, err := onBattery(); err == nil && battery {
+			q.paused = true
+		}
+	}
+
+	// The load that the work itself causes does not count.
+	q.updateOwnLoad(now, 0)
+	if q.policy.MaxLoad > 0 {
+		if load, err := loadAverage(); err == nil && load-q.ownLoad > q.policy.MaxLoad {
+			q.paused = true
+		}
+	}
+
+	// Until the next check, the threads work for the duty cycle unless
+	// they are paused.
+	if !q.paused && q.running != nil {
+		if ok, _ := q.policy.allows(q.running.priority, now); ok {
+			q.ownShare = float64(q.policy.DutyCycle) / 100
+		}
+	}
+
+	if q.paused != wasPaused {
+		if q.paused {
+			log.Info("Proof-of-work paused.")
+		} else {
+			log.Info("Proof-of-work resumed.")
+		}
+	}
+}
+
+// Throttle is given to a Backend along with the work. Local backends call
+// Wait between batches of hashes.
+type Throttle interface {
+	// Wait is called after the backend has been working for the given
+	// time. It sleeps for as long as needed to keep within the duty cycle,
+	// and for as long as work is paused. It returns false if cancel is
+	// closed in the meantime.
+	Wait(busy time.Duration, cancel <-chan struct{}) bool
+}
+
+// policyThrottle throttles work according to the policy of a Pow.
+type policyThrottle struct {
+	q *Pow
+}
+
+// Wait sleeps according to the policy of the proof-of-work manager.
+func (t policyThrottle) Wait(busy time.Duration, cancel <-chan struct{}) bool {
+	for {
+		policy, paused := t.q.throttleState()
+		if !paused {
+			if policy.DutyCycle >= 100 {
+				return true
+			}
+			idle := busy * time.Duration(100-policy.DutyCycle) / time.Duration(policy.DutyCycle)
+			return sleep(idle, cancel)
+		}
+
+		if !sleep(pauseInterval, cancel) {
+			return false
+		}
+	}
+}
+
+// sleep waits for the given time. It returns false if cancel is closed first.
+func sleep(d time.Duration, cancel <-chan struct{}) bool {
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-cancel:
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -5,7 +5,9 @@
 package powmgr
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/DanielKrawisz/bmutil/hash"
 	"github.com/DanielKrawisz/bmutil/pow"
@@ -22,6 +24,55 @@ type powOrder struct {
 
 	// The function to run when the pow is completed.
 	donePowFunc func(u pow.Nonce)
+
+	// The function to run when the backend returns an invalid nonce, which
+	// may be nil. attempts is the number of times that it happened.
+	failedFunc func(error)
+	attempts   int
+
+	orderType OrderType
+	priority  Priority
+
+	// submitted is when the order was given and started is when work on
+	// it began.
+	submitted time.Time
+	started   time.Time
+
+	// cancel is closed when the order is cancelled. cancelled, finished
+	// and gaveUp are protected by the mutex of the Pow.
+	cancel    chan struct{}
+	cancelled bool
+	finished  bool
+	gaveUp    bool
+}
+
+// maxAttempts is the number of times that the work for an order is tried
+// before it is given up.
+const maxAttempts = 3
+
+// Failure is given to the failure function of an order when the backend
+// returns a nonce which does not meet the target.
+type Failure struct {
+	// Attempts is the number of times that the work failed.
+	Attempts int
+
+	// Retrying is whether the work is being tried again.
+	Retrying bool
+}
+
+// Error returns a description of the failure.
+func (f *Failure) Error() string {
+	if f.Retrying {
+		return fmt.Sprintf("invalid proof-of-work on attempt %d; trying again", f.Attempts)
+	}
+	return fmt.Sprintf("invalid proof-of-work on attempt %d; giving up", f.Attempts)
+}
+
+// Order is a handle for an order that was given to the proof-of-work
+// manager, which can be used to cancel it.
+type Order struct {
+	q     *Pow
+	order *powOrder
 }
 
 type powNode struct {
@@ -31,26 +82,126 @@ type powNode struct {
 
 // Pow is the proof-of-work manager.
 type Pow struct {
-	// powFunc is the function that calculates the pow.
-	powFunc func(target pow.Target, hash []byte) pow.Nonce
+	// backend calculates the pow.
+	backend Backend
 
 	mtx  sync.Mutex
 	head *powNode
-	tail **powNode
+
+	// running is the order that is being worked on, if any.
+	running *powOrder
+
+	// working is whether the work function is running.
+	working bool
+
+	// wakeup is signalled when the work function may have to start an
+	// order that it was holding back.
+	wakeup chan struct{}
+
+	// policy limits how much of the computer is used. paused is whether
+	// work is paused, which was last checked at the time checked.
+	policy  Policy
+	paused  bool
+	checked time.Time
+
+	// ownLoad is how much of the load average is caused by the work itself,
+	// as it was at the time loadTime, when localThreads * ownShare threads
+	// were working. localThreads is the number of threads of the backend,
+	// as given to SetCalibration.
+	ownLoad      float64
+	ownShare     float64
+	loadTime     time.Time
+	localThreads int
+
+	// hashrate is the number of hashes per second that the local backend
+	// computes, or zero if it has not been measured yet. It is protected by
+	// mtx, so that it can be read while it is being measured.
+	hashrate float64
+
+	// benchmarkMtx is held while the hashrate is measured. calibrationFile
+	// is where the calibration is saved, and threads is the number of
+	// threads used by the local backend, or zero if it is not known. They
+	// are protected by benchmarkMtx.
+	benchmarkMtx    sync.Mutex
+	calibrationFile string
+	threads         int
 }
 
 // New creates a new PowManager.
-func New(powFunc func(target pow.Target, hash []byte) pow.Nonce) *Pow {
+func New(backend Backend) *Pow {
 	return &Pow{
-		powFunc: powFunc,
+		backend: backend,
+		wakeup:  make(chan struct{}, 1),
+		policy:  DefaultPolicy,
+	}
+}
+
+// Run adds an object message with a target value for PoW to the pow queue,
+// behind every order with the same or a higher priority. It returns a handle
+// with which the order can be cancelled. If the PowManager is idle, then it
+// starts running hashes immediately. Every nonce is checked before it is
+// given to donePowFunc. If it is invalid, failedFunc is called with a
+// *Failure, unless it is nil, and the work is tried again up to maxAttempts
+// times. An order of normal priority which is expected to take longer than
+// the LongOrder of the policy is given low priority.
+func (q *Pow) Run(target pow.Target, obj []byte, orderType OrderType,
+	priority Priority, donePowFunc func(u pow.Nonce), failedFunc func(error)) *Order {
+	p := &powOrder{
+		target:      target,
+		object:      obj,
+		donePowFunc: donePowFunc,
+		failedFunc:  failedFunc,
+		orderType:   orderType,
+		priority:    q.priority(target, priority),
+		submitted:   time.Now(),
+		cancel:      make(chan struct{}),
+	}
+	q.enqueue(p)
+	return &Order{
+		q:     q,
+		order: p,
+	}
+}
+
+// Cancel cancels the order. If the proof-of-work is being calculated, it is
+// stopped. Cancel returns true if the done function of the order is certain
+// never to be called, and false if it was called already.
+func (o *Order) Cancel() bool {
+	q := o.q
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if o.order.finished {
+		return false
+	}
+	if o.order.cancelled {
+		return true
 	}
+
+	o.order.cancelled = true
+	close(o.order.cancel)
+
+	// Remove the order from the queue if it is still waiting.
+	for p := &q.head; *p != nil; p = &(*p).next {
+		if (*p).order == o.order {
+			*p = (*p).next
+			break
+		}
+	}
+
+	log.Debug("Proof-of-work order cancelled.")
+	return true
 }
 
-// Run adds an object message with a target value for PoW to the end of the
-// pow queue. It returns the index value of the stored element. If the
-// PowManager is running, then a signal is sent to start running hashes immediately.
-func (q *Pow) Run(target pow.Target, obj []byte, donePowFunc func(u pow.Nonce)) {
-	q.enqueue(&powOrder{target, obj, donePowFunc})
+// Finished returns whether the order is over, either because its done
+// function has been called or because it failed too many times. An order
+// that was cancelled is not finished.
+func (o *Order) Finished() bool {
+	q := o.q
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	return o.order.finished || o.order.gaveUp
 }
 
 func (q *Pow) enqueue(p *powOrder) {
@@ -62,41 +213,108 @@ func (q *Pow) enqueue(p *powOrder) {
 		next:  nil,
 	}
 
-	if q.head == nil {
-		q.tail = &node.next
-		q.head = node
+	// Find the first order with a lower priority and insert the new
+	// one in front of it.
+	next := &q.head
+	for *next != nil && (*next).order.priority >= p.priority {
+		next = &(*next).next
+	}
+	node.next = *next
+	*next = node
 
-		// Since the queue was empty, it's time to
-		// start the work function again.
+	if !q.working {
+		// Since the work function had stopped, it's
+		// time to start it again.
+		q.working = true
 		go q.work()
-
 		return
 	}
 
-	*q.tail = node
-	q.tail = &node.next
+	q.wake()
 }
 
-func (q *Pow) dequeue() *powOrder {
-	q.mtx.Lock()
-	defer q.mtx.Unlock()
+// wake tells the work function that there may be an order that it can start.
+func (q *Pow) wake() {
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the first order that may be started off the queue and marks it
+// as running. If every order must wait for a time window, next waits. If
+// there are no orders, the work function must stop.
+func (q *Pow) next() *powOrder {
+	for {
+		q.mtx.Lock()
 
-	if q.head == nil {
-		return nil
+		if q.head == nil {
+			q.running = nil
+			q.working = false
+			q.updateOwnLoad(time.Now(), 0)
+			q.mtx.Unlock()
+			return nil
+		}
+
+		now := time.Now()
+		wait := windowInterval
+		for node := &q.head; *node != nil; node = &(*node).next {
+			order := (*node).order
+			ok, until := q.policy.allows(order.priority, now)
+			if ok {
+				*node = (*node).next
+				q.running = order
+				order.started = now
+				q.mtx.Unlock()
+				return order
+			}
+
+			if until < wait {
+				wait = until
+			}
+		}
+
+		q.mtx.Unlock()
+
+		log.Debugf("Waiting %s for a time window to open.", wait)
+		select {
+		case <-q.wakeup:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// fail reports that the backend returned an invalid nonce for the order and
+// puts it back in the queue unless it has been tried too many times.
+func (q *Pow) fail(order *powOrder) {
+	order.attempts++
+	failure := &Failure{
+		Attempts: order.attempts,
+		Retrying: order.attempts < maxAttempts,
 	}
+	log.Errorf("Proof-of-work backend returned an invalid nonce for a %s: %v",
+		order.orderType, failure)
 
-	p := q.head.order
+	if !failure.Retrying {
+		q.mtx.Lock()
+		order.gaveUp = true
+		q.mtx.Unlock()
+	}
 
-	q.head = q.head.next
+	if order.failedFunc != nil {
+		go order.failedFunc(failure)
+	}
 
-	return p
+	if failure.Retrying {
+		q.enqueue(order)
+	}
 }
 
 // work repeatedly checks the head of the queue and does work on anything
 // there until the queue is empty.
 func (q *Pow) work() {
 	for {
-		order := q.dequeue()
+		order := q.next()
 
 		if order == nil {
 			return
@@ -105,10 +323,25 @@ func (q *Pow) work() {
 		hash := hash.Sha512(order.object)
 
 		// run POW for the next object in the queue.
-		n := q.powFunc(order.target, hash)
+		n, ok := q.backend.Do(order.target, hash, policyThrottle{q}, order.cancel)
 
-		// Remove the last item from the queue.
-		q.dequeue()
+		// Don't trust the backend.
+		valid := ok && trialValue(uint64(n), hash) <= uint64(order.target)
+
+		// The order may have been cancelled just as the work was finished.
+		q.mtx.Lock()
+		ok = ok && !order.cancelled
+		order.finished = ok && valid
+		q.running = nil
+		q.mtx.Unlock()
+		if !ok {
+			continue
+		}
+
+		if !valid {
+			q.fail(order)
+			continue
+		}
 
 		// Do whatever we're supposed to do with the nonce.
 		go order.donePowFunc(n)
@@ -0,0 +1,402 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmutil/hash"
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+// testTarget is met by about a quarter of all nonces.
+const testTarget = pow.Target(1 << 62)
+
+// testBackend does proof-of-work sequentially and remembers the hashes that
+// it was given in order. The first order waits until start is closed, and
+// the first few nonces that it returns are invalid.
+type testBackend struct {
+	mtx     sync.Mutex
+	start   chan struct{}
+	invalid int
+	hashes  []string
+}
+
+func (b *testBackend) Do(target pow.Target, hash []byte, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+	b.mtx.Lock()
+	b.hashes = append(b.hashes, string(hash))
+	first := len(b.hashes) == 1
+	invalid := b.invalid > 0
+	if invalid {
+		b.invalid--
+	}
+	b.mtx.Unlock()
+
+	if first && b.start != nil {
+		select {
+		case <-b.start:
+		case <-cancel:
+			return 0, false
+		}
+	}
+
+	if invalid {
+		for nonce := uint64(0); ; nonce++ {
+			if trialValue(nonce, hash) > uint64(target) {
+				return pow.Nonce(nonce), true
+			}
+		}
+	}
+
+	return Sequential(target, hash, throttle, cancel)
+}
+
+func TestQueueOrder(t *testing.T) {
+	backend := &testBackend{start: make(chan struct{})}
+	q := New(backend)
+
+	// Don't measure the hashrate with the test backend.
+	q.hashrate = 1e6
+
+	type result struct {
+		priority Priority
+		sender   int
+		seq      int
+	}
+	var mtx sync.Mutex
+	results := make(map[string]result)
+	done := make(chan struct{}, 100)
+
+	// The first order holds up the queue while the others are given.
+	q.Run(testTarget, []byte("first"), TypeMessage, PriorityNormal, func(pow.Nonce) {}, nil)
+
+	priorities := []Priority{PriorityLow, PriorityNormal, PriorityHigh}
+	senders, orders := 6, 10
+	var wg sync.WaitGroup
+	wg.Add(senders)
+	for i := 0; i < senders; i++ {
+		go func(sender int) {
+			defer wg.Done()
+			for seq := 0; seq < orders; seq++ {
+				r := result{
+					priority: priorities[(sender+seq)%len(priorities)],
+					sender:   sender,
+					seq:      seq,
+				}
+				obj := []byte(fmt.Sprintf("%d %d", sender, seq))
+				mtx.Lock()
+				results[string(hash.Sha512(obj))] = r
+				mtx.Unlock()
+				q.Run(testTarget, obj, TypeMessage, r.priority, func(pow.Nonce) {
+					done <- struct{}{}
+				}, nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	info := q.Queue()
+	if len(info) != senders*orders+1 {
+		t.Fatalf("expected %d orders in the queue, got %d", senders*orders+1, len(info))
+	}
+	for i := 1; i < len(info); i++ {
+		if info[i].Position != i {
+			t.Fatalf("order %d has position %d", i, info[i].Position)
+		}
+		if i > 1 && info[i].Priority > info[i-1].Priority {
+			t.Fatalf("queue out of order at position %d", i)
+		}
+	}
+
+	close(backend.start)
+
+	// Every order must be done exactly once.
+	for i := 0; i < senders*orders; i++ {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("only %d of %d orders were done", i, senders*orders)
+		}
+	}
+	if len(backend.hashes) != senders*orders+1 {
+		t.Fatalf("expected %d orders to be worked on, got %d", senders*orders+1, len(backend.hashes))
+	}
+
+	// The orders must be worked on in order of priority, and the orders of
+	// each sender with the same priority in the order they were given in.
+	last := make(map[Priority]map[int]int)
+	for _, p := range priorities {
+		last[p] = make(map[int]int)
+	}
+	previous := PriorityHigh
+	for _, h := range backend.hashes[1:] {
+		r := results[h]
+		if r.priority > previous {
+			t.Errorf("order with priority %s run after one with priority %s", r.priority, previous)
+		}
+		previous = r.priority
+
+		if seq, ok := last[r.priority][r.sender]; ok && seq > r.seq {
+			t.Errorf("order %d of sender %d run after order %d", r.seq, r.sender, seq)
+		}
+		last[r.priority][r.sender] = r.seq
+	}
+}
+
+func TestLongOrder(t *testing.T) {
+	backend := &testBackend{start: make(chan struct{})}
+	q := New(backend)
+
+	// Don't measure the hashrate with the test backend.
+	q.hashrate = 1e6
+	policy := DefaultPolicy
+	policy.LongOrder = time.Second
+	if err := q.SetPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	// About 2^24 hashes are needed for the hard target, which takes longer
+	// than a second at this hashrate.
+	hard := pow.Target(1 << 40)
+	easy := pow.Target(1 << 61)
+	tests := []struct {
+		target   pow.Target
+		priority Priority
+		expected Priority
+	}{
+		{hard, PriorityNormal, PriorityLow},
+		{easy, PriorityNormal, PriorityNormal},
+		{hard, PriorityHigh, PriorityHigh},
+	}
+
+	// The first order holds up the queue while the others are given.
+	orders := []*Order{q.Run(testTarget, []byte("first"), TypeMessage,
+		PriorityNormal, func(pow.Nonce) {}, nil)}
+	for i, test := range tests {
+		orders = append(orders, q.Run(test.target, []byte(fmt.Sprintf("%d", i)),
+			TypeMessage, test.priority, func(pow.Nonce) {}, nil))
+	}
+
+	info := q.Queue()
+	for i, test := range tests {
+		var found bool
+		for _, o := range info {
+			if o.Target == test.target && o.Priority == test.expected {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("test %d: expected an order with priority %s", i, test.expected)
+		}
+	}
+
+	for _, o := range orders {
+		o.Cancel()
+	}
+	close(backend.start)
+}
+
+func TestLongOrderUnmeasured(t *testing.T) {
+	backend := &testBackend{start: make(chan struct{})}
+	q := New(backend)
+
+	policy := DefaultPolicy
+	policy.LongOrder = time.Second
+	if err := q.SetPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	// Giving an order does not wait for the hashrate to be measured, and
+	// until it is, no order is given low priority.
+	begin := time.Now()
+	first := q.Run(testTarget, []byte("first"), TypeMessage, PriorityNormal, func(pow.Nonce) {}, nil)
+	second := q.Run(pow.Target(1<<20), []byte("second"), TypeMessage, PriorityNormal, func(pow.Nonce) {}, nil)
+	if elapsed := time.Since(begin); elapsed >= benchmarkDuration {
+		t.Errorf("Giving orders took %s", elapsed)
+	}
+	if second.order.priority != PriorityNormal {
+		t.Errorf("Expected normal priority, got %s", second.order.priority)
+	}
+
+	first.Cancel()
+	second.Cancel()
+	close(backend.start)
+}
+
+func TestInvalidNonce(t *testing.T) {
+	tests := []struct {
+		invalid  int
+		failures int
+		done     bool
+	}{
+		{0, 0, true},
+		{1, 1, true},
+		{maxAttempts - 1, maxAttempts - 1, true},
+		{maxAttempts, maxAttempts, false},
+	}
+
+	for i, test := range tests {
+		q := New(&testBackend{invalid: test.invalid})
+
+		done := make(chan pow.Nonce, 1)
+		failed := make(chan error, maxAttempts)
+		obj := []byte("object")
+		order := q.Run(testTarget, obj, TypeMessage, PriorityNormal, func(n pow.Nonce) {
+			done <- n
+		}, func(err error) {
+			failed <- err
+		})
+
+		for j := 0; j < test.failures; j++ {
+			select {
+			case err := <-failed:
+				failure, ok := err.(*Failure)
+				if !ok {
+					t.Fatalf("test %d: unexpected error %v", i, err)
+				}
+				if failure.Retrying != (failure.Attempts < maxAttempts) {
+					t.Errorf("test %d: retrying %t after %d attempts", i,
+						failure.Retrying, failure.Attempts)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("test %d: expected %d failures, got %d", i, test.failures, j)
+			}
+		}
+
+		if !test.done {
+			select {
+			case <-done:
+				t.Errorf("test %d: order done with an invalid nonce", i)
+			case <-time.After(100 * time.Millisecond):
+			}
+			if !order.Finished() {
+				t.Errorf("test %d: order not finished after giving up", i)
+			}
+			continue
+		}
+
+		select {
+		case n := <-done:
+			if trialValue(uint64(n), hash.Sha512(obj)) > uint64(testTarget) {
+				t.Errorf("test %d: invalid nonce %d", i, n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("test %d: order was not done", i)
+		}
+		if !order.Finished() {
+			t.Errorf("test %d: order not finished after it was done", i)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	easy := pow.Data{NonceTrialsPerByte: 1, ExtraBytes: 1}
+	hard := pow.Data{NonceTrialsPerByte: 1 << 40, ExtraBytes: 1}
+	expiration := time.Now().Add(time.Hour)
+
+	payload := []byte("an object which is received from the network")
+	target := pow.CalculateTarget(uint64(len(payload)), uint64(time.Hour/time.Second), easy)
+	n, _ := Sequential(target, hash.Sha512(payload), nil, nil)
+	object := append(n.Bytes(), payload...)
+
+	if !Check(object, expiration, easy) {
+		t.Error("Expected the nonce to meet the easy difficulty")
+	}
+	if Check(object, expiration, hard) {
+		t.Error("Expected the nonce not to meet the hard difficulty")
+	}
+	if Check(object[:4], expiration, easy) {
+		t.Error("Expected an object without a nonce to fail")
+	}
+}
+
+func TestThrottleWindow(t *testing.T) {
+	q := New(&testBackend{})
+
+	// A window which closed an hour ago.
+	now := sinceMidnight(time.Now())
+	window := Window{
+		Start: (now + 21*time.Hour) % (24 * time.Hour),
+		End:   (now + 23*time.Hour) % (24 * time.Hour),
+	}
+	if err := q.SetPolicy(Policy{DutyCycle: 100, Windows: []Window{window}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		priority Priority
+		paused   bool
+	}{
+		{PriorityLow, true},
+		{PriorityNormal, false},
+		{PriorityHigh, false},
+	} {
+		q.mtx.Lock()
+		q.running = &powOrder{priority: test.priority}
+		q.mtx.Unlock()
+
+		if _, paused := q.throttleState(); paused != test.paused {
+			t.Errorf("%s priority: expected paused %t, got %t", test.priority, test.paused, paused)
+		}
+	}
+}
+
+func TestOwnLoad(t *testing.T) {
+	q := New(&testBackend{})
+	q.localThreads = 4
+
+	start := time.Now()
+	q.updateOwnLoad(start, 0.5)
+
+	// After a long time, the load caused by two threads is two.
+	q.updateOwnLoad(start.Add(time.Hour), 0)
+	if q.ownLoad < 1.99 || q.ownLoad > 2 {
+		t.Errorf("Expected an own load of 2, got %f", q.ownLoad)
+	}
+
+	// Then it goes away again.
+	q.updateOwnLoad(start.Add(2*time.Hour), 0)
+	if q.ownLoad > 0.01 {
+		t.Errorf("Expected no own load, got %f", q.ownLoad)
+	}
+}
+
+func TestSetCalibration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "powmgr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "calibration.json")
+
+	// Without a calibration, the hashrate is measured and saved.
+	q := New(Func(Sequential))
+	q.SetCalibration(file, 1)
+	hashrate := q.Hashrate()
+
+	c, err := LoadCalibration(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved, ok := c.Hashrate(1); !ok || saved != hashrate {
+		t.Errorf("Expected a saved hashrate of %f, got %f", hashrate, saved)
+	}
+
+	// The next time, the calibration is used.
+	q = New(Func(Sequential))
+	q.SetCalibration(file, 1)
+	q.mtx.Lock()
+	calibrated := q.hashrate
+	q.mtx.Unlock()
+	if calibrated != hashrate {
+		t.Errorf("Expected the calibrated hashrate %f, got %f", hashrate, calibrated)
+	}
+}
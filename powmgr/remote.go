@@ -0,0 +1,232 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/DanielKrawisz/bmagent/powmgr/rpc"
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+const (
+	// workerTimeoutFactor is how many times as long as the work is expected
+	// to take locally a worker is given before it is considered to have
+	// failed.
+	workerTimeoutFactor = 10
+
+	// minWorkerTimeout and maxWorkerTimeout bound the time a worker is
+	// given. No object lives longer than maxWorkerTimeout, so there is no
+	// point in waiting longer for one.
+	minWorkerTimeout = time.Minute
+	maxWorkerTimeout = 28 * 24 * time.Hour
+)
+
+// errInvalidNonce is returned when a worker returns a nonce which does not
+// meet the target.
+var errInvalidNonce = errors.New("invalid nonce")
+
+// worker is a connection to an external proof-of-work worker.
+type worker struct {
+	addr   string
+	client rpc.PowWorkerClient
+}
+
+// Remote is a Backend which sends proof-of-work to external workers. The
+// nonces are divided between the workers and the first valid nonce that
+// any of them returns is used. Once any of them fails, the work is also
+// done by the local backend.
+type Remote struct {
+	workers []worker
+	local   Backend
+
+	// hashrate is the number of hashes per second that the local backend
+	// computes, or zero if it is not known yet.
+	hashrate float64
+	mtx      sync.Mutex
+}
+
+// NewRemote creates a Remote backend for the workers at the given addresses.
+// If caFile is empty, the connections to them are not encrypted. Workers
+// that cannot be reached are skipped until they can be.
+func NewRemote(addrs []string, caFile string, local Backend) (*Remote, error) {
+	opts := []grpc.DialOption{}
+	if caFile == "" {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		creds, err := credentials.NewClientTLSFromFile(caFile, "")
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create TLS credentials %v", err)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	}
+
+	r := &Remote{
+		workers: make([]worker, 0, len(addrs)),
+		local:   local,
+	}
+	for _, addr := range addrs {
+		conn, err := grpc.Dial(addr, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to dial %s: %v", addr, err)
+		}
+
+		r.workers = append(r.workers, worker{
+			addr:   addr,
+			client: rpc.NewPowWorkerClient(conn),
+		})
+	}
+
+	return r, nil
+}
+
+// SetHashrate sets the number of hashes per second that the local backend
+// computes, from which it is worked out how long the workers are given. If
+// it is not set, the local backend is measured the first time that there is
+// work to do.
+func (r *Remote) SetHashrate(hashrate float64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.hashrate = hashrate
+}
+
+// workerTimeout returns how long the workers are given to do the work for
+// the target. The workers should not be slower than the local backend, but
+// they are given many times as long as it would need before the work is
+// done locally as well.
+func (r *Remote) workerTimeout(target pow.Target) time.Duration {
+	r.mtx.Lock()
+	if r.hashrate == 0 {
+		r.hashrate = benchmark(r.local)
+		log.Infof("Measured a local hashrate of %.0f hashes per second.", r.hashrate)
+	}
+	hashrate := r.hashrate
+	r.mtx.Unlock()
+
+	// On average, 2^64 / target hashes are tried before one is found which
+	// meets the target.
+	seconds := math.Exp2(64) / float64(target) / hashrate * workerTimeoutFactor
+	switch {
+	case seconds >= maxWorkerTimeout.Seconds():
+		return maxWorkerTimeout
+	case seconds <= minWorkerTimeout.Seconds():
+		return minWorkerTimeout
+	default:
+		return time.Duration(seconds * float64(time.Second))
+	}
+}
+
+// Do sends the work to every worker and returns the first valid nonce. As
+// soon as a worker fails, the work is done locally as well, and whichever
+// finishes first is used. The throttle only applies to the local work.
+func (r *Remote) Do(target pow.Target, hash []byte, throttle Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	// Stop the workers and the local work if the order is cancelled.
+	go func() {
+		select {
+		case <-cancel:
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+
+	// A worker which does not answer in time has failed.
+	workerCtx, stopWorkers := context.WithTimeout(ctx, r.workerTimeout(target))
+	defer stopWorkers()
+
+	type result struct {
+		addr  string
+		nonce pow.Nonce
+		err   error
+	}
+
+	version := uint32(1)
+	t := uint64(target)
+	step := uint64(len(r.workers))
+	results := make(chan result, len(r.workers))
+	for i, w := range r.workers {
+		go func(w worker, start uint64) {
+			reply, err := w.client.Work(workerCtx, &rpc.WorkRequest{
+				Version: &version,
+				Target:  &t,
+				Hash:    hash,
+				Start:   &start,
+				Step:    &step,
+			})
+			if err != nil {
+				results <- result{addr: w.addr, err: err}
+				return
+			}
+
+			// Don't trust the worker.
+			nonce := reply.GetNonce()
+			if trialValue(nonce, hash) > t {
+				results <- result{addr: w.addr, err: errInvalidNonce}
+				return
+			}
+
+			results <- result{addr: w.addr, nonce: pow.Nonce(nonce)}
+		}(w, uint64(i))
+	}
+
+	// local is nil until the local work is started. The local work is
+	// stopped when Do returns.
+	type localResult struct {
+		nonce pow.Nonce
+		ok    bool
+	}
+	var local chan localResult
+	startLocal := func() {
+		local = make(chan localResult, 1)
+		go func() {
+			nonce, ok := r.local.Do(target, hash, throttle, ctx.Done())
+			local <- localResult{nonce, ok}
+		}()
+	}
+
+	if len(r.workers) == 0 {
+		log.Info("No proof-of-work worker is available; doing the work locally.")
+		startLocal()
+	}
+
+	for {
+		select {
+		case res := <-results:
+			if res.err == nil {
+				log.Debugf("Proof-of-work done by worker %s.", res.addr)
+				return res.nonce, true
+			}
+
+			select {
+			case <-cancel:
+				return 0, false
+			default:
+			}
+
+			log.Warnf("Proof-of-work worker %s failed: %v", res.addr, res.err)
+			if local == nil {
+				log.Info("Doing the proof-of-work locally as well.")
+				startLocal()
+			}
+
+		case res := <-local:
+			if res.ok {
+				log.Debug("Proof-of-work done locally.")
+			}
+			return res.nonce, res.ok
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package rpc contains the protocol with which the proof-of-work manager
+// sends work to external workers.
+package rpc
+
+//go:generate protoc --go_out=plugins=grpc:. worker.proto
@@ -0,0 +1,205 @@
+// Code generated by protoc-gen-go.
+// source: worker.proto
+// DO NOT EDIT!
+
+/*
+Package rpc is a generated protocol buffer package.
+
+It is generated from these files:
+	worker.proto
+
+It has these top-level messages:
+	WorkRequest
+	WorkReply
+*/
+package rpc
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type WorkRequest struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Target           *uint64 `protobuf:"varint,2,opt,name=target" json:"target,omitempty"`
+	Hash             []byte  `protobuf:"bytes,3,opt,name=hash" json:"hash,omitempty"`
+	Start            *uint64 `protobuf:"varint,4,opt,name=start" json:"start,omitempty"`
+	Step             *uint64 `protobuf:"varint,5,opt,name=step" json:"step,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *WorkRequest) Reset()                    { *m = WorkRequest{} }
+func (m *WorkRequest) String() string            { return proto.CompactTextString(m) }
+func (*WorkRequest) ProtoMessage()               {}
+func (*WorkRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *WorkRequest) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *WorkRequest) GetTarget() uint64 {
+	if m != nil && m.Target != nil {
+		return *m.Target
+	}
+	return 0
+}
+
+func (m *WorkRequest) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *WorkRequest) GetStart() uint64 {
+	if m != nil && m.Start != nil {
+		return *m.Start
+	}
+	return 0
+}
+
+func (m *WorkRequest) GetStep() uint64 {
+	if m != nil && m.Step != nil {
+		return *m.Step
+	}
+	return 0
+}
+
+type WorkReply struct {
+	Version          *uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Nonce            *uint64 `protobuf:"varint,2,opt,name=nonce" json:"nonce,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *WorkReply) Reset()                    { *m = WorkReply{} }
+func (m *WorkReply) String() string            { return proto.CompactTextString(m) }
+func (*WorkReply) ProtoMessage()               {}
+func (*WorkReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *WorkReply) GetVersion() uint32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *WorkReply) GetNonce() uint64 {
+	if m != nil && m.Nonce != nil {
+		return *m.Nonce
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*WorkRequest)(nil), "rpc.WorkRequest")
+	proto.RegisterType((*WorkReply)(nil), "rpc.WorkReply")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion3
+
+// Client API for PowWorker service
+
+type PowWorkerClient interface {
+	Work(ctx context.Context, in *WorkRequest, opts ...grpc.CallOption) (*WorkReply, error)
+}
+
+type powWorkerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPowWorkerClient(cc *grpc.ClientConn) PowWorkerClient {
+	return &powWorkerClient{cc}
+}
+
+func (c *powWorkerClient) Work(ctx context.Context, in *WorkRequest, opts ...grpc.CallOption) (*WorkReply, error) {
+	out := new(WorkReply)
+	err := grpc.Invoke(ctx, "/rpc.PowWorker/Work", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for PowWorker service
+
+type PowWorkerServer interface {
+	Work(context.Context, *WorkRequest) (*WorkReply, error)
+}
+
+func RegisterPowWorkerServer(s *grpc.Server, srv PowWorkerServer) {
+	s.RegisterService(&_PowWorker_serviceDesc, srv)
+}
+
+func _PowWorker_Work_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WorkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PowWorkerServer).Work(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.PowWorker/Work",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PowWorkerServer).Work(ctx, req.(*WorkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _PowWorker_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpc.PowWorker",
+	HandlerType: (*PowWorkerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Work",
+			Handler:    _PowWorker_Work_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: fileDescriptor0,
+}
+
+func init() { proto.RegisterFile("worker.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 198 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0xe2, 0x29, 0xcf, 0x2f, 0xca,
+	0x4e, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2e, 0x2a, 0x48, 0x56, 0xaa, 0xe5,
+	0xe2, 0x0e, 0xcf, 0x2f, 0xca, 0x0e, 0x4a, 0x2d, 0x2c, 0x4d, 0x2d, 0x2e, 0x11, 0x92, 0xe0, 0x62,
+	0x2f, 0x4b, 0x2d, 0x2a, 0xce, 0xcc, 0xcf, 0x93, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0d, 0x82, 0x71,
+	0x85, 0xc4, 0xb8, 0xd8, 0x4a, 0x12, 0x8b, 0xd2, 0x53, 0x4b, 0x24, 0x98, 0x14, 0x18, 0x35, 0x58,
+	0x82, 0xa0, 0x3c, 0x21, 0x21, 0x2e, 0x96, 0x8c, 0xc4, 0xe2, 0x0c, 0x09, 0x66, 0x05, 0x46, 0x0d,
+	0x9e, 0x20, 0x30, 0x5b, 0x48, 0x84, 0x8b, 0xb5, 0xb8, 0x24, 0xb1, 0xa8, 0x44, 0x82, 0x05, 0xac,
+	0x14, 0xc2, 0x01, 0xa9, 0x2c, 0x2e, 0x49, 0x2d, 0x90, 0x60, 0x05, 0x0b, 0x82, 0xd9, 0x4a, 0xd6,
+	0x5c, 0x9c, 0x10, 0xeb, 0x0b, 0x72, 0x2a, 0xf1, 0x58, 0x2e, 0xc2, 0xc5, 0x9a, 0x97, 0x9f, 0x97,
+	0x9c, 0x0a, 0xb5, 0x1b, 0xc2, 0x31, 0x32, 0xe7, 0xe2, 0x0c, 0xc8, 0x2f, 0x0f, 0x07, 0xfb, 0x49,
+	0x48, 0x8b, 0x8b, 0x05, 0xc4, 0x12, 0x12, 0xd0, 0x2b, 0x2a, 0x48, 0xd6, 0x43, 0xf2, 0x93, 0x14,
+	0x1f, 0x92, 0x48, 0x41, 0x4e, 0xa5, 0x12, 0x83, 0x13, 0x93, 0x07, 0x33, 0x60, 0x00, 0x9c, 0x21,
+	0xc9, 0x39, 0x0c, 0x01, 0x00, 0x00,
+}
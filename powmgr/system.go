@@ -0,0 +1,57 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// loadAvgFile contains the load average on Linux.
+	loadAvgFile = "/proc/loadavg"
+
+	// powerSupplyDir contains a directory for every power supply on Linux.
+	powerSupplyDir = "/sys/class/power_supply"
+)
+
+// loadAverage returns the load average over the last minute.
+func loadAverage() (float64, error) {
+	b, err := ioutil.ReadFile(loadAvgFile)
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// onBattery returns whether the computer is running on a battery, which is
+// the case if any battery is discharging.
+func onBattery() (bool, error) {
+	supplies, err := filepath.Glob(filepath.Join(powerSupplyDir, "*"))
+	if err != nil {
+		return false, err
+	}
+
+	for _, supply := range supplies {
+		kind, err := ioutil.ReadFile(filepath.Join(supply, "type"))
+		if err != nil || strings.TrimSpace(string(kind)) != "Battery" {
+			continue
+		}
+
+		status, err := ioutil.ReadFile(filepath.Join(supply, "status"))
+		if err == nil && strings.TrimSpace(string(status)) == "Discharging" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
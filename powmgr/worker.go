@@ -0,0 +1,81 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package powmgr
+
+import (
+	"crypto/sha512"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/DanielKrawisz/bmagent/powmgr/rpc"
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+// DefaultWorkerPort is the port on which a worker listens by default.
+const DefaultWorkerPort = 8447
+
+// Worker calculates proof-of-work in another process for a Remote backend.
+// It implements rpc.PowWorkerServer.
+type Worker struct {
+	threads int
+
+	// jobs has room for as many requests as may be worked on at once.
+	jobs chan struct{}
+}
+
+// NewWorker creates a Worker that uses the given number of threads for each
+// request and works on at most the given number of requests at once.
+func NewWorker(threads, jobs int) *Worker {
+	if threads < 1 {
+		threads = 1
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	return &Worker{
+		threads: threads,
+		jobs:    make(chan struct{}, jobs),
+	}
+}
+
+// Work searches the nonces given in the request until it finds one that
+// meets the target or the request is cancelled. If the worker is already
+// working on as many requests as it may, the request is refused, so that
+// the client does the work itself.
+func (w *Worker) Work(ctx context.Context, r *rpc.WorkRequest) (*rpc.WorkReply, error) {
+	if r.GetVersion() != 1 || r.Target == nil || len(r.Hash) != sha512.Size {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid work request")
+	}
+
+	select {
+	case w.jobs <- struct{}{}:
+		defer func() { <-w.jobs }()
+	default:
+		return nil, grpc.Errorf(codes.ResourceExhausted, "too many requests")
+	}
+
+	step := r.GetStep()
+	if step == 0 {
+		step = 1
+	}
+
+	log.Debugf("Starting proof-of-work for target %d.", r.GetTarget())
+	nonce, ok := searchParallel(pow.Target(r.GetTarget()), r.Hash,
+		r.GetStart(), step, w.threads, nil, ctx.Done())
+	if !ok {
+		log.Debug("Proof-of-work cancelled.")
+		return nil, grpc.Errorf(codes.Canceled, "work cancelled")
+	}
+
+	version := uint32(1)
+	n := uint64(nonce)
+	return &rpc.WorkReply{
+		Version: &version,
+		Nonce:   &n,
+	}, nil
+}
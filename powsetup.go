@@ -0,0 +1,44 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/DanielKrawisz/bmagent/powmgr"
+)
+
+// benchmarkPow measures how fast proof-of-work is done with the sequential
+// handler and with the parallel handler with different numbers of threads,
+// saves the result and recommends a number of threads.
+func benchmarkPow(cfg *Config) error {
+	max := runtime.NumCPU()
+	if cfg.PowThreads > max {
+		max = cfg.PowThreads
+	}
+
+	fmt.Println("Measuring proof-of-work. This takes about a second for each number of threads.")
+	c := powmgr.Calibrate(max)
+	for _, r := range c.Rates {
+		handler := "parallel"
+		if r.Threads == 1 {
+			handler = "sequential"
+		}
+		fmt.Printf("%3d threads (%s): %.0f hashes per second\n", r.Threads, handler, r.Hashrate)
+	}
+
+	if threads := c.Recommend(); threads == 1 {
+		fmt.Println("Recommended: --pow=sequential")
+	} else {
+		fmt.Printf("Recommended: --pow=parallel --powthreads=%d\n", threads)
+	}
+
+	if err := c.Save(cfg.calibrationPath); err != nil {
+		return err
+	}
+	fmt.Println("Saved to", cfg.calibrationPath)
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/powmgr"
+	"github.com/DanielKrawisz/bmagent/store"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmutil/cipher"
+	"github.com/DanielKrawisz/bmutil/pow"
+	"github.com/DanielKrawisz/bmutil/wire"
+)
+
+// publishPubkey generates a pubkey for the given identity and sends it out
+// once the proof-of-work is done, recording when it was sent. It does nothing
+// if a pubkey for the identity is already waiting for proof-of-work.
+func (s *server) publishPubkey(privID *keys.PrivateID) {
+	addr := privID.Address().String()
+
+	s.publishingMtx.Lock()
+	if _, ok := s.publishing[addr]; ok {
+		s.publishingMtx.Unlock()
+		serverLog.Debugf("Pubkey for %s is already waiting for proof-of-work.", addr)
+		return
+	}
+	s.publishing[addr] = struct{}{}
+	s.publishingMtx.Unlock()
+
+	done := func() {
+		s.publishingMtx.Lock()
+		delete(s.publishing, addr)
+		s.publishingMtx.Unlock()
+	}
+
+	// Generate a pubkey message.
+	pkMsg, err := cipher.GeneratePubKey(privID.Private, defaultPubkeyExpiry)
+	if err != nil {
+		serverLog.Errorf("Failed to generate pubkey for %s: %v", addr, err)
+		done()
+		return
+	}
+
+	// Add it to pow queue.
+	pkObj := pkMsg.Object()
+	expires := pkObj.Header().Expiration()
+	b := wire.Encode(pkObj)[8:] // exclude nonce
+	target := pow.CalculateTarget(uint64(len(b)),
+		uint64(expires.Sub(time.Now()).Seconds()), pow.Default)
+
+	s.pow.Run(target, b, powmgr.TypePubKey, powmgr.TypePubKey.Priority(), func(nonce pow.Nonce) {
+		defer done()
+
+		if err := s.Send(append(nonce.Bytes(), b...)); err != nil {
+			return
+		}
+
+		err := s.store.Published().Put(addr, &store.Publication{
+			Sent:    time.Now(),
+			Expires: expires,
+		})
+		if err != nil {
+			serverLog.Errorf("Failed to record publication of pubkey for %s: %v", addr, err)
+		}
+	}, func(err error) {
+		if failure, ok := err.(*powmgr.Failure); ok && failure.Retrying {
+			return
+		}
+
+		serverLog.Errorf("Failed to do proof-of-work for pubkey of %s: %v", addr, err)
+		done()
+	})
+}
+
+// pubkeyOut returns whether the last pubkey that we sent for the given
+// address is still on the network and will not need to be sent again soon.
+func (s *server) pubkeyOut(addr string, now time.Time) bool {
+	pub, err := s.store.Published().Get(addr)
+	if err != nil {
+		if err != data.ErrNotFound {
+			serverLog.Errorf("Failed to read publication of pubkey for %s: %v", addr, err)
+		}
+		return false
+	}
+
+	return now.Before(pub.Expires.Add(-pubkeyRepublishMargin))
+}
+
+// requestBurst counts the getpubkey requests for one of our identities since
+// the start of a window of time.
+type requestBurst struct {
+	start time.Time
+	count uint32
+}
+
+// countGetpubkey records a getpubkey request for the given address and logs
+// a warning if there have been suspiciously many of them recently. It
+// returns whether a warning was logged.
+func (s *server) countGetpubkey(addr string, now time.Time) bool {
+	burst, ok := s.getpubkeyBursts[addr]
+	if !ok || now.Sub(burst.start) > getpubkeyBurstWindow {
+		burst = &requestBurst{start: now}
+		s.getpubkeyBursts[addr] = burst
+	}
+	burst.count++
+
+	// Warn once per window.
+	if burst.count != getpubkeyBurstLimit {
+		return false
+	}
+
+	serverLog.Warnf("Received %d getpubkey requests for %s since %s. Someone may be "+
+		"trying to make us do proof-of-work.", burst.count, addr, burst.start.Format(time.RFC822))
+	return true
+}
+
+// publishDue sends out the pubkeys of the enabled identities other than chans
+// which have never been published or which will soon expire.
+func (s *server) publishDue() {
+	now := time.Now()
+	for _, u := range s.users {
+		u.Keys.ForEach(func(id *keys.PrivateID) error {
+			if id.Disabled || id.IsChan {
+				return nil
+			}
+
+			addr := id.Address().String()
+			if s.pubkeyOut(addr, now) {
+				return nil
+			}
+
+			serverLog.Infof("Publishing pubkey for %s.", addr)
+			s.publishPubkey(id)
+			return nil
+		})
+	}
+}
+
+// Publish asks for the pubkeys which are due to be sent out, such as those of
+// newly created identities, to be published right away.
+func (s *server) Publish() {
+	select {
+	case s.publishNow <- struct{}{}:
+	default: // Already asked.
+	}
+}
+
+// pubkeyHandler publishes the pubkeys of our identities when bmagent starts,
+// whenever Publish is called, and periodically so that they are sent out
+// again before they expire.
+func (s *server) pubkeyHandler() {
+	defer s.wg.Done()
+
+	s.publishDue()
+
+	t := time.NewTicker(pubkeyCheckInterval)
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-t.C:
+			s.publishDue()
+		case <-s.publishNow:
+			s.publishDue()
+		}
+	}
+}
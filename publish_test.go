@@ -0,0 +1,43 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountGetpubkey(t *testing.T) {
+	s := &server{
+		getpubkeyBursts: make(map[string]*requestBurst),
+	}
+	addr := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+	other := "BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8"
+	now := time.Now()
+
+	// A burst is reported once, when the limit is reached.
+	for i := 1; i <= 2*getpubkeyBurstLimit; i++ {
+		warned := s.countGetpubkey(addr, now.Add(time.Duration(i)*time.Second))
+		if warned != (i == getpubkeyBurstLimit) {
+			t.Errorf("Request %d: expected warning %t, got %t", i, i == getpubkeyBurstLimit, warned)
+		}
+	}
+
+	// Requests for other identities are counted separately.
+	if s.countGetpubkey(other, now) {
+		t.Error("Expected no warning for another identity")
+	}
+
+	// The count starts again after the window.
+	later := now.Add(2 * getpubkeyBurstWindow)
+	for i := 1; i < getpubkeyBurstLimit; i++ {
+		if s.countGetpubkey(addr, later) {
+			t.Errorf("Request %d after the window: expected no warning", i)
+		}
+	}
+	if !s.countGetpubkey(addr, later) {
+		t.Error("Expected a warning for a new burst")
+	}
+}
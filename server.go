@@ -9,8 +9,10 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/sha256"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -23,6 +25,7 @@ import (
 	"github.com/DanielKrawisz/bmagent/store"
 	"github.com/DanielKrawisz/bmagent/user"
 	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmagent/user/imapext"
 	"github.com/DanielKrawisz/bmutil"
 	"github.com/DanielKrawisz/bmutil/cipher"
 	"github.com/DanielKrawisz/bmutil/hash"
@@ -30,6 +33,7 @@ import (
 	"github.com/DanielKrawisz/bmutil/pow"
 	"github.com/DanielKrawisz/bmutil/wire"
 	"github.com/DanielKrawisz/bmutil/wire/obj"
+	"github.com/btcsuite/btcutil"
 	"github.com/jordwest/imap-server"
 )
 
@@ -45,6 +49,24 @@ const (
 	// saveInterval is the interval after which data in memory should be saved
 	// to disk.
 	saveInterval = time.Minute * 5
+
+	// scheduleInterval is the interval after which bmclient should check
+	// for messages which are scheduled to be sent.
+	scheduleInterval = time.Minute
+
+	// pubkeyCheckInterval is the interval after which bmclient should check
+	// whether the pubkeys of our identities need to be sent out again.
+	pubkeyCheckInterval = time.Hour
+
+	// pubkeyRepublishMargin is how long before a pubkey expires that it is
+	// sent out again, which leaves time for the proof-of-work.
+	pubkeyRepublishMargin = time.Hour * 24 * 2
+
+	// getpubkeyBurstWindow and getpubkeyBurstLimit define a burst of
+	// getpubkey requests for one of our identities, which is logged because
+	// it may be an attempt to make us waste time on proof-of-work.
+	getpubkeyBurstWindow = time.Hour
+	getpubkeyBurstLimit  = 10
 )
 
 // server struct manages everything that a running instance of bmclient
@@ -66,6 +88,11 @@ type server struct {
 	imap             *imap.Server
 	imapUser         map[uint32]*user.User
 	imapListeners    []net.Listener
+	contacts         map[string]struct{}
+	publishing       map[string]struct{} // Pubkeys waiting for proof-of-work.
+	publishingMtx    sync.Mutex
+	publishNow       chan struct{}
+	getpubkeyBursts  map[string]*requestBurst // Only used by newGetpubkey.
 	quit             chan struct{}
 	wg               sync.WaitGroup
 }
@@ -74,17 +101,29 @@ type server struct {
 func newServer(rpcc *rpc.ClientConfig, u *User, s *store.Store, pk *store.PKRequests) (*server, error) {
 
 	srvr := &server{
-		users:         make(map[uint32]*User),
-		store:         s,
-		pk:            pk,
-		smtpListeners: make([]net.Listener, 0, len(cfg.SMTPListeners)),
-		imapListeners: make([]net.Listener, 0, len(cfg.IMAPListeners)),
-		quit:          make(chan struct{}),
-		imapUser:      make(map[uint32]*user.User),
-		pow:           powmgr.New(cfg.powHandler),
+		users:           make(map[uint32]*User),
+		store:           s,
+		pk:              pk,
+		smtpListeners:   make([]net.Listener, 0, len(cfg.SMTPListeners)),
+		imapListeners:   make([]net.Listener, 0, len(cfg.IMAPListeners)),
+		quit:            make(chan struct{}),
+		imapUser:        make(map[uint32]*user.User),
+		pow:             powmgr.New(cfg.powHandler),
+		contacts:        make(map[string]struct{}),
+		publishing:      make(map[string]struct{}),
+		publishNow:      make(chan struct{}, 1),
+		getpubkeyBursts: make(map[string]*requestBurst),
+	}
+	for _, addr := range cfg.Contacts {
+		srvr.contacts[addr] = struct{}{}
+	}
+
+	err := srvr.pow.SetPolicy(cfg.powPolicy)
+	if err != nil {
+		return nil, err
 	}
+	srvr.pow.SetCalibration(cfg.calibrationPath, cfg.powThreads)
 
-	var err error
 	srvr.bmd, err = rpc.NewClient(rpcc, srvr.newMessage, srvr.newBroadcast, srvr.newGetpubkey)
 	if err != nil {
 		log.Errorf("Cannot create bmd server RPC client: %v", err)
@@ -99,7 +138,7 @@ func newServer(rpcc *rpc.ClientConfig, u *User, s *store.Store, pk *store.PKRequ
 	}
 
 	so := &serverOps{
-		pubIDs: make(map[string]identity.Public),
+		pubIDs: make(map[string]cachedPublicID),
 		id:     1,
 		user:   u,
 		server: srvr,
@@ -114,8 +153,23 @@ func newServer(rpcc *rpc.ClientConfig, u *User, s *store.Store, pk *store.PKRequ
 	if err != nil {
 		return nil, err
 	}
+	imapUser.SetPowLimits(cfg.powLimits)
 	srvr.imapUser[1] = imapUser
 
+	// Create folders for each identity, chan and subscription.
+	if cfg.VirtualFolders {
+		var broadcasts []string
+		userData.BroadcastAddresses.ForEach(func(addr bmutil.Address) error {
+			broadcasts = append(broadcasts, addr.String())
+			return nil
+		})
+
+		err = imapUser.EnableVirtualFolders(broadcasts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Setup SMTP and IMAP servers.
 	srvr.smtp = user.NewSMTPServer(&email.SMTPConfig{
 		RequireTLS: !cfg.DisableServerTLS,
@@ -131,13 +185,22 @@ func newServer(rpcc *rpc.ClientConfig, u *User, s *store.Store, pk *store.PKRequ
 	// Setup tracer for IMAP.
 	// srvr.imap.Transcript = os.Stderr
 
-	// Setup IMAP listeners.
+	// Setup IMAP listeners. TLS is handled in front of imap-server so
+	// that the commands which it does not support can be read.
+	var imapTLS *tls.Config
+	if !cfg.DisableServerTLS {
+		imapTLS, err = serverTLSConfig(cfg.RPCCert, cfg.RPCKey)
+		if err != nil {
+			return nil, imapLog.Criticalf("Failed to load TLS certificate: %v", err)
+		}
+	}
 	for _, laddr := range cfg.IMAPListeners {
 		l, err := net.Listen("tcp", laddr)
 		if err != nil {
 			return nil, imapLog.Criticalf("Failed to listen on %s: %v", l, err)
 		}
-		srvr.imapListeners = append(srvr.imapListeners, l)
+		srvr.imapListeners = append(srvr.imapListeners,
+			imapext.NewListener(l, imapUser, imapTLS))
 	}
 
 	// Setup SMTP listeners.
@@ -212,6 +275,14 @@ func (s *server) Start() {
 	// Start saving data periodically.
 	s.wg.Add(1)
 	go s.savePeriodically()
+
+	// Start sending scheduled messages.
+	s.wg.Add(1)
+	go s.scheduleHandler()
+
+	// Start publishing the pubkeys of our identities.
+	s.wg.Add(1)
+	go s.pubkeyHandler()
 }
 
 var errSuccessCode = errors.New("decryption successful")
@@ -261,6 +332,8 @@ func (s *server) newMessage(counter uint64, object []byte) {
 
 	var id uint32
 	var message *cipher.Message
+	// The proof-of-work required by the identity used to decrypt the message.
+	var powData *pow.Data
 
 	// Try decrypting with all available identities.
 	for uid, user := range s.users {
@@ -270,6 +343,7 @@ func (s *server) newMessage(counter uint64, object []byte) {
 			if decryptErr == nil {
 				address = id.Address().String()
 				ofChan = id.IsChan
+				powData = id.Data().Pow
 				//id = uid
 				return errSuccessCode
 			}
@@ -290,7 +364,39 @@ func (s *server) newMessage(counter uint64, object []byte) {
 
 	// Decryption was successful.
 
-	// TODO Store public key of the sender in bmagent
+	// Check that the sender did as much proof-of-work as our identity
+	// demands. Contacts are exempt. No ack is sent for messages which fail.
+	sender := message.Bitmessage().Public.Address().String()
+	if _, ok := s.contacts[sender]; !ok && powData != nil &&
+		!powmgr.Check(object, msg.Header().Expiration(), *powData) {
+		if !cfg.PowQuarantine {
+			serverLog.Infof("Dropped message #%d from %s to %s, which did not meet the proof-of-work required.",
+				counter, sender, address)
+			return
+		}
+
+		bmsg, err := email.MsgRead(message, address, ofChan)
+		if err != nil {
+			log.Errorf("Failed to decode message #%d: %v", counter, err)
+			return
+		}
+
+		serverLog.Infof("Quarantined message #%d from %s to %s, which did not meet the proof-of-work required.",
+			counter, sender, address)
+		err = s.imapUser[id].DeliverQuarantined(bmsg)
+		if err != nil {
+			log.Errorf("Failed to save message #%d: %v", counter, err)
+		}
+		return
+	}
+
+	// Store public key of the sender so that we can reply without having to
+	// request it.
+	err = s.store.PubKeys().Put(message.Bitmessage().Public)
+	if err != nil {
+		serverLog.Errorf("Failed to store public key of sender of message #%d: %v",
+			counter, err)
+	}
 
 	// Read message.
 	bmsg, err := email.MsgRead(message, address, ofChan)
@@ -431,27 +537,21 @@ func (s *server) newGetpubkey(counter uint64, object []byte) {
 		return
 	}
 
-	addr := privID.Address()
-	serverLog.Infof("Received a getpubkey request for %s; sending out the pubkey.", addr)
+	addr := privID.Address().String()
+	now := time.Now()
+	s.countGetpubkey(addr, now)
 
-	// Generate a pubkey message.
-	pkMsg, err := cipher.GeneratePubKey(privID.Private, defaultPubkeyExpiry)
-	if err != nil {
-		serverLog.Errorf("Failed to generate pubkey for %s: %v", addr, err)
+	// Don't generate a new pubkey while the last one that we sent is still
+	// on the network, so that requests can't be used to make us do
+	// proof-of-work over and over.
+	if s.pubkeyOut(addr, now) {
+		serverLog.Debugf("Received a getpubkey request for %s; ignoring it because "+
+			"our pubkey is already on the network.", addr)
 		return
 	}
 
-	// Add it to pow queue.
-	pkObj := pkMsg.Object()
-	pkHeader := pkObj.Header()
-	b := wire.Encode(pkObj)[8:] // exclude nonce
-	target := pow.CalculateTarget(uint64(len(b)),
-		uint64(pkHeader.Expiration().Sub(time.Now()).Seconds()),
-		pow.Default)
-
-	s.pow.Run(target, b, func(nonce pow.Nonce) {
-		s.Send(append(nonce.Bytes(), b...))
-	})
+	serverLog.Infof("Received a getpubkey request for %s; sending out the pubkey.", addr)
+	s.publishPubkey(privID)
 }
 
 // pkRequestHandler manages the pubkey request store. It periodically checks
@@ -512,6 +612,11 @@ func (s *server) pkRequestHandler() {
 				serverLog.Debugf("Received pubkey for %s. Processing pending messages.",
 					address)
 
+				err := s.store.PubKeys().Put(public)
+				if err != nil {
+					serverLog.Error("Failed to store public key: ", err)
+				}
+
 				// Process pending messages with this public identity and add
 				// them to pow queue.
 				for _, user := range s.imapUser {
@@ -523,7 +628,7 @@ func (s *server) pkRequestHandler() {
 
 				// Now that we have the public identities, remove them from the
 				// PK request store.
-				err := s.pk.Remove(address)
+				err = s.pk.Remove(address)
 				if err != nil {
 					serverLog.Critical("Failed to remove address from public"+
 						" key request store: ", err)
@@ -560,6 +665,32 @@ func (s *server) savePeriodically() {
 	}
 }
 
+// scheduleHandler periodically sends the messages which are scheduled to be
+// sent. Messages which came due while bmclient was not running are sent as
+// soon as it starts.
+func (s *server) scheduleHandler() {
+	defer s.wg.Done()
+
+	send := func() {
+		for _, u := range s.imapUser {
+			if err := u.SendScheduled(time.Now()); err != nil {
+				serverLog.Error("Failed to send scheduled messages: ", err)
+			}
+		}
+	}
+	send()
+
+	t := time.NewTicker(scheduleInterval)
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-t.C:
+			send()
+		}
+	}
+}
+
 // saveData saves any data in memory to disk. This includes writing the keyfile
 // and counter values to the store.
 func (s *server) saveData() {
@@ -567,6 +698,12 @@ func (s *server) saveData() {
 		user.SaveKeyfile()
 	}
 
+	for _, u := range s.imapUser {
+		if err := u.Close(); err != nil {
+			serverLog.Error("Failed to save mailbox statistics: ", err)
+		}
+	}
+
 	// Save counter values to store.
 	err := s.store.SetCounter(wire.ObjectTypeMsg,
 		atomic.LoadUint64(&s.msgCounter))
@@ -614,7 +751,7 @@ func (s *server) getOrRequestPublicIdentity(user uint32, address string) (identi
 		uint64(msg.Header().Expiration().Sub(time.Now()).Seconds()),
 		pow.Default)
 
-	s.pow.Run(target, b, func(nonce pow.Nonce) {
+	s.pow.Run(target, b, powmgr.TypeGetPubKey, powmgr.TypeGetPubKey.Priority(), func(nonce pow.Nonce) {
 		err := s.Send(append(nonce.Bytes(), b...))
 		if err != nil {
 			serverLog.Error("Could not run pow: ", err)
@@ -624,7 +761,7 @@ func (s *server) getOrRequestPublicIdentity(user uint32, address string) (identi
 		count, _ := s.pk.New(address)
 		serverLog.Tracef("getOrRequestPublicIdentity: Requested address %s %d time(s).",
 			address, count)
-	})
+	}, nil)
 
 	return nil, nil
 }
@@ -663,3 +800,32 @@ func (s *server) WaitForShutdown() {
 	s.wg.Wait()
 	s.bmd.WaitForShutdown()
 }
+
+// serverTLSConfig loads the certificate and key which the servers use for
+// TLS. They are generated if they do not exist yet.
+func serverTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if !fileExists(certFile) && !fileExists(keyFile) {
+		serverLog.Infof("Generating TLS certificate %s.", certFile)
+		validUntil := time.Now().Add(10 * 365 * 24 * time.Hour)
+		cert, key, err := btcutil.NewTLSCertPair("bmagent autogenerated cert", validUntil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(certFile, cert, 0666); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(keyFile, key, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
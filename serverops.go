@@ -9,15 +9,24 @@ import (
 	"time"
 
 	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/store"
+	"github.com/DanielKrawisz/bmagent/store/data"
 	"github.com/DanielKrawisz/bmagent/user"
 	"github.com/DanielKrawisz/bmagent/user/email"
 	"github.com/DanielKrawisz/bmutil/identity"
 	"github.com/DanielKrawisz/bmutil/wire"
 )
 
+// cachedPublicID is a public identity in the cache of serverOps along with
+// the time at which a new pubkey must be requested.
+type cachedPublicID struct {
+	identity.Public
+	expires time.Time
+}
+
 // serverOps implements the email.ServerOps interface.
 type serverOps struct {
-	pubIDs map[string]identity.Public // a cache
+	pubIDs map[string]cachedPublicID // a cache
 	id     uint32
 	user   *User
 	server *server
@@ -39,10 +48,11 @@ func (s *serverOps) GetOrRequestPublicID(emailAddress string) (identity.Public,
 	serverLog.Debug("GetOrRequestPublicID for ", addr)
 
 	// Check the map of cached identities.
-	identity, ok := s.pubIDs[addr]
-	if ok {
+	now := time.Now()
+	cached, ok := s.pubIDs[addr]
+	if ok && now.Before(cached.expires) {
 		serverLog.Debug("GetOrRequestPublicID: identity found ", addr)
-		return identity, nil
+		return cached.Public, nil
 	}
 
 	// Check the private identities, just in case.
@@ -51,7 +61,19 @@ func (s *serverOps) GetOrRequestPublicID(emailAddress string) (identity.Public,
 		return private.Public(), nil
 	}
 
-	pubID, err := s.server.getOrRequestPublicIdentity(s.id, addr)
+	// Check the public keys that we have saved. One which has expired may
+	// have been replaced, so a new one is requested instead.
+	pubID, expires, err := s.server.store.PubKeys().Get(addr)
+	if err == nil && now.Before(expires) {
+		s.pubIDs[addr] = cachedPublicID{pubID, expires}
+		return pubID, nil
+	} else if err == nil {
+		serverLog.Debug("GetOrRequestPublicID: public key has expired ", addr)
+	} else if err != data.ErrNotFound {
+		serverLog.Error("Failed to read public key from store: ", err)
+	}
+
+	pubID, err = s.server.getOrRequestPublicIdentity(s.id, addr)
 	if err != nil { // Some error occured.
 		return nil, err
 	}
@@ -59,7 +81,12 @@ func (s *serverOps) GetOrRequestPublicID(emailAddress string) (identity.Public,
 		return nil, email.ErrGetPubKeySent
 	}
 
-	s.pubIDs[addr] = pubID
+	err = s.server.store.PubKeys().Put(pubID)
+	if err != nil {
+		serverLog.Error("Failed to store public key: ", err)
+	}
+
+	s.pubIDs[addr] = cachedPublicID{pubID, time.Now().Add(store.PubKeyLifetime)}
 	return pubID, nil
 }
 
@@ -89,3 +116,18 @@ func ObjectExpiration(objType wire.ObjectType) time.Duration {
 func (s *serverOps) Send(obj []byte) { // Send the object out on the network.
 	s.server.Send(obj)
 }
+
+// Publish sends out the pubkeys which are due to be published.
+func (s *serverOps) Publish() {
+	s.server.Publish()
+}
+
+// Subscribe adds an address to the broadcasts that the user listens to.
+func (s *serverOps) Subscribe(address string) error {
+	userData, err := s.server.store.GetUser(s.user.Username)
+	if err != nil {
+		return err
+	}
+
+	return userData.BroadcastAddresses.Add(address)
+}
@@ -7,6 +7,7 @@ package store
 
 import (
 	"bytes"
+	"sync"
 
 	"github.com/DanielKrawisz/bmagent/store/data"
 	"github.com/DanielKrawisz/bmutil"
@@ -17,6 +18,7 @@ import (
 // to. It provides functionality for adding, removal and running a function for
 // each address.
 type BroadcastAddresses struct {
+	mtx      sync.RWMutex
 	db       *bolt.DB
 	username []byte
 	addrs    []bmutil.Address // All broadcast addresses.
@@ -60,13 +62,23 @@ func newBroadcastsStore(db *bolt.DB, username string) (*BroadcastAddresses, erro
 	return b, nil
 }
 
-// Add adds a new address to the store.
+// Add adds a new address to the store. Nothing is done if the address is
+// already there.
 func (b *BroadcastAddresses) Add(address string) error {
 	addr, err := bmutil.DecodeAddress(address)
 	if err != nil {
 		return err
 	}
 
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for _, t := range b.addrs {
+		if bytes.Equal(t.RipeHash()[:], addr.RipeHash()[:]) {
+			return nil
+		}
+	}
+
 	k := []byte(address)
 	v := []byte{}
 
@@ -88,6 +100,9 @@ func (b *BroadcastAddresses) Remove(address string) error {
 		return err
 	}
 
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
 	k := []byte(address)
 
 	err = b.db.Update(func(tx *bolt.Tx) error {
@@ -114,6 +129,9 @@ func (b *BroadcastAddresses) Remove(address string) error {
 // ForEach runs the specified function for each broadcast address, breaking
 // early if an error occurs.
 func (b *BroadcastAddresses) ForEach(f func(address bmutil.Address) error) error {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
 	for _, addr := range b.addrs {
 		err := f(addr)
 		if err != nil {
@@ -75,4 +75,18 @@ func TestBroadcastAddresses(t *testing.T) {
 	if counter != 2 {
 		t.Errorf("For counter expected %d got %d", 2, counter)
 	}
+
+	// Adding an address a second time does nothing.
+	err = u.BroadcastAddresses.Add(addr1)
+	if err != nil {
+		t.Error(err)
+	}
+	counter = 0
+	u.BroadcastAddresses.ForEach(func(bmutil.Address) error {
+		counter++
+		return nil
+	})
+	if counter != 2 {
+		t.Errorf("For counter expected %d got %d", 2, counter)
+	}
 }
@@ -2,6 +2,7 @@ package data
 
 import (
 	"errors"
+	"sort"
 )
 
 var (
@@ -63,6 +64,19 @@ type Folder interface {
 	// LastID returns the highest index value in the mailbox, followed by a
 	// map containing the last indices for each suffix.
 	LastID() (uint64, map[uint64]uint64)
+
+	// IDs returns the ids of the messages in the folder with the given
+	// suffix in increasing order. If suffix is zero, the ids of all messages
+	// are returned. Unlike ForEachMessage, it does not read the messages.
+	IDs(suffix uint64) ([]uint64, error)
+
+	// Metadata returns the data which was last saved with SetMetadata, or
+	// nil if there is none.
+	Metadata() ([]byte, error)
+
+	// SetMetadata saves data which describes the folder as a whole. If md
+	// is nil, the metadata is removed.
+	SetMetadata(md []byte) error
 }
 
 // Folders represents a set of named folders.
@@ -74,6 +88,13 @@ type Folders interface {
 	Delete(string) error
 }
 
+// idList implements sort.Interface for a list of message ids.
+type idList []uint64
+
+func (l idList) Len() int           { return len(l) }
+func (l idList) Less(i, j int) bool { return l[i] < l[j] }
+func (l idList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
 type message struct {
 	payload []byte
 	suffix  uint64
@@ -86,15 +107,21 @@ type memFolder struct {
 	nextIndex         uint64
 	lastIndexBySuffix map[uint64]uint64
 	messages          map[uint64]message
+	metadata          []byte
+
+	name  string
+	index *memIndex
 }
 
 // newMemFolder returns an in-memory folder.
-func newMemFolder() *memFolder {
+func newMemFolder(name string, index *memIndex) *memFolder {
 	return &memFolder{
 		lastIndex:         0,
 		nextIndex:         1,
 		messages:          make(map[uint64]message),
 		lastIndexBySuffix: make(map[uint64]uint64),
+		name:              name,
+		index:             index,
 	}
 }
 
@@ -182,6 +209,7 @@ func (f *memFolder) DeleteMessage(id uint64) error {
 	suffix = m.suffix
 
 	delete(f.messages, id)
+	f.index.remove(f.name, id)
 
 	if f.lastIndexBySuffix[suffix] != id {
 		return nil
@@ -229,14 +257,68 @@ func (f *memFolder) ForEachMessage(lowID, highID, suffix uint64,
 	return nil
 }
 
+func (f *memFolder) InsertNewIndexedMessage(msg []byte, suffix uint64, terms Terms) (uint64, error) {
+	id, err := f.InsertNewMessage(msg, suffix)
+	if err != nil {
+		return 0, err
+	}
+
+	f.index.add(f.name, id, terms)
+	return id, nil
+}
+
+func (f *memFolder) InsertIndexedMessage(id uint64, msg []byte, suffix uint64, terms Terms) error {
+	err := f.InsertMessage(id, msg, suffix)
+	if err != nil {
+		return err
+	}
+
+	f.index.add(f.name, id, terms)
+	return nil
+}
+
+func (f *memFolder) IndexMessage(id uint64, terms Terms) error {
+	if _, ok := f.messages[id]; !ok {
+		return ErrNotFound
+	}
+
+	f.index.add(f.name, id, terms)
+	return nil
+}
+
+func (f *memFolder) IDs(suffix uint64) ([]uint64, error) {
+	ids := make([]uint64, 0, len(f.messages))
+	for id, m := range f.messages {
+		if suffix != 0 && m.suffix != suffix {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	sort.Sort(idList(ids))
+
+	return ids, nil
+}
+
+func (f *memFolder) Metadata() ([]byte, error) {
+	return f.metadata, nil
+}
+
+func (f *memFolder) SetMetadata(md []byte) error {
+	f.metadata = md
+	return nil
+}
+
 type memFolders struct {
 	folders map[string]*memFolder
+	index   *memIndex
 }
 
 // NewMemFolders returns an in-memory folders object.
 func NewMemFolders() Folders {
 	return &memFolders{
 		folders: make(map[string]*memFolder),
+		index:   newMemIndex(),
 	}
 }
 
@@ -247,7 +329,7 @@ func (mf *memFolders) New(name string) (Folder, error) {
 		return nil, ErrDuplicateID
 	}
 
-	f := newMemFolder()
+	f := newMemFolder(name, mf.index)
 
 	mf.folders[name] = f
 
@@ -271,7 +353,14 @@ func (mf *memFolders) Rename(name, newname string) error {
 		return ErrNotFound
 	}
 
+	if _, ok := mf.folders[newname]; ok {
+		return ErrDuplicateID
+	}
+
 	mf.folders[newname] = f
+	delete(mf.folders, name)
+	f.name = newname
+	mf.index.rename(name, newname)
 
 	return nil
 }
@@ -284,7 +373,17 @@ func (mf *memFolders) Delete(name string) error {
 	}
 
 	delete(mf.folders, name)
+	delete(mf.index.folders, name)
+
+	return nil
+}
+
+func (mf *memFolders) Search(terms []string, limit int) ([]Match, error) {
+	return mf.index.search(terms, limit), nil
+}
 
+func (mf *memFolders) ClearIndex() error {
+	mf.index.folders = make(map[string]map[uint64]Terms)
 	return nil
 }
 
@@ -0,0 +1,149 @@
+package data
+
+import (
+	"math"
+	"sort"
+)
+
+// Terms maps the terms of a message to the number of times that they
+// appear in it.
+type Terms map[string]uint32
+
+// Match is a message found by a full-text search.
+type Match struct {
+	Folder string
+	ID     uint64
+	Score  float64
+}
+
+// IndexedFolder is a Folder which keeps a full-text index of its messages.
+// The index is updated in the same transaction as the messages themselves,
+// and messages are removed from the index when they are deleted.
+type IndexedFolder interface {
+	Folder
+
+	// InsertNewIndexedMessage is like InsertNewMessage, but also adds the
+	// terms of the message to the index.
+	InsertNewIndexedMessage(msg []byte, suffix uint64, terms Terms) (uint64, error)
+
+	// InsertIndexedMessage is like InsertMessage, but also adds the terms
+	// of the message to the index.
+	InsertIndexedMessage(id uint64, msg []byte, suffix uint64, terms Terms) error
+
+	// IndexMessage replaces the terms of a message which is already in
+	// the folder.
+	IndexMessage(id uint64, terms Terms) error
+}
+
+// Index is a full-text index of the messages in a set of folders.
+type Index interface {
+	// Search returns the messages that contain any of the given terms,
+	// ordered from the best match to the worst. At most limit matches are
+	// returned, or all of them if limit is zero.
+	Search(terms []string, limit int) ([]Match, error)
+
+	// ClearIndex removes every message from the index.
+	ClearIndex() error
+}
+
+// TermScore returns the contribution of a term to the score of a message.
+// tf is the number of times that the term appears in the message, df is the
+// number of messages which contain the term and n is the number of messages
+// in the index.
+func TermScore(tf uint32, df, n int) float64 {
+	if tf == 0 || df == 0 {
+		return 0
+	}
+
+	return (1 + math.Log(float64(tf))) * math.Log(1+float64(n)/float64(df))
+}
+
+// matches implements sort.Interface to order matches from best to worst.
+type matches []Match
+
+func (m matches) Len() int { return len(m) }
+
+func (m matches) Less(i, j int) bool {
+	if m[i].Score != m[j].Score {
+		return m[i].Score > m[j].Score
+	}
+	if m[i].Folder != m[j].Folder {
+		return m[i].Folder < m[j].Folder
+	}
+	return m[i].ID < m[j].ID
+}
+
+func (m matches) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+
+// SortMatches orders matches from the best to the worst and returns at most
+// limit of them, or all of them if limit is zero.
+func SortMatches(m []Match, limit int) []Match {
+	sort.Sort(matches(m))
+
+	if limit > 0 && len(m) > limit {
+		m = m[:limit]
+	}
+	return m
+}
+
+// memIndex is a full-text index which exists in memory.
+type memIndex struct {
+	// The terms of each message in each folder.
+	folders map[string]map[uint64]Terms
+}
+
+func newMemIndex() *memIndex {
+	return &memIndex{
+		folders: make(map[string]map[uint64]Terms),
+	}
+}
+
+func (idx *memIndex) add(folder string, id uint64, terms Terms) {
+	f, ok := idx.folders[folder]
+	if !ok {
+		f = make(map[uint64]Terms)
+		idx.folders[folder] = f
+	}
+	f[id] = terms
+}
+
+func (idx *memIndex) remove(folder string, id uint64) {
+	delete(idx.folders[folder], id)
+}
+
+func (idx *memIndex) rename(folder, newname string) {
+	if f, ok := idx.folders[folder]; ok {
+		idx.folders[newname] = f
+		delete(idx.folders, folder)
+	}
+}
+
+func (idx *memIndex) search(terms []string, limit int) []Match {
+	var n int
+	df := make(map[string]int)
+	for _, f := range idx.folders {
+		n += len(f)
+		for _, t := range f {
+			for _, term := range terms {
+				if t[term] > 0 {
+					df[term]++
+				}
+			}
+		}
+	}
+
+	var m []Match
+	for name, f := range idx.folders {
+		for id, t := range f {
+			var score float64
+			for _, term := range terms {
+				score += TermScore(t[term], df[term], n)
+			}
+			if score > 0 {
+				m = append(m, Match{Folder: name, ID: id, Score: score})
+			}
+		}
+	}
+
+	return SortMatches(m, limit)
+}
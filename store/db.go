@@ -45,6 +45,8 @@ const (
 var (
 	powQueueBucket           = []byte("powQueue")
 	pkRequestsBucket         = []byte("pubkeyRequests")
+	pubKeysBucket            = []byte("pubkeys")
+	publishedBucket          = []byte("published")
 	miscBucket               = []byte("misc")
 	countersBucket           = []byte("counters")
 	broadcastAddressesBucket = []byte("broadcastAddresses")
@@ -85,6 +87,10 @@ var (
 	// ErrDuplicateMailbox is returned by NewMailbox when a mailbox with the
 	// given name already exists.
 	ErrDuplicateMailbox = errors.New("duplicate mailbox")
+
+	// ErrInvalidPublicID is returned by PubKeys.Put when the given public
+	// identity is missing its keys or proof-of-work data.
+	ErrInvalidPublicID = errors.New("invalid public identity")
 )
 
 // Loader transforms underlying database into Store.
@@ -123,6 +129,19 @@ type Store struct {
 	db        *bolt.DB
 	mutex     sync.RWMutex // For protecting the map.
 	users     map[string]*User
+	pubkeys   *PubKeys
+	published *Published
+}
+
+// PubKeys returns the store of public identities known to bmagent.
+func (s *Store) PubKeys() *PubKeys {
+	return s.pubkeys
+}
+
+// Published returns the store of the times that the pubkeys of our own
+// identities were sent out.
+func (s *Store) Published() *Published {
+	return s.published
 }
 
 // Users returns the map of users in the Store.
@@ -279,6 +298,8 @@ func (l *Loader) Construct(pass []byte) (*Store, *PKRequests, error) {
 		db:        l.db,
 		users:     make(map[string]*User),
 		masterKey: &masterKey,
+		pubkeys:   &PubKeys{db: l.db},
+		published: &Published{db: l.db},
 	}
 
 	err = initializePKRequestStore(l.db)
@@ -287,6 +308,13 @@ func (l *Loader) Construct(pass []byte) (*Store, *PKRequests, error) {
 		return nil, nil, err
 	}
 
+	err = initializePubKeyStore(l.db)
+	if err != nil {
+		l.Close()
+		return nil, nil, err
+	}
+
+	err = initializePublishedStore(l.db)
 	if err != nil {
 		l.Close()
 		return nil, nil, err
@@ -377,6 +405,10 @@ func initializeFolders(db *bolt.DB, username string, bucketID []byte) ([]string,
 		if err != nil {
 			return err
 		}
+		err = initializeIndex(userBucket)
+		if err != nil {
+			return err
+		}
 
 		if newUser { // This is a new user.
 			// Set ID for messages to 0.
@@ -21,6 +21,9 @@ import (
 var (
 	// folderCreatedOnKey contains the time of creation of mailbox.
 	folderCreatedOnKey = []byte("createdOn")
+
+	// folderMetadataKey contains the encrypted metadata of the mailbox.
+	folderMetadataKey = []byte("metadata")
 )
 
 type folders struct {
@@ -129,6 +132,11 @@ func (f *folders) Delete(name string) error {
 			return err
 		}
 
+		err = newIndex(f.user.masterKey, f.user.db, tx, f.user.bucketID).removeFolder(name)
+		if err != nil {
+			return err
+		}
+
 		// Delete mailbox from store.
 		delete(f.folders, name)
 
@@ -138,53 +146,27 @@ func (f *folders) Delete(name string) error {
 
 // Rename renames a folder.
 func (f *folders) Rename(name, newname string) error {
-	return f.user.db.Update(func(tx *bolt.Tx) error {
-		// Delete the mailbox and associated data.
-		bucket := tx.Bucket(f.user.bucketID)
-		if bucket == nil {
-			return data.ErrNotFound
-		}
-
-		f.mutex.Lock()
-		defer f.mutex.Unlock()
-
-		folders := bucket.Bucket(foldersBucket)
-
-		oldBucket := folders.Bucket([]byte(name))
-		if oldBucket == nil {
-			return data.ErrNotFound
-		}
-
-		if bucket.Bucket([]byte(newname)) != nil {
-			return data.ErrDuplicateID
-		}
+	flr, err := f.Get(name)
+	if err != nil {
+		return err
+	}
 
-		// create the new bucket.
-		_, err := newFolder(f.user.masterKey, f.user.db, f.user.username, newname)
-		if err != nil {
-			return err
-		}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 
-		// copy all items from oldBucket into newBucket
-		newBucket := bucket.Bucket([]byte(newname))
-		err = oldBucket.ForEach(func(k, v []byte) error {
-			newBucket.Put(k, v)
-			return nil
-		})
-		if err != nil {
-			return err
-		}
+	if _, ok := f.folders[newname]; ok {
+		return ErrDuplicateMailbox
+	}
 
-		err = bucket.Bucket(foldersBucket).DeleteBucket([]byte(name))
-		if err != nil {
-			return err
-		}
+	err = flr.(*folder).setName(newname)
+	if err != nil {
+		return err
+	}
 
-		// Delete mailbox from store.
-		delete(f.folders, name)
+	delete(f.folders, name)
+	f.folders[newname] = flr
 
-		return nil
-	})
+	return nil
 }
 
 // folder is a folder of messages corresponding to a private identity or
@@ -337,6 +319,21 @@ func (f *folder) LastID() (uint64, map[uint64]uint64) {
 // InsertNewMessage inserts a new message with the specified suffix into a
 // new position in the database and returns the new id.
 func (f *folder) InsertNewMessage(msg []byte, suffix uint64) (uint64, error) {
+	return f.insertNewMessage(msg, suffix, nil)
+}
+
+// InsertNewIndexedMessage is like InsertNewMessage, but also adds the terms
+// of the message to the full-text index in the same transaction.
+func (f *folder) InsertNewIndexedMessage(msg []byte, suffix uint64, terms data.Terms) (uint64, error) {
+	if terms == nil {
+		terms = data.Terms{}
+	}
+	return f.insertNewMessage(msg, suffix, terms)
+}
+
+// insertNewMessage inserts a new message and adds it to the full-text index
+// if terms is not nil.
+func (f *folder) insertNewMessage(msg []byte, suffix uint64, terms data.Terms) (uint64, error) {
 	if msg == nil {
 		return 0, errors.New("Nil message inserted.")
 	}
@@ -374,7 +371,12 @@ func (f *folder) InsertNewMessage(msg []byte, suffix uint64) (uint64, error) {
 			return data.ErrDuplicateID
 		}
 
-		return bf.Put(k, enc)
+		err = bf.Put(k, enc)
+		if err != nil || terms == nil {
+			return err
+		}
+
+		return newIndex(f.masterKey, f.db, tx, f.userID).add(f.name, f.nextID, terms)
 	})
 	if err != nil {
 		return 0, err
@@ -390,6 +392,21 @@ func (f *folder) InsertNewMessage(msg []byte, suffix uint64) (uint64, error) {
 // InsertMessage inserts a new message with the specified suffix and id into the
 // folder and returns the ID.
 func (f *folder) InsertMessage(id uint64, msg []byte, suffix uint64) error {
+	return f.insertMessage(id, msg, suffix, nil)
+}
+
+// InsertIndexedMessage is like InsertMessage, but also adds the terms of the
+// message to the full-text index in the same transaction.
+func (f *folder) InsertIndexedMessage(id uint64, msg []byte, suffix uint64, terms data.Terms) error {
+	if terms == nil {
+		terms = data.Terms{}
+	}
+	return f.insertMessage(id, msg, suffix, terms)
+}
+
+// insertMessage inserts a message with the given id and adds it to the
+// full-text index if terms is not nil.
+func (f *folder) insertMessage(id uint64, msg []byte, suffix uint64, terms data.Terms) error {
 	if id == 0 || id >= f.nextID {
 		return data.ErrInvalidID
 	}
@@ -422,7 +439,12 @@ func (f *folder) InsertMessage(id uint64, msg []byte, suffix uint64) error {
 			return data.ErrDuplicateID
 		}
 
-		return m.Put(k, enc)
+		err = m.Put(k, enc)
+		if err != nil || terms == nil {
+			return err
+		}
+
+		return newIndex(f.masterKey, f.db, tx, f.userID).add(f.name, id, terms)
 	})
 	if err != nil {
 		return err
@@ -571,7 +593,7 @@ func (f *folder) DeleteMessage(id uint64) error {
 			return err
 		}
 
-		return nil
+		return newIndex(f.masterKey, f.db, tx, f.userID).remove(f.name, id)
 	})
 
 	if err != nil {
@@ -581,6 +603,120 @@ func (f *folder) DeleteMessage(id uint64) error {
 	return nil
 }
 
+// IndexMessage replaces the terms of a message which is already in the
+// folder in the full-text index.
+func (f *folder) IndexMessage(id uint64, terms data.Terms) error {
+	idBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(idBytes, id)
+
+	return f.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(f.userID)
+		if bucket == nil {
+			return data.ErrNotFound
+		}
+
+		k, v := bucket.Bucket(foldersBucket).Bucket([]byte(f.name)).Cursor().Seek(idBytes)
+		if k == nil || v == nil || !bytes.Equal(k[:8], idBytes) {
+			return data.ErrNotFound
+		}
+
+		return newIndex(f.masterKey, f.db, tx, f.userID).add(f.name, id, terms)
+	})
+}
+
+// IDs returns the ids of the messages in the folder with the given suffix in
+// increasing order. If suffix is zero, the ids of all messages are returned.
+// Unlike ForEachMessage, it does not read the messages.
+func (f *folder) IDs(suffix uint64) ([]uint64, error) {
+	var ids []uint64
+
+	err := f.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(f.userID)
+		if bucket == nil {
+			return data.ErrNotFound
+		}
+		cursor := bucket.Bucket(foldersBucket).Bucket([]byte(f.name)).Cursor()
+
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			// Skip the data bucket.
+			if v == nil {
+				continue
+			}
+
+			if suffix != 0 && binary.BigEndian.Uint64(k[8:]) != suffix {
+				continue
+			}
+
+			ids = append(ids, binary.BigEndian.Uint64(k[:8]))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Metadata returns the data which was last saved with SetMetadata, or nil if
+// there is none.
+func (f *folder) Metadata() ([]byte, error) {
+	var md []byte
+
+	err := f.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(f.userID)
+		if bucket == nil {
+			return data.ErrNotFound
+		}
+		v := bucket.Bucket(foldersBucket).Bucket([]byte(f.name)).
+			Bucket(folderDataBucket).Get(folderMetadataKey)
+		if v == nil {
+			return nil
+		}
+
+		var success bool
+		md, success = decrypt(f.masterKey, f.db, v)
+		if !success {
+			return ErrDecryptionFailed
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+// SetMetadata saves data which describes the folder as a whole. If md is
+// nil, the metadata is removed.
+func (f *folder) SetMetadata(md []byte) error {
+	var enc []byte
+	if md != nil {
+		var err error
+		enc, err = encrypt(f.masterKey, f.db, md)
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(f.userID)
+		if bucket == nil {
+			return data.ErrNotFound
+		}
+		bucket = bucket.Bucket(foldersBucket).Bucket([]byte(f.name)).
+			Bucket(folderDataBucket)
+
+		if enc == nil {
+			return bucket.Delete(folderMetadataKey)
+		}
+		return bucket.Put(folderMetadataKey, enc)
+	})
+}
+
 // setName changes the name of the folders.
 func (f *folder) setName(name string) error {
 	if name == "" {
@@ -622,6 +758,12 @@ func (f *folder) setName(name string) error {
 			return b.Put(k, v)
 		})
 
+		// Move the messages in the full-text index.
+		err = newIndex(f.masterKey, f.db, tx, f.userID).renameFolder(f.name, name)
+		if err != nil {
+			return err
+		}
+
 		// Delete old mailbox.
 		return tx.Bucket(f.userID).Bucket(foldersBucket).DeleteBucket([]byte(f.name))
 	})
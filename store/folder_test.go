@@ -314,3 +314,131 @@ func testLastIDBySuffix(
 			tc.Context(), note, suffix, expectedID, id)
 	}
 }
+
+func TestRenameFolder(t *testing.T) {
+	f, err := NewUser(t).Folders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mbox, err := f.New("Old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.New("Other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testInsertMessage(mbox, []byte("a message"), 1, 1, t)
+
+	// Renaming to an existing name should fail.
+	err = f.Rename("Old", "Other")
+	if err != store.ErrDuplicateMailbox {
+		t.Error("Expected ErrDuplicateMailbox got", err)
+	}
+
+	err = f.Rename("Old", "New/Sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.Get("Old")
+	if err != data.ErrNotFound {
+		t.Error("Expected data.ErrNotFound got", err)
+	}
+
+	renamed, err := f.Get("New/Sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The message should have been moved with the folder.
+	_, msg, err := renamed.GetMessage(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "a message" {
+		t.Errorf("Expected %s got %s", "a message", string(msg))
+	}
+
+	// New messages should continue with the same ids.
+	testInsertMessage(renamed, []byte("another message"), 1, 2, t)
+}
+
+func TestFolderMetadata(t *testing.T) {
+	f, err := NewUser(t).Folders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mbox, err := f.New("Metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md, err := mbox.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md != nil {
+		t.Errorf("Expected no metadata, got %v", md)
+	}
+
+	err = mbox.SetMetadata([]byte("some metadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testInsertMessage(mbox, []byte("a message"), 1, 1, t)
+	testInsertMessage(mbox, []byte("another message"), 2, 2, t)
+	testInsertMessage(mbox, []byte("a third message"), 1, 3, t)
+	err = mbox.DeleteMessage(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The metadata must not be mistaken for a message.
+	ids, err := mbox.IDs(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("Expected ids [1 3], got %v", ids)
+	}
+
+	ids, err = mbox.IDs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("Expected 2 ids with suffix 1, got %v", ids)
+	}
+	ids, err = mbox.IDs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("Expected no ids with suffix 2, got %v", ids)
+	}
+
+	md, err = mbox.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(md) != "some metadata" {
+		t.Errorf("Expected %s got %s", "some metadata", string(md))
+	}
+
+	err = mbox.SetMetadata(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err = mbox.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md != nil {
+		t.Errorf("Expected metadata to be removed, got %v", md)
+	}
+}
@@ -0,0 +1,385 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/boltdb/bolt"
+)
+
+// The full-text index of a user's folders is kept in the index bucket of
+// the user. The terms are never stored in the clear. They are replaced by an
+// HMAC with a key derived from the master key so that the postings of a term
+// can be found without revealing what the term is, and everything else is
+// encrypted like the contents of messages.
+var (
+	indexBucket = []byte("index")
+
+	// indexKeyLabel is the message whose HMAC with the master key is the
+	// key with which terms are hashed, so that the master key itself is
+	// only ever used for encryption.
+	indexKeyLabel = []byte("bmagent-index")
+
+	// indexTermsBucket has a key for every term in every message, which is
+	// the HMAC of the term followed by the document number of the message.
+	// The value is the encrypted number of times that the term appears.
+	indexTermsBucket = []byte("terms")
+
+	// indexDocsBucket maps document numbers to an encrypted indexDoc.
+	indexDocsBucket = []byte("docs")
+
+	// indexIDsBucket maps a folder name, a zero byte and a message id to
+	// the document number of the message.
+	indexIDsBucket = []byte("ids")
+
+	// indexNextDocKey contains the next document number to be assigned.
+	indexNextDocKey = []byte("nextDoc")
+
+	// indexDocCountKey contains the number of messages in the index.
+	indexDocCountKey = []byte("docCount")
+)
+
+// indexDoc is the information stored about each message in the index.
+type indexDoc struct {
+	Folder string
+	ID     uint64
+	Terms  data.Terms
+}
+
+// deriveIndexKey returns the key with which the terms in the index are
+// hashed.
+func deriveIndexKey(masterKey *[keySize]byte) []byte {
+	mac := hmac.New(sha256.New, masterKey[:])
+	mac.Write(indexKeyLabel)
+	return mac.Sum(nil)
+}
+
+// termKey returns the key under which the postings of a term are stored.
+func termKey(indexKey []byte, term string) []byte {
+	mac := hmac.New(sha256.New, indexKey)
+	mac.Write([]byte(term))
+	return mac.Sum(nil)
+}
+
+// idKey returns the key which identifies a message in the index.
+func idKey(folder string, id uint64) []byte {
+	k := make([]byte, len(folder)+9)
+	copy(k, folder)
+	binary.BigEndian.PutUint64(k[len(folder)+1:], id)
+	return k
+}
+
+// initializeIndex creates the buckets of the index in the given user bucket
+// if they don't exist.
+func initializeIndex(userBucket *bolt.Bucket) error {
+	bucket, err := userBucket.CreateBucketIfNotExists(indexBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range [][]byte{indexTermsBucket, indexDocsBucket, indexIDsBucket} {
+		_, err = bucket.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// index provides the operations on the full-text index of a user within a
+// single transaction.
+type index struct {
+	masterKey *[keySize]byte
+	indexKey  []byte
+	db        *bolt.DB
+	bucket    *bolt.Bucket
+}
+
+func newIndex(masterKey *[keySize]byte, db *bolt.DB, tx *bolt.Tx, userID []byte) *index {
+	return &index{
+		masterKey: masterKey,
+		indexKey:  deriveIndexKey(masterKey),
+		db:        db,
+		bucket:    tx.Bucket(userID).Bucket(indexBucket),
+	}
+}
+
+func (idx *index) counter(key []byte) uint64 {
+	v := idx.bucket.Get(key)
+	if v == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
+
+func (idx *index) setCounter(key []byte, n uint64) error {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, n)
+	return idx.bucket.Put(key, v)
+}
+
+// getDoc reads and decrypts the document with the given number.
+func (idx *index) getDoc(docNum []byte) (*indexDoc, error) {
+	v := idx.bucket.Bucket(indexDocsBucket).Get(docNum)
+	if v == nil {
+		return nil, data.ErrNotFound
+	}
+
+	b, success := decrypt(idx.masterKey, idx.db, v)
+	if !success {
+		return nil, ErrDecryptionFailed
+	}
+
+	doc := &indexDoc{}
+	err := json.Unmarshal(b, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// putDoc encrypts and saves a document.
+func (idx *index) putDoc(docNum []byte, doc *indexDoc) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	enc, err := encrypt(idx.masterKey, idx.db, b)
+	if err != nil {
+		return err
+	}
+
+	return idx.bucket.Bucket(indexDocsBucket).Put(docNum, enc)
+}
+
+// add adds a message to the index, replacing it if it is already there.
+func (idx *index) add(folder string, id uint64, terms data.Terms) error {
+	err := idx.remove(folder, id)
+	if err != nil {
+		return err
+	}
+
+	n := idx.counter(indexNextDocKey)
+	docNum := make([]byte, 8)
+	binary.BigEndian.PutUint64(docNum, n)
+
+	err = idx.putDoc(docNum, &indexDoc{
+		Folder: folder,
+		ID:     id,
+		Terms:  terms,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = idx.bucket.Bucket(indexIDsBucket).Put(idKey(folder, id), docNum)
+	if err != nil {
+		return err
+	}
+
+	postings := idx.bucket.Bucket(indexTermsBucket)
+	for term, tf := range terms {
+		v := make([]byte, binary.MaxVarintLen32)
+		v = v[:binary.PutUvarint(v, uint64(tf))]
+
+		enc, err := encrypt(idx.masterKey, idx.db, v)
+		if err != nil {
+			return err
+		}
+
+		err = postings.Put(append(termKey(idx.indexKey, term), docNum...), enc)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = idx.setCounter(indexNextDocKey, n+1)
+	if err != nil {
+		return err
+	}
+	return idx.setCounter(indexDocCountKey, idx.counter(indexDocCountKey)+1)
+}
+
+// remove removes a message from the index. Nothing is done if the message is
+// not in the index.
+func (idx *index) remove(folder string, id uint64) error {
+	ids := idx.bucket.Bucket(indexIDsBucket)
+	k := idKey(folder, id)
+	docNum := ids.Get(k)
+	if docNum == nil {
+		return nil
+	}
+	docNum = append([]byte{}, docNum...)
+
+	doc, err := idx.getDoc(docNum)
+	if err != nil {
+		return err
+	}
+
+	postings := idx.bucket.Bucket(indexTermsBucket)
+	for term := range doc.Terms {
+		err = postings.Delete(append(termKey(idx.indexKey, term), docNum...))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = idx.bucket.Bucket(indexDocsBucket).Delete(docNum)
+	if err != nil {
+		return err
+	}
+	err = ids.Delete(k)
+	if err != nil {
+		return err
+	}
+
+	count := idx.counter(indexDocCountKey)
+	if count > 0 {
+		count--
+	}
+	return idx.setCounter(indexDocCountKey, count)
+}
+
+// folderIDs returns the ids of all messages in the given folder which are in
+// the index.
+func (idx *index) folderIDs(folder string) []uint64 {
+	prefix := append([]byte(folder), 0)
+
+	var ids []uint64
+	cursor := idx.bucket.Bucket(indexIDsBucket).Cursor()
+	for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+		if len(k) != len(prefix)+8 {
+			continue
+		}
+		ids = append(ids, binary.BigEndian.Uint64(k[len(prefix):]))
+	}
+
+	return ids
+}
+
+// removeFolder removes every message in a folder from the index.
+func (idx *index) removeFolder(folder string) error {
+	for _, id := range idx.folderIDs(folder) {
+		if err := idx.remove(folder, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameFolder changes the folder of every message in the index which is
+// in the folder called name.
+func (idx *index) renameFolder(name, newname string) error {
+	ids := idx.bucket.Bucket(indexIDsBucket)
+	for _, id := range idx.folderIDs(name) {
+		k := idKey(name, id)
+		docNum := append([]byte{}, ids.Get(k)...)
+
+		doc, err := idx.getDoc(docNum)
+		if err != nil {
+			return err
+		}
+		doc.Folder = newname
+		err = idx.putDoc(docNum, doc)
+		if err != nil {
+			return err
+		}
+
+		err = ids.Delete(k)
+		if err != nil {
+			return err
+		}
+		err = ids.Put(idKey(newname, id), docNum)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// search finds the messages containing any of the given terms and ranks
+// them.
+func (idx *index) search(terms []string, limit int) ([]data.Match, error) {
+	n := int(idx.counter(indexDocCountKey))
+	scores := make(map[string]float64)
+
+	postings := idx.bucket.Bucket(indexTermsBucket)
+	for _, term := range terms {
+		prefix := termKey(idx.indexKey, term)
+
+		tfs := make(map[string]uint32)
+		cursor := postings.Cursor()
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			b, success := decrypt(idx.masterKey, idx.db, v)
+			if !success {
+				return nil, ErrDecryptionFailed
+			}
+			tf, _ := binary.Uvarint(b)
+			tfs[string(k[len(prefix):])] = uint32(tf)
+		}
+
+		for docNum, tf := range tfs {
+			scores[docNum] += data.TermScore(tf, len(tfs), n)
+		}
+	}
+
+	matches := make([]data.Match, 0, len(scores))
+	for docNum, score := range scores {
+		doc, err := idx.getDoc([]byte(docNum))
+		if err != nil {
+			return nil, err
+		}
+
+		matches = append(matches, data.Match{
+			Folder: doc.Folder,
+			ID:     doc.ID,
+			Score:  score,
+		})
+	}
+
+	return data.SortMatches(matches, limit), nil
+}
+
+// Search returns the messages in any folder that contain any of the given
+// terms, ordered from the best match to the worst. At most limit matches are
+// returned, or all of them if limit is zero.
+func (f *folders) Search(terms []string, limit int) ([]data.Match, error) {
+	var matches []data.Match
+	err := f.user.db.View(func(tx *bolt.Tx) error {
+		var err error
+		matches, err = newIndex(f.user.masterKey, f.user.db, tx, f.user.bucketID).
+			search(terms, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// ClearIndex removes every message from the full-text index.
+func (f *folders) ClearIndex() error {
+	return f.user.db.Update(func(tx *bolt.Tx) error {
+		userBucket := tx.Bucket(f.user.bucketID)
+		err := userBucket.DeleteBucket(indexBucket)
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+
+		return initializeIndex(userBucket)
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+)
+
+func TestIndex(t *testing.T) {
+	folders, err := NewUser(t).Folders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testIndex(t, folders)
+	testIndex(t, data.NewMemFolders())
+}
+
+func testIndex(t *testing.T, folders data.Folders) {
+	index, ok := folders.(data.Index)
+	if !ok {
+		t.Fatal("Folders do not implement data.Index")
+	}
+
+	newFolder := func(name string) data.IndexedFolder {
+		f, err := folders.New(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		indexed, ok := f.(data.IndexedFolder)
+		if !ok {
+			t.Fatal("Folder does not implement data.IndexedFolder")
+		}
+		return indexed
+	}
+
+	inbox := newFolder("Inbox")
+	sent := newFolder("Sent")
+
+	insert := func(f data.IndexedFolder, terms data.Terms) uint64 {
+		id, err := f.InsertNewIndexedMessage([]byte("message"), 2, terms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	insert(inbox, data.Terms{"pizza": 3, "lunch": 1})
+	insert(inbox, data.Terms{"pizza": 1, "recipe": 1})
+	insert(sent, data.Terms{"meeting": 1, "lunch": 1})
+
+	// Messages inserted without terms are not in the index.
+	_, err := inbox.InsertNewMessage([]byte("message"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(expected []data.Match, terms ...string) {
+		matches, err := index.Search(terms, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) != len(expected) {
+			t.Errorf("Search %v: expected %d matches, got %v", terms, len(expected), matches)
+			return
+		}
+		for i, m := range matches {
+			if m.Folder != expected[i].Folder || m.ID != expected[i].ID {
+				t.Errorf("Search %v: expected match %d to be %s #%d, got %s #%d",
+					terms, i, expected[i].Folder, expected[i].ID, m.Folder, m.ID)
+			}
+		}
+	}
+
+	search([]data.Match{{Folder: "Inbox", ID: 1}, {Folder: "Inbox", ID: 2}}, "pizza")
+	search([]data.Match{{Folder: "Inbox", ID: 1}, {Folder: "Sent", ID: 1}}, "lunch")
+	search([]data.Match{{Folder: "Inbox", ID: 1}, {Folder: "Inbox", ID: 2}, {Folder: "Sent", ID: 1}},
+		"pizza", "lunch")
+	search(nil, "nothing")
+
+	// Limit the number of results.
+	matches, err := index.Search([]string{"pizza", "lunch"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Folder != "Inbox" || matches[0].ID != 1 {
+		t.Errorf("Expected only Inbox #1, got %v", matches)
+	}
+
+	// Deleted messages are removed from the index.
+	err = inbox.DeleteMessage(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	search([]data.Match{{Folder: "Inbox", ID: 2}}, "pizza")
+
+	// Replace the terms of a message.
+	err = inbox.IndexMessage(2, data.Terms{"calzone": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	search(nil, "pizza")
+	search([]data.Match{{Folder: "Inbox", ID: 2}}, "calzone")
+
+	// Renamed folders keep their messages in the index.
+	err = folders.Rename("Sent", "Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	search([]data.Match{{Folder: "Archive", ID: 1}}, "meeting")
+
+	// Deleted folders are removed from the index.
+	err = folders.Delete("Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	search(nil, "meeting")
+
+	err = index.ClearIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	search(nil, "calzone")
+}
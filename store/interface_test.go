@@ -2,6 +2,7 @@ package store_test
 
 import (
 	"errors"
+	"sort"
 	"testing"
 
 	"github.com/DanielKrawisz/bmagent/store/data"
@@ -17,6 +18,7 @@ type testFolder struct {
 	name      string
 	nextIndex uint64
 	messages  map[uint64]message
+	metadata  []byte
 }
 
 func (f *testFolder) Name() string {
@@ -123,6 +125,34 @@ func (f *testFolder) ForEachMessage(lowID, highID, suffix uint64,
 	return nil
 }
 
+type ids []uint64
+
+func (l ids) Len() int           { return len(l) }
+func (l ids) Less(i, j int) bool { return l[i] < l[j] }
+func (l ids) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+func (f *testFolder) IDs(suffix uint64) ([]uint64, error) {
+	var list []uint64
+	for id, m := range f.messages {
+		if suffix != 0 && m.suffix != suffix {
+			continue
+		}
+		list = append(list, id)
+	}
+
+	sort.Sort(ids(list))
+	return list, nil
+}
+
+func (f *testFolder) Metadata() ([]byte, error) {
+	return f.metadata, nil
+}
+
+func (f *testFolder) SetMetadata(md []byte) error {
+	f.metadata = md
+	return nil
+}
+
 // testContext is used to store context information about a running test which
 // is passed into helper functions.
 // In the future, this could be expanded to encompass cases relevant to
@@ -0,0 +1,141 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmutil"
+	"github.com/DanielKrawisz/bmutil/identity"
+	"github.com/DanielKrawisz/bmutil/pow"
+	"github.com/boltdb/bolt"
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// PubKeyLifetime is how long a public key is used after it is saved. This
+// is the longest that a pubkey object lives in the network, so by then its
+// owner will have published it again, perhaps with a different difficulty.
+const PubKeyLifetime = 28 * 24 * time.Hour
+
+// PubKeys is a store that keeps the public identities of other Bitmessage
+// users, such as the senders of messages that we have received. It allows
+// bmagent to send messages to these addresses without having to ask bmd or
+// the network for their public keys.
+type PubKeys struct {
+	db *bolt.DB
+}
+
+// storedPubKey is the representation of a public identity in the database.
+type storedPubKey struct {
+	SigningKey         []byte
+	EncryptionKey      []byte
+	Behavior           uint32
+	NonceTrialsPerByte uint64
+	ExtraBytes         uint64
+	Saved              time.Time
+	Expires            time.Time
+}
+
+// initializePubKeyStore initializes the database for public keys.
+func initializePubKeyStore(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(pubKeysBucket)
+		return err
+	})
+}
+
+// Put saves the public identity in the store, replacing any previous entry
+// for the same address. It expires after PubKeyLifetime.
+func (p *PubKeys) Put(id identity.Public) error {
+	key := id.Key()
+	pd := id.Pow()
+	if key == nil || pd == nil {
+		return ErrInvalidPublicID
+	}
+
+	now := time.Now()
+	v, err := json.Marshal(&storedPubKey{
+		SigningKey:         (*btcec.PublicKey)(key.Verification).SerializeUncompressed(),
+		EncryptionKey:      (*btcec.PublicKey)(key.Encryption).SerializeUncompressed(),
+		Behavior:           id.Behavior(),
+		NonceTrialsPerByte: pd.NonceTrialsPerByte,
+		ExtraBytes:         pd.ExtraBytes,
+		Saved:              now,
+		Expires:            now.Add(PubKeyLifetime),
+	})
+	if err != nil {
+		return err
+	}
+
+	addr := id.Address().String()
+	log.Debug("Saving public key for ", addr)
+
+	return p.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pubKeysBucket).Put([]byte(addr), v)
+	})
+}
+
+// Get returns the public identity corresponding to the given address and the
+// time at which it expires, after which a new pubkey should be requested. If
+// the address doesn't exist, an ErrNotFound is returned.
+func (p *PubKeys) Get(address string) (identity.Public, time.Time, error) {
+	addr, err := bmutil.DecodeAddress(address)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var stored storedPubKey
+	err = p.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(pubKeysBucket).Get([]byte(address))
+		if v == nil {
+			return data.ErrNotFound
+		}
+		return json.Unmarshal(v, &stored)
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	verKey, err := btcec.ParsePubKey(stored.SigningKey, btcec.S256())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	encKey, err := btcec.ParsePubKey(stored.EncryptionKey, btcec.S256())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// Keys saved before expiration times were recorded expire as though
+	// they had been.
+	expires := stored.Expires
+	if expires.IsZero() {
+		expires = stored.Saved.Add(PubKeyLifetime)
+	}
+
+	id, err := identity.NewPublic(
+		&identity.PublicKey{
+			Verification: (*identity.PubKey)(verKey),
+			Encryption:   (*identity.PubKey)(encKey),
+		},
+		addr.Version(), addr.Stream(), stored.Behavior,
+		&pow.Data{
+			stored.NonceTrialsPerByte,
+			stored.ExtraBytes,
+		})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return id, expires, nil
+}
+
+// Remove removes an address from the store.
+func (p *PubKeys) Remove(address string) error {
+	return p.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pubKeysBucket).Delete([]byte(address))
+	})
+}
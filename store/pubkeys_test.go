@@ -0,0 +1,103 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package store_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/store"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmutil"
+	"github.com/DanielKrawisz/bmutil/identity"
+	"github.com/DanielKrawisz/bmutil/pow"
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func TestPubKeys(t *testing.T) {
+	// Open store.
+	f, err := ioutil.TempFile("", "tempstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fName := f.Name()
+	f.Close()
+
+	l, err := store.Open(fName)
+	s, _, err := l.Construct([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := s.PubKeys()
+
+	addr := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+
+	// Check if a non-existing address returns correct error.
+	_, _, err = pk.Get(addr)
+	if err != data.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+
+	a, err := bmutil.DecodeAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verKey, _ := btcec.NewPrivateKey(btcec.S256())
+	encKey, _ := btcec.NewPrivateKey(btcec.S256())
+	id, err := identity.NewPublic(
+		&identity.PublicKey{
+			Verification: (*identity.PubKey)(verKey.PubKey()),
+			Encryption:   (*identity.PubKey)(encKey.PubKey()),
+		},
+		a.Version(), a.Stream(), identity.BehaviorAck,
+		&pow.Data{2000, 3000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	err = pk.Put(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, expires, err := pk.Get(id.Address().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expires.After(before.Add(27 * 24 * time.Hour)) {
+		t.Errorf("Expected the pubkey to expire in 28 days, got %v", expires)
+	}
+	if got.Address().String() != id.Address().String() {
+		t.Errorf("Wrong address: got %s, expected %s",
+			got.Address().String(), id.Address().String())
+	}
+	if got.Behavior() != identity.BehaviorAck {
+		t.Errorf("Wrong behavior: got %d", got.Behavior())
+	}
+	if *got.Pow() != *id.Pow() {
+		t.Errorf("Wrong pow data: got %v, expected %v", got.Pow(), id.Pow())
+	}
+
+	// Remove the identity and check that it's gone.
+	err = pk.Remove(id.Address().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pk.Get(id.Address().String())
+	if err != data.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+
+	// Close database.
+	err = s.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	os.Remove(fName)
+}
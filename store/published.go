@@ -0,0 +1,77 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/boltdb/bolt"
+)
+
+// Published is a store that keeps track of when the pubkeys of our own
+// identities were last sent out to the network, so that they can be sent
+// again before they expire.
+type Published struct {
+	db *bolt.DB
+}
+
+// Publication describes the last pubkey that was sent for an identity.
+type Publication struct {
+	// Sent is the time that the pubkey was sent.
+	Sent time.Time
+
+	// Expires is the time that the pubkey expires in the network.
+	Expires time.Time
+}
+
+// initializePublishedStore initializes the database for publications.
+func initializePublishedStore(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(publishedBucket)
+		return err
+	})
+}
+
+// Put records that a pubkey was sent for the given address, replacing any
+// earlier record.
+func (p *Published) Put(address string, pub *Publication) error {
+	v, err := json.Marshal(pub)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Recording publication of pubkey for ", address)
+
+	return p.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(publishedBucket).Put([]byte(address), v)
+	})
+}
+
+// Get returns the last publication of the pubkey for the given address. If
+// it was never sent, an ErrNotFound is returned.
+func (p *Published) Get(address string) (*Publication, error) {
+	var pub Publication
+	err := p.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(publishedBucket).Get([]byte(address))
+		if v == nil {
+			return data.ErrNotFound
+		}
+		return json.Unmarshal(v, &pub)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pub, nil
+}
+
+// Remove removes an address from the store.
+func (p *Published) Remove(address string) error {
+	return p.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(publishedBucket).Delete([]byte(address))
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package store_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/store"
+	"github.com/DanielKrawisz/bmagent/store/data"
+)
+
+func TestPublished(t *testing.T) {
+	// Open store.
+	f, err := ioutil.TempFile("", "tempstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fName := f.Name()
+	f.Close()
+	defer os.Remove(fName)
+
+	l, err := store.Open(fName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _, err := l.Construct([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := s.Published()
+
+	addr := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+
+	// An identity that was never published is not found.
+	_, err = published.Get(addr)
+	if err != data.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+
+	sent := time.Now().Round(time.Second)
+	pub := &store.Publication{
+		Sent:    sent,
+		Expires: sent.Add(14 * 24 * time.Hour),
+	}
+	if err := published.Put(addr, pub); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := published.Get(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Sent.Equal(pub.Sent) || !got.Expires.Equal(pub.Expires) {
+		t.Errorf("Expected %v, got %v", pub, got)
+	}
+
+	// A later publication replaces the earlier one.
+	pub.Expires = pub.Expires.Add(time.Hour)
+	if err := published.Put(addr, pub); err != nil {
+		t.Fatal(err)
+	}
+	got, err = published.Get(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Expires.Equal(pub.Expires) {
+		t.Errorf("Expected expiration %v, got %v", pub.Expires, got.Expires)
+	}
+
+	if err := published.Remove(addr); err != nil {
+		t.Fatal(err)
+	}
+	_, err = published.Get(addr)
+	if err != data.ErrNotFound {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,10 +1,12 @@
 package user
 
 import (
+	"encoding/binary"
 	"errors"
 	"time"
 
 	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmagent/user/metadata"
 	"github.com/DanielKrawisz/bmutil"
 	"github.com/DanielKrawisz/bmutil/format"
 	"github.com/DanielKrawisz/bmutil/format/serialize"
@@ -47,6 +49,79 @@ func (*broadcastID) String() string {
 // Broadcast represents a broadcast message, which doesn't require a public id.
 var Broadcast = identity.Public(&broadcastID{})
 
+// metadataField is the protobuf field number under which a metadata.Metadata
+// is appended to the encoding of a serialize.Message. It must not be used by
+// serialize.Message, which ignores it when decoding.
+const metadataField = 100
+
+// errInvalidProtobuf is returned when the encoding of a message cannot be
+// read.
+var errInvalidProtobuf = errors.New("Invalid protobuf encoding")
+
+// appendMetadata appends the metadata to a protobuf encoding of a message.
+func appendMetadata(data []byte, md *metadata.Metadata) ([]byte, error) {
+	b, err := proto.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := proto.NewBuffer(data)
+	buf.EncodeVarint(uint64(metadataField<<3 | proto.WireBytes))
+	buf.EncodeRawBytes(b)
+	return buf.Bytes(), nil
+}
+
+// readMetadata finds the metadata appended to a protobuf encoding of a
+// message. If there is none, nil is returned.
+func readMetadata(data []byte) (*metadata.Metadata, error) {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errInvalidProtobuf
+		}
+		data = data[n:]
+
+		switch key & 7 {
+		case proto.WireVarint:
+			_, n = binary.Uvarint(data)
+			if n <= 0 {
+				return nil, errInvalidProtobuf
+			}
+			data = data[n:]
+		case proto.WireFixed64:
+			if len(data) < 8 {
+				return nil, errInvalidProtobuf
+			}
+			data = data[8:]
+		case proto.WireFixed32:
+			if len(data) < 4 {
+				return nil, errInvalidProtobuf
+			}
+			data = data[4:]
+		case proto.WireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return nil, errInvalidProtobuf
+			}
+			data = data[n:]
+
+			if key>>3 == metadataField {
+				md := &metadata.Metadata{}
+				err := proto.Unmarshal(data[:l], md)
+				if err != nil {
+					return nil, err
+				}
+				return md, nil
+			}
+			data = data[l:]
+		default:
+			return nil, errInvalidProtobuf
+		}
+	}
+
+	return nil, nil
+}
+
 // decodeBitmessage takes the protobuf encoding of a Bitmessage and converts it
 // back to a Bitmessage.
 func decodeBitmessage(data []byte) (*email.Bmail, obj.Object, error) {
@@ -81,6 +156,13 @@ func decodeBitmessage(data []byte) (*email.Bmail, obj.Object, error) {
 		}
 		r.Body = string(msg.Encoding.Body)
 
+		q = r
+	case email.ExtendedEncoding:
+		r, err := email.DecodeExtended(msg.Encoding.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		q = r
 	default:
 		return nil, nil, errors.New("Unsupported encoding")
@@ -111,6 +193,34 @@ func decodeBitmessage(data []byte) (*email.Bmail, obj.Object, error) {
 		}
 	}
 
+	md, err := readMetadata(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if md != nil {
+		if l.ImapData != nil {
+			l.ImapData.Keywords = md.Keywords
+		}
+		if md.Original != "" {
+			l.Recipients = &email.Recipients{
+				To:       md.To,
+				Cc:       md.Cc,
+				Original: md.Original,
+			}
+		}
+		l.MessageID = md.MessageId
+		l.InReplyTo = md.InReplyTo
+		l.References = md.References
+		l.TTL = time.Duration(md.Ttl) * time.Second
+		l.Verified = md.Verified
+		if md.SendAt != "" {
+			l.SendAt, err = time.Parse(email.DateFormat, md.SendAt)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
 	if msg.State != nil {
 		lastSend, err := time.Parse(email.DateFormat, msg.State.LastSend)
 		if err != nil {
@@ -125,6 +235,13 @@ func decodeBitmessage(data []byte) (*email.Bmail, obj.Object, error) {
 			AckReceived:              msg.State.AckReceived,
 			Received:                 msg.State.Received,
 		}
+
+		if md != nil {
+			l.State.PowFailures = md.PowFailures
+			l.State.Error = md.Error
+			l.State.Held = md.Held
+			l.State.Approved = md.Approved
+		}
 	}
 
 	var o obj.Object
@@ -188,5 +305,37 @@ func serializeBitmessage(m *email.Bmail, o obj.Object) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	md := &metadata.Metadata{}
+	if m.ImapData != nil {
+		md.Keywords = m.ImapData.Keywords
+	}
+	// The Bcc recipients are deliberately left out.
+	if m.Recipients != nil {
+		md.To = m.Recipients.To
+		md.Cc = m.Recipients.Cc
+		md.Original = m.Recipients.Original
+	}
+	md.MessageId = m.MessageID
+	md.InReplyTo = m.InReplyTo
+	md.References = m.References
+	if !m.SendAt.IsZero() {
+		md.SendAt = m.SendAt.Format(email.DateFormat)
+	}
+	md.Ttl = uint64(m.TTL / time.Second)
+	md.Verified = m.Verified
+	if m.State != nil {
+		md.PowFailures = m.State.PowFailures
+		md.Error = m.State.Error
+		md.Held = m.State.Held
+		md.Approved = m.State.Approved
+	}
+
+	if len(md.Keywords) > 0 || md.Original != "" || md.MessageId != "" ||
+		md.InReplyTo != "" || len(md.References) > 0 || md.SendAt != "" ||
+		md.Ttl != 0 || md.PowFailures != 0 || md.Error != "" ||
+		md.Held != "" || md.Approved || md.Verified {
+		return appendMetadata(data, md)
+	}
 	return data, nil
 }
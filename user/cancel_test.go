@@ -0,0 +1,119 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/powmgr"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmutil/pow"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestCancelPow(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(OutboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	// The proof-of-work never finishes unless it is cancelled.
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	pm := powmgr.New(powmgr.Func(func(target pow.Target, hash []byte,
+		throttle powmgr.Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+		close(started)
+		<-cancel
+		close(stopped)
+		return 0, false
+	}))
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, pm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox := u.boxes[OutboxFolderName]
+
+	bm := MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Hello", "Hello.")
+	if err := outbox.AddNew(bm, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	u.trackPow(bm, pm.Run(pow.Target(1), []byte("object"), powmgr.TypeMessage,
+		powmgr.PriorityNormal, func(pow.Nonce) {
+			close(done)
+		}, nil))
+	<-started
+
+	// Deleting the message from the outbox stops the proof-of-work.
+	if err := outbox.DeleteBitmessageByUID(bm.ImapData.UID); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Proof-of-work was not stopped.")
+	}
+
+	select {
+	case <-done:
+		t.Error("Cancelled order was completed.")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if len(u.orders) != 0 {
+		t.Errorf("Expected no orders to remain, got %d", len(u.orders))
+	}
+}
+
+func TestUntrackPow(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(OutboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := powmgr.New(powmgr.Func(func(target pow.Target, hash []byte,
+		throttle powmgr.Throttle, cancel <-chan struct{}) (pow.Nonce, bool) {
+		return 0, true
+	}))
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, pm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox := u.boxes[OutboxFolderName]
+
+	bm := MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Hello", "Hello.")
+	if err := outbox.AddNew(bm, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	// The order is forgotten once it is done, whether the done function
+	// runs before or after the order is tracked.
+	done := make(chan struct{})
+	u.trackPow(bm, pm.Run(pow.Target(math.MaxUint64), []byte("object"), powmgr.TypeMessage,
+		powmgr.PriorityNormal, func(pow.Nonce) {
+			u.untrackPow(bm.ImapData.UID)
+			close(done)
+		}, nil))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Proof-of-work was not done.")
+	}
+
+	u.ordersMtx.Lock()
+	defer u.ordersMtx.Unlock()
+	if len(u.orders) != 0 {
+		t.Errorf("Expected no orders to remain, got %d", len(u.orders))
+	}
+}
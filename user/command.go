@@ -5,10 +5,12 @@
 package user
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/DanielKrawisz/bmagent/cmd"
 	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/user/email"
 	"github.com/DanielKrawisz/bmutil"
 	"github.com/DanielKrawisz/bmutil/identity"
 	"github.com/jordwest/imap-server/types"
@@ -27,12 +29,15 @@ func PrivateIDToPublicID(pi *keys.PrivateID) cmd.PublicID {
 // our inbox as an email.
 func (u *User) executeCommand(from, name, params string) error {
 	// Get the inbox.
-	commandFolder := u.boxes[CommandsFolderName]
+	commandFolder, err := u.mailbox(CommandsFolderName)
+	if err != nil {
+		return err
+	}
 
 	bm := cmd.EmailCommand(u, from, "command@bm.agent", name, strings.Fields(params))
 
 	// Email ourselves the response.
-	err := commandFolder.AddNew(bm, types.FlagRecent)
+	err = commandFolder.AddNew(bm, types.FlagRecent)
 	if err != nil {
 		return err
 	}
@@ -49,7 +54,22 @@ func (u *User) NewAddress(tag string, sendAck bool) cmd.PublicID {
 	}
 
 	// first generate the new keys.
-	return PrivateIDToPublicID(u.keys.NewUnnamed(bmutil.DefaultStream, behavior))
+	id := u.keys.NewUnnamed(bmutil.DefaultStream, behavior)
+
+	if u.virtual {
+		err := u.addIdentityMailbox(id.Address().String())
+		if err != nil {
+			email.IMAPLog.Errorf("Failed to create folder for %s: %v",
+				id.Address().String(), err)
+		}
+	}
+
+	// Announce the new identity to the network.
+	if u.server != nil {
+		u.server.Publish()
+	}
+
+	return PrivateIDToPublicID(id)
 }
 
 // ListAddresses lists all available addresses.
@@ -62,3 +82,51 @@ func (u *User) ListAddresses() []cmd.PublicID {
 
 	return pi
 }
+
+// GetMessages returns the messages in the given folder which have all of the
+// given keywords. If the folder is empty, every folder is searched except
+// for the virtual folders, which would only return duplicates.
+func (u *User) GetMessages(folder string, keywords []string) ([]*email.Bmail, error) {
+	u.boxesMtx.RLock()
+	defer u.boxesMtx.RUnlock()
+
+	var boxes []*mailbox
+	if folder == "" {
+		names := make([]string, 0, len(u.boxes))
+		for name, mbox := range u.boxes {
+			if mbox.sub == nil {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			boxes = append(boxes, u.boxes[name])
+		}
+	} else {
+		name, err := normalizeMailboxName(folder)
+		if err != nil {
+			return nil, err
+		}
+
+		mbox, ok := u.boxes[name]
+		if !ok {
+			return nil, ErrMailboxNotFound
+		}
+		boxes = []*mailbox{mbox}
+	}
+
+	criteria := &email.SearchCriteria{Keyword: keywords}
+
+	var messages []*email.Bmail
+	for _, mbox := range boxes {
+		for _, uid := range mbox.Search(criteria) {
+			bm := mbox.BitmessageByUID(uid)
+			if bm != nil {
+				messages = append(messages, bm)
+			}
+		}
+	}
+
+	return messages, nil
+}
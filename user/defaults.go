@@ -60,4 +60,33 @@ const (
 	// CommandsFolderName is the default name for the folder containing
 	// responses to sent commands.
 	CommandsFolderName = "Commands"
+
+	// QuarantineFolderName is the name of the folder containing received
+	// messages which did not meet the proof-of-work required of them. It is
+	// created when the first such message is received.
+	QuarantineFolderName = "Quarantine"
+
+	// IdentitiesFolderName is the name of the virtual folder containing the
+	// messages sent to any private identity which is not a chan.
+	IdentitiesFolderName = "Identities"
+
+	// ChansFolderName is the name of the virtual folder containing the
+	// messages sent to any chan.
+	ChansFolderName = "Chans"
+
+	// SubscriptionsFolderName is the name of the virtual folder containing
+	// all broadcasts.
+	SubscriptionsFolderName = "Subscriptions"
+
+	// IdentitiesFolderPrefix is the prefix of the virtual folders containing
+	// the messages sent to each private identity.
+	IdentitiesFolderPrefix = IdentitiesFolderName + FolderDelimiter
+
+	// ChansFolderPrefix is the prefix of the virtual folders containing the
+	// messages sent to each chan.
+	ChansFolderPrefix = ChansFolderName + FolderDelimiter
+
+	// SubscriptionsFolderPrefix is the prefix of the virtual folders
+	// containing the broadcasts from each subscribed address.
+	SubscriptionsFolderPrefix = SubscriptionsFolderName + FolderDelimiter
 )
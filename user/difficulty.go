@@ -0,0 +1,122 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/pow"
+)
+
+// ErrNotHeld is returned when a message is approved which is not being held
+// in the outbox.
+var ErrNotHeld = errors.New("Message is not held for approval")
+
+// PowLimits are the greatest proof-of-work difficulties that will be done for
+// a message without the user's approval. A zero for either part of a
+// difficulty means that there is no limit on it.
+type PowLimits struct {
+	// Default is the limit for recipients without one of their own.
+	Default pow.Data
+
+	// Contacts are the limits for particular recipients by Bitmessage
+	// address.
+	Contacts map[string]pow.Data
+}
+
+// limit returns the limit on the difficulty for the given recipient.
+func (l *PowLimits) limit(addr string) pow.Data {
+	if limit, ok := l.Contacts[addr]; ok {
+		return limit
+	}
+
+	return l.Default
+}
+
+// exceeds returns whether the given difficulty is greater than the limit.
+func exceeds(difficulty, limit pow.Data) bool {
+	return (limit.NonceTrialsPerByte != 0 && difficulty.NonceTrialsPerByte > limit.NonceTrialsPerByte) ||
+		(limit.ExtraBytes != 0 && difficulty.ExtraBytes > limit.ExtraBytes)
+}
+
+// SetPowLimits sets the greatest difficulties that will be done for messages
+// without asking first.
+func (u *User) SetPowLimits(limits *PowLimits) {
+	u.limitsMtx.Lock()
+	defer u.limitsMtx.Unlock()
+
+	u.limits = limits
+}
+
+// checkDifficulty returns ErrDifficultyTooHigh if the recipient of the
+// message demands more proof-of-work than the limit and the user has not
+// approved it anyway. The reason is saved in the message's state.
+func (u *User) checkDifficulty(m *email.Bmail, addr string, difficulty *pow.Data) error {
+	u.limitsMtx.RLock()
+	limits := u.limits
+	u.limitsMtx.RUnlock()
+
+	if limits == nil || difficulty == nil || m.State.Approved {
+		return nil
+	}
+
+	limit := limits.limit(addr)
+	if !exceeds(*difficulty, limit) {
+		m.State.Held = ""
+		return nil
+	}
+
+	m.State.Held = fmt.Sprintf("%s requires %d nonce trials per byte and %d extra bytes, "+
+		"more than the limit of %s; use the approve command to send it anyway",
+		addr, difficulty.NonceTrialsPerByte, difficulty.ExtraBytes, formatLimit(limit))
+
+	return email.ErrDifficultyTooHigh
+}
+
+// formatLimit writes a limit on the difficulty in words.
+func formatLimit(limit pow.Data) string {
+	switch {
+	case limit.NonceTrialsPerByte == 0:
+		return fmt.Sprintf("%d extra bytes", limit.ExtraBytes)
+	case limit.ExtraBytes == 0:
+		return fmt.Sprintf("%d nonce trials per byte", limit.NonceTrialsPerByte)
+	default:
+		return fmt.Sprintf("%d nonce trials per byte and %d extra bytes",
+			limit.NonceTrialsPerByte, limit.ExtraBytes)
+	}
+}
+
+// Approve sends the message in the outbox with the given uid, which is being
+// held because its recipient demands more proof-of-work than the limit.
+func (u *User) Approve(uid uint64) error {
+	outbox, err := u.mailbox(OutboxFolderName)
+	if err != nil {
+		return err
+	}
+
+	bmsg := outbox.BitmessageByUID(uid)
+	if bmsg == nil {
+		return ErrNoMessageFound
+	}
+	if bmsg.State == nil || bmsg.State.Held == "" {
+		return ErrNotHeld
+	}
+
+	email.SMTPLog.Infof("Sending message from %s to %s, which was approved.",
+		bmsg.From, bmsg.To)
+
+	bmsg.State.Held = ""
+	bmsg.State.Approved = true
+	outbox.Lock()
+	err = outbox.saveBitmessage(bmsg)
+	outbox.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return u.process(bmsg)
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/pow"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestCheckDifficulty(t *testing.T) {
+	contact := "BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8"
+	stranger := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+
+	u := &User{}
+	u.SetPowLimits(&PowLimits{
+		Default: pow.Data{NonceTrialsPerByte: 4000, ExtraBytes: 4000},
+		Contacts: map[string]pow.Data{
+			contact: pow.Data{NonceTrialsPerByte: 100000},
+		},
+	})
+
+	tests := []struct {
+		addr       string
+		difficulty pow.Data
+		approved   bool
+		held       bool
+	}{
+		{stranger, pow.Data{NonceTrialsPerByte: 1000, ExtraBytes: 1000}, false, false},
+		{stranger, pow.Data{NonceTrialsPerByte: 4000, ExtraBytes: 4000}, false, false},
+		{stranger, pow.Data{NonceTrialsPerByte: 4001, ExtraBytes: 1000}, false, true},
+		{stranger, pow.Data{NonceTrialsPerByte: 1000, ExtraBytes: 4001}, false, true},
+		{stranger, pow.Data{NonceTrialsPerByte: 50000, ExtraBytes: 1000}, true, false},
+		{contact, pow.Data{NonceTrialsPerByte: 50000, ExtraBytes: 1000000}, false, false},
+		{contact, pow.Data{NonceTrialsPerByte: 200000, ExtraBytes: 1000}, false, true},
+	}
+
+	for i, test := range tests {
+		m := &email.Bmail{State: &email.MessageState{Approved: test.approved}}
+		difficulty := test.difficulty
+		err := u.checkDifficulty(m, test.addr, &difficulty)
+		if test.held {
+			if err != email.ErrDifficultyTooHigh {
+				t.Errorf("%d: expected ErrDifficultyTooHigh, got %v", i, err)
+			}
+			if !strings.Contains(m.State.Held, test.addr) {
+				t.Errorf("%d: expected the reason to name the recipient, got %q", i, m.State.Held)
+			}
+		} else if err != nil || m.State.Held != "" {
+			t.Errorf("%d: expected the message not to be held, got %v, %q", i, err, m.State.Held)
+		}
+	}
+
+	// Without limits, nothing is held.
+	u.SetPowLimits(nil)
+	m := &email.Bmail{State: &email.MessageState{}}
+	if err := u.checkDifficulty(m, stranger, &pow.Data{NonceTrialsPerByte: 1 << 40}); err != nil {
+		t.Errorf("Expected no limit, got %v", err)
+	}
+}
+
+func TestMinimumPow(t *testing.T) {
+	d := pow.Data{
+		NonceTrialsPerByte: pow.DefaultNonceTrialsPerByte,
+		ExtraBytes:         pow.DefaultExtraBytes,
+	}
+
+	tests := []struct {
+		pubkey   pow.Data
+		expected pow.Data
+	}{
+		{pow.Data{}, d},
+		{d, d},
+		{pow.Data{NonceTrialsPerByte: 1, ExtraBytes: 2 * d.ExtraBytes},
+			pow.Data{NonceTrialsPerByte: d.NonceTrialsPerByte, ExtraBytes: 2 * d.ExtraBytes}},
+		{pow.Data{NonceTrialsPerByte: 2 * d.NonceTrialsPerByte, ExtraBytes: 1},
+			pow.Data{NonceTrialsPerByte: 2 * d.NonceTrialsPerByte, ExtraBytes: d.ExtraBytes}},
+	}
+
+	for i, test := range tests {
+		pubkey := test.pubkey
+		if got := minimumPow(&pubkey); *got != test.expected {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, *got)
+		}
+		if pubkey != test.pubkey {
+			t.Errorf("%d: the pubkey's parameters were changed", i)
+		}
+	}
+}
+
+func TestApprove(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(OutboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox := u.boxes[OutboxFolderName]
+
+	bm := MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Hello", "Hello.")
+	bm.State = &email.MessageState{}
+	if err := outbox.AddNew(bm, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.Approve(bm.ImapData.UID); err != ErrNotHeld {
+		t.Errorf("Expected ErrNotHeld, got %v", err)
+	}
+	if err := u.Approve(bm.ImapData.UID + 1); err != ErrNoMessageFound {
+		t.Errorf("Expected ErrNoMessageFound, got %v", err)
+	}
+
+	// A held message shows why in its state.
+	reason := "the recipient requires too much"
+	bm.State.Held = reason
+	if err := outbox.saveBitmessage(bm); err != nil {
+		t.Fatal(err)
+	}
+	header := outbox.MessageByUID(uint32(bm.ImapData.UID)).Header()
+	if got, expected := header.Get("X-Bitmessage-State"), email.StateHeld+": "+reason; got != expected {
+		t.Errorf("Expected state %q, got %q", expected, got)
+	}
+
+	// Once approved, the message is no longer held, though it cannot be
+	// sent here because there is no private key for it.
+	if err := u.Approve(bm.ImapData.UID); err != ErrMissingPrivateID {
+		t.Errorf("Expected ErrMissingPrivateID, got %v", err)
+	}
+	saved := outbox.BitmessageByUID(bm.ImapData.UID)
+	if saved.State.Held != "" || !saved.State.Approved {
+		t.Errorf("Expected the message to be approved, got %q, %t",
+			saved.State.Held, saved.State.Approved)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+var (
+	// ErrInvalidSender is returned when a message to be sent is not from
+	// one of the user's identities.
+	ErrInvalidSender = errors.New("Sender is not one of the user's identities")
+
+	// ErrInvalidRecipient is returned when a message to be sent is not
+	// addressed to a valid Bitmessage address.
+	ErrInvalidRecipient = errors.New("Recipient is not a valid Bitmessage address")
+)
+
+// validateAddresses checks that a message can be sent, which means that it
+// is from one of the user's identities and that it is addressed to a
+// Bitmessage address or to the broadcast address.
+func (u *User) validateAddresses(bmsg *email.Bmail) error {
+	from, err := email.ToBm(bmsg.From)
+	if err != nil || from == "" || u.keys.Get(from) == nil {
+		return ErrInvalidSender
+	}
+
+	if _, err := email.ToBm(bmsg.To); err != nil {
+		return ErrInvalidRecipient
+	}
+
+	return nil
+}
+
+// send puts a new message in the outbox and begins the process of sending
+// it into the network. A message with more than one recipient is sent
+// separately to each of them, and the messages in the outbox are returned.
+// A message which is scheduled to be sent later waits in the outbox until
+// SendScheduled is called after its time. An error is returned only if
+// nothing was put in the outbox. Once the messages are there, a message
+// which cannot be sent keeps the reason with it instead, so that the others
+// are not sent again if the client tries again.
+func (u *User) send(bmsg *email.Bmail) ([]*email.Bmail, error) {
+	// A time which has already passed means that the message is to be sent
+	// right away.
+	if !bmsg.SendAt.After(time.Now()) {
+		bmsg.SendAt = time.Time{}
+	}
+
+	bms := bmsg.Split()
+
+	// Nothing is sent unless every recipient is valid.
+	for _, bm := range bms {
+		err := u.validateAddresses(bm)
+		if err != nil {
+			email.SMTPLog.Errorf("Cannot send message from %s to %s: %v",
+				bm.From, bm.To, err)
+			return nil, err
+		}
+	}
+
+	outbox, err := u.mailbox(OutboxFolderName)
+	if err != nil {
+		return nil, err
+	}
+	for i, bm := range bms {
+		err := outbox.addNew(bm, types.FlagSeen)
+		if err != nil {
+			// Take back the messages that were added already.
+			for _, added := range bms[:i] {
+				if err := outbox.DeleteBitmessageByUID(added.ImapData.UID); err != nil {
+					email.SMTPLog.Errorf("Could not delete message #%d: %v",
+						added.ImapData.UID, err)
+				}
+			}
+			return nil, err
+		}
+	}
+
+	if !bmsg.SendAt.IsZero() {
+		email.SMTPLog.Infof("Message from %s scheduled to be sent at %s.",
+			bmsg.From, bmsg.SendAt.Format(email.DateFormat))
+		return bms, nil
+	}
+
+	for _, bm := range bms {
+		if err := u.process(bm); err != nil {
+			sendFailed(outbox, bm, err)
+		}
+	}
+
+	return bms, nil
+}
+
+// SendDraft sends the message with the given uid in the Drafts folder. The
+// draft is removed once the message has been put in the outbox. If sendAt is
+// not zero, the message is scheduled to be sent at that time instead of any
+// time given in the draft, and likewise if ttl is not zero, the message lives
+// that long in the network.
+func (u *User) SendDraft(uid uint64, sendAt time.Time, ttl time.Duration) ([]*email.Bmail, error) {
+	drafts, err := u.mailbox(DraftsFolderName)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := drafts.BitmessageByUID(uid)
+	if draft == nil {
+		return nil, ErrNoMessageFound
+	}
+
+	// Convert the draft the same way as a message that was sent over SMTP
+	// so that it is checked in the same way.
+	em, err := draft.ToEmail()
+	if err != nil {
+		return nil, err
+	}
+
+	bmsg, err := email.NewBitmessageFromSMTP(em.Content)
+	if err != nil {
+		return nil, err
+	}
+	if !sendAt.IsZero() {
+		bmsg.SendAt = sendAt
+	}
+	if ttl != 0 {
+		bmsg.TTL = ttl
+	}
+
+	bms, err := u.send(bmsg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = drafts.DeleteBitmessageByUID(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return bms, nil
+}
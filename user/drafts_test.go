@@ -0,0 +1,182 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/identity"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestSendDraft(t *testing.T) {
+	folders := data.NewMemFolders()
+	for _, name := range []string{OutboxFolderName, DraftsFolderName} {
+		if _, err := folders.New(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The user has no identities, so no message can be sent.
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drafts := u.boxes[DraftsFolderName]
+	outbox := u.boxes[OutboxFolderName]
+
+	from := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr"
+	to := "BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr"
+
+	save := func(box *mailbox) error {
+		em := box.NewMessage().(*email.IMAPEmail)
+		em.Content.Headers = map[string][]string{
+			"From":    []string{from},
+			"To":      []string{to},
+			"Subject": []string{"Hello"},
+		}
+		em.Content.Body = "Hello, world."
+		return box.Save(em)
+	}
+
+	if err := save(drafts); err != nil {
+		t.Fatal(err)
+	}
+	draft := drafts.BitmessageByUID(1)
+	if draft == nil {
+		t.Fatal("Draft was not saved.")
+	}
+	if draft.To != to {
+		t.Errorf("Expected draft to be addressed to %s, got %s", to, draft.To)
+	}
+
+	_, err = u.SendDraft(2, time.Time{}, 0)
+	if err != ErrNoMessageFound {
+		t.Errorf("Expected error %v, got %v", ErrNoMessageFound, err)
+	}
+
+	_, err = u.SendDraft(1, time.Time{}, 0)
+	if err != ErrInvalidSender {
+		t.Errorf("Expected error %v, got %v", ErrInvalidSender, err)
+	}
+	if drafts.Messages() != 1 {
+		t.Error("Expected draft to remain in Drafts.")
+	}
+
+	// Saving a message in the outbox sends it rather than storing it.
+	err = save(outbox)
+	if err != ErrInvalidSender {
+		t.Errorf("Expected error %v, got %v", ErrInvalidSender, err)
+	}
+	if outbox.Messages() != 0 {
+		t.Error("Expected no messages to be saved in the Outbox.")
+	}
+}
+
+// pubkeyOps is a ServerOps which gives the error in the map for each public
+// identity that is asked for.
+type pubkeyOps map[string]error
+
+func (p pubkeyOps) GetOrRequestPublicID(addr string) (identity.Public, error) {
+	return nil, p[addr]
+}
+
+func (pubkeyOps) Send(obj []byte) {}
+
+func (pubkeyOps) Publish() {}
+
+func (pubkeyOps) Subscribe(address string) error { return nil }
+
+func TestSendPartialFailure(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(OutboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	from := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+	toA := email.BmToEmail("BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8")
+	toB := email.BmToEmail("BM-GtovgYdgs7qXPkoYaRgrLFuFKz1SFpsw")
+
+	// The pubkey of A is requested and B cannot be reached.
+	errUnreachable := errors.New("Unreachable")
+	u, err := NewUser("cosmos", namedKeys{from: &keys.PrivateID{}}, nil, folders, nil,
+		pubkeyOps{toA: email.ErrGetPubKeySent, toB: errUnreachable})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bm := MakeTestBitmessage(email.BmToEmail(from), toA, "Hello", "Hello, both.")
+	bm.Recipients = &email.Recipients{To: []string{toA, toB}}
+
+	// Both messages stay in the outbox and no error is returned, so that
+	// the message to A is not sent again if the client tries again.
+	bms, err := u.send(bm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bms) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(bms))
+	}
+
+	outbox := u.boxes[OutboxFolderName]
+	if outbox.Messages() != 2 {
+		t.Errorf("Expected 2 messages in the Outbox, got %d", outbox.Messages())
+	}
+	for _, bm := range bms {
+		saved := outbox.BitmessageByUID(bm.ImapData.UID)
+		if saved == nil {
+			t.Fatalf("Message to %s is not in the Outbox.", bm.To)
+		}
+
+		var expected string
+		if bm.To == toB {
+			expected = errUnreachable.Error()
+		}
+		if saved.State.Error != expected {
+			t.Errorf("Message to %s: expected error %q, got %q", bm.To,
+				expected, saved.State.Error)
+		}
+	}
+}
+
+func TestRecipientsMetadata(t *testing.T) {
+	f, err := data.NewMemFolders().New(SentFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := newMailbox(SentFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bm := MakeTestBitmessage("BM-From", "BM-To", "a", "first")
+	bm.Recipients = &email.Recipients{
+		To:       []string{"BM-To", "BM-Other"},
+		Cc:       []string{"BM-Copy"},
+		Bcc:      []string{"BM-Secret"},
+		Original: "original",
+	}
+	mb.AddNew(bm, types.FlagSeen)
+
+	mb, err = newMailbox(SentFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &email.Recipients{
+		To:       []string{"BM-To", "BM-Other"},
+		Cc:       []string{"BM-Copy"},
+		Original: "original",
+	}
+	got := mb.BitmessageByUID(1).Recipients
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected recipients %v, got %v", expected, got)
+	}
+}
@@ -6,6 +6,8 @@
 package email
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/mail"
@@ -16,6 +18,7 @@ import (
 	"github.com/DanielKrawisz/bmutil"
 	"github.com/DanielKrawisz/bmutil/cipher"
 	"github.com/DanielKrawisz/bmutil/format"
+	"github.com/DanielKrawisz/bmutil/wire/obj"
 	"github.com/jordwest/imap-server/types"
 	"github.com/mailhog/data"
 )
@@ -52,6 +55,12 @@ var (
 	// do POW on it before proceeding.
 	ErrAckMissing = fmt.Errorf("Ack missing")
 
+	// ErrDifficultyTooHigh is the error returned by GenerateObject when the
+	// recipient of a message demands more proof-of-work than we are willing
+	// to do without the user's approval. The message is held in the outbox
+	// until it is approved.
+	ErrDifficultyTooHigh = fmt.Errorf("Recipient's proof-of-work difficulty is too high")
+
 	// BitmessageRegex is the regex of a Bitmessage address.
 	BitmessageRegex = regexp.MustCompile(fmt.Sprintf("^%s$", bmAddrPattern))
 
@@ -123,6 +132,7 @@ type ImapData struct {
 	UID            uint64
 	SequenceNumber uint32
 	Flags          types.Flags
+	Keywords       []string // Custom flags set by the IMAP client.
 	TimeReceived   time.Time
 	Mailbox        Mailbox
 }
@@ -141,6 +151,32 @@ type MessageState struct {
 	AckReceived bool
 	// Whether the message was received over the bitmessage network.
 	Received bool
+	// The number of times that the proof-of-work for the message was
+	// invalid.
+	PowFailures uint32
+	// The error which stopped the message from being sent, if any.
+	Error string
+	// Why the message is being held in the outbox until the user approves
+	// it, if it is.
+	Held string
+	// Whether the user approved sending the message even though the
+	// recipient demands more proof-of-work than the limit.
+	Approved bool
+}
+
+// Recipients describes a message that was sent to more than one address.
+// Such a message is sent as a separate Bitmessage to each recipient.
+type Recipients struct {
+	// The addresses in the To and Cc headers of the original message.
+	To []string
+	Cc []string
+
+	// The blind carbon copy recipients. They are only known to the original
+	// message and are never saved or shown.
+	Bcc []string
+
+	// Identifies the original message.
+	Original string
 }
 
 // Bmail represents an email compatible with a bitmessage format
@@ -154,9 +190,68 @@ type Bmail struct {
 	Content    format.Encoding
 	ImapData   *ImapData
 	State      *MessageState
+	Recipients *Recipients // Set if the message had more than one recipient.
+
+	// Threading information. MessageID is empty until the message has been
+	// sent. See MessageID.
+	MessageID  string
+	InReplyTo  string
+	References []string
+
+	// SendAt is the time at which a message in the Outbox is scheduled to
+	// be sent. It is zero if the message is to be sent right away.
+	SendAt time.Time
+
+	// TTL is how long the message is to live in the network once it is
+	// sent. If it is zero, the default is used.
+	TTL time.Duration
+
+	// Verified is whether the signature of the sender was verified when
+	// the message was decoded from the network. It is never set from the
+	// headers of an e-mail.
+	Verified bool
+}
+
+// Split returns a separate message for each distinct recipient of a message
+// which was sent to more than one address. The Bcc recipients are removed
+// from every one of them.
+func (m *Bmail) Split() []*Bmail {
+	if m.Recipients == nil {
+		return []*Bmail{m}
+	}
+
+	recipients := &Recipients{
+		To:       m.Recipients.To,
+		Cc:       m.Recipients.Cc,
+		Original: m.Recipients.Original,
+	}
+
+	seen := make(map[string]struct{})
+	var bms []*Bmail
+	for _, list := range [][]string{m.Recipients.To, m.Recipients.Cc, m.Recipients.Bcc} {
+		for _, to := range list {
+			if _, ok := seen[to]; ok {
+				continue
+			}
+			seen[to] = struct{}{}
+
+			bm := *m
+			bm.To = to
+			bm.Recipients = recipients
+			if m.State != nil {
+				state := *m.State
+				bm.State = &state
+			}
+			bms = append(bms, &bm)
+		}
+	}
+
+	return bms
 }
 
-// MsgRead creates a Bitmessage object from an unencrypted wire.MsgMsg.
+// MsgRead creates a Bitmessage object from an unencrypted wire.MsgMsg. The
+// message must have been decrypted with cipher.TryDecryptAndVerifyMessage,
+// which checks the signature of the sender, so it is marked as verified.
 func MsgRead(msg *cipher.Message, toAddress string, ofChan bool) (*Bmail, error) {
 	bmsg := msg.Bitmessage()
 	object := msg.Object()
@@ -165,37 +260,76 @@ func MsgRead(msg *cipher.Message, toAddress string, ofChan bool) (*Bmail, error)
 
 	fromAddress := from.Address().String()
 
-	return &Bmail{
+	content, err := readContent(bmsg.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	bm := &Bmail{
 		From:       BmToEmail(fromAddress),
 		To:         BmToEmail(toAddress),
 		Expiration: header.Expiration(),
 		OfChannel:  ofChan,
-		Content:    bmsg.Content,
+		Content:    content,
 		Ack:        msg.Ack(),
-	}, nil
+		MessageID:  MessageID(obj.InventoryHash(object)),
+		Verified:   true,
+	}
+	bm.readThreading()
+	return bm, nil
 }
 
 // BroadcastRead creates a Bitmessage object from an unencrypted
-// wire.MsgBroadcast.
+// wire.MsgBroadcast. As with MsgRead, the signature must have been checked
+// when it was decrypted.
 func BroadcastRead(msg *cipher.Broadcast) (*Bmail, error) {
 	bmsg := msg.Bitmessage()
 
-	return &Bmail{
+	content, err := readContent(bmsg.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	bm := &Bmail{
 		From:       Broadcast,
 		To:         BmToEmail(bmsg.Public.Address().String()),
 		Expiration: msg.Object().Header().Expiration(),
-		Content:    bmsg.Content,
-	}, nil
+		Content:    content,
+		MessageID:  MessageID(obj.InventoryHash(msg.Object())),
+		Verified:   true,
+	}
+	bm.readThreading()
+	return bm, nil
+}
+
+// readThreading copies the threading information in the content of a
+// message into the message.
+func (m *Bmail) readThreading() {
+	if e, ok := m.Content.(*Extended); ok {
+		m.InReplyTo = e.InReplyTo
+		m.References = e.References
+	}
 }
 
 // ToEmail converts a Bitmessage into an IMAPEmail.
 func (m *Bmail) ToEmail() (*IMAPEmail, error) {
-	var payload *format.Encoding2
+	return m.ToEmailWithHeaders(nil)
+}
+
+// ToEmailWithHeaders converts a Bitmessage into an IMAPEmail with some
+// additional headers, such as those given by StatusHeaders.
+func (m *Bmail) ToEmailWithHeaders(extra map[string][]string) (*IMAPEmail, error) {
+	var subject, contentType, body string
 	switch m := m.Content.(type) {
-	// Only encoding 2 is considered to be compatible with email.
-	// In the future, there may be more encodings also compatible with email.
+	// Only encoding 2 and the extended encoding are considered to be
+	// compatible with email.
 	case *format.Encoding2:
-		payload = m
+		subject = m.Subject
+		contentType = plainContentType
+		body = m.Body
+	case *Extended:
+		subject = m.Subject
+		contentType, body = m.ToMIME()
 	default:
 		return nil, errors.New("Wrong format")
 	}
@@ -206,36 +340,70 @@ func (m *Bmail) ToEmail() (*IMAPEmail, error) {
 
 	headers := make(map[string][]string)
 
-	headers["Subject"] = []string{payload.Subject}
+	headers["Subject"] = []string{subject}
 
 	headers["From"] = []string{m.From}
 
 	headers["To"] = []string{m.To}
 
+	// The Bcc recipients are not included because they are never saved.
+	if m.Recipients != nil {
+		all := make([]string, 0, len(m.Recipients.To)+len(m.Recipients.Cc))
+		all = append(all, m.Recipients.To...)
+		all = append(all, m.Recipients.Cc...)
+		headers["X-Bitmessage-Recipients"] = []string{strings.Join(all, ", ")}
+	}
+
 	headers["Date"] = []string{m.ImapData.TimeReceived.Format(DateFormat)}
-	headers["Expires"] = []string{m.Expiration.Format(DateFormat)}
+	// Drafts may not have an expiration yet.
+	if !m.Expiration.IsZero() {
+		headers["Expires"] = []string{m.Expiration.Format(DateFormat)}
+	}
+	if !m.SendAt.IsZero() {
+		headers["Send-At"] = []string{m.SendAt.Format(DateFormat)}
+	}
+	if m.TTL != 0 {
+		headers["X-Bitmessage-TTL"] = []string{FormatTTL(m.TTL)}
+	}
 	if m.OfChannel {
 		headers["Reply-To"] = []string{m.To}
 	}
-	headers["Content-Type"] = []string{`text/plain; charset="UTF-8"`}
+	if m.MessageID != "" {
+		headers["Message-ID"] = []string{m.MessageID}
+	}
+	if m.InReplyTo != "" {
+		headers["In-Reply-To"] = []string{m.InReplyTo}
+	}
+	if len(m.References) > 0 {
+		headers["References"] = []string{strings.Join(m.References, " ")}
+	}
+	if m.Verified {
+		headers["X-Bitmessage-Sender-Verified"] = []string{"yes"}
+	}
+	for key, value := range extra {
+		headers[key] = value
+	}
+	headers["MIME-Version"] = []string{"1.0"}
+	headers["Content-Type"] = []string{contentType}
 	headers["Content-Transfer-Encoding"] = []string{"8bit"}
 
 	content := &data.Content{
 		Headers: headers,
-		Body:    payload.Body,
+		Body:    body,
 	}
 
 	email := &IMAPEmail{
 		ImapSequenceNumber: m.ImapData.SequenceNumber,
 		ImapUID:            m.ImapData.UID,
 		ImapFlags:          m.ImapData.Flags,
+		ImapKeywords:       m.ImapData.Keywords,
 		Date:               m.ImapData.TimeReceived,
 		Mailbox:            m.ImapData.Mailbox,
 		Content:            content,
 	}
 
 	// Calculate the size of the message.
-	content.Size = len(fmt.Sprintf("%s\r\n", email.Header())) + len(payload.Body)
+	content.Size = len(fmt.Sprintf("%s\r\n", email.Header())) + len(body)
 
 	return email, nil
 }
@@ -245,13 +413,13 @@ func NewBitmessageFromSMTP(smtp *data.Content) (*Bmail, error) {
 	header := smtp.Headers
 
 	// Check that To and From are set.
-	toList, ok := header["To"]
-	if !ok {
-		return nil, errors.New("Invalid headers: To field is required")
+	toList, err := addressList(header["To"])
+	if err != nil {
+		return nil, err
 	}
 
-	if len(toList) != 1 {
-		return nil, errors.New("Invalid headers: only one To field is allowed.")
+	if len(toList) == 0 {
+		return nil, errors.New("Invalid headers: To field is required")
 	}
 
 	fromList, ok := header["From"]
@@ -263,26 +431,43 @@ func NewBitmessageFromSMTP(smtp *data.Content) (*Bmail, error) {
 		return nil, errors.New("Invalid headers: only one From field is allowed.")
 	}
 
-	var from, to string
-
 	if !(ValidateEmail(fromList[0]) || ValidateEmail(toList[0])) {
 		return nil, ErrInvalidEmail
 	}
 
-	// No errors because this must have succeeded when the
-	// address was validated above.
-	fromAddr, _ := mail.ParseAddress(fromList[0])
-	toAddr, _ := mail.ParseAddress(toList[0])
-	from = fromAddr.Address
-	to = toAddr.Address
+	// Only one of the addresses was validated above, so the other
+	// might still not be an e-mail address at all.
+	fromAddr, err := mail.ParseAddress(fromList[0])
+	if err != nil {
+		return nil, ErrInvalidEmail
+	}
+	from := fromAddr.Address
+	to := toList[0]
+
+	// Bitmessage has no notion of more than one recipient, so a message
+	// with several of them is sent separately to each one. See Split.
+	ccList, err := addressList(header["Cc"])
+	if err != nil {
+		return nil, err
+	}
+	bccList, err := addressList(header["Bcc"])
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients *Recipients
+	if len(toList)+len(ccList)+len(bccList) > 1 {
+		original, err := originalID(header)
+		if err != nil {
+			return nil, err
+		}
 
-	// If CC or BCC are set, give an error because these headers cannot
-	// be made to work as the user would expect with the Bitmessage protocol
-	// as it is currently defined.
-	cc, okCc := header["Cc"]
-	bcc, okBcc := header["Bcc"]
-	if (okCc && len(cc) > 0) || (okBcc && len(bcc) > 0) {
-		return nil, errors.New("Invalid headers: do not use CC or BCC with Bitmessage")
+		recipients = &Recipients{
+			To:       toList,
+			Cc:       ccList,
+			Bcc:      bccList,
+			Original: original,
+		}
 	}
 
 	// Expires is a rarely-used header that is relevant to Bitmessage.
@@ -297,6 +482,16 @@ func NewBitmessageFromSMTP(smtp *data.Content) (*Bmail, error) {
 		expiration = exp
 	}
 
+	sendAt, err := readSendAt(header)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := readTTL(header)
+	if err != nil {
+		return nil, err
+	}
+
 	var subject string
 	if subj, ok := header["Subject"]; ok {
 		subject = subj[0]
@@ -304,24 +499,75 @@ func NewBitmessageFromSMTP(smtp *data.Content) (*Bmail, error) {
 		subject = ""
 	}
 
-	body, err := GetSMTPBody(smtp)
+	content, err := GetSMTPContent(subject, smtp)
 	if err != nil {
 		return nil, err
 	}
+	inReplyTo, references := ThreadingHeaders(header)
+
+	if len(content.Message()) > MaxContentSize {
+		return nil, ErrMessageTooLarge
+	}
 
 	return &Bmail{
 		From:       from,
 		To:         to,
 		Expiration: expiration,
 		Ack:        nil,
-		Content: &format.Encoding2{
-			Subject: subject,
-			Body:    body,
-		},
-		State: &MessageState{},
+		Content:    content,
+		State:      &MessageState{},
+		Recipients: recipients,
+		InReplyTo:  inReplyTo,
+		References: references,
+		SendAt:     sendAt,
+		TTL:        ttl,
 	}, nil
 }
 
+// readSendAt reads the Send-At header, which gives the time at which a
+// message is to be sent. It has the same format as Expires.
+func readSendAt(header map[string][]string) (time.Time, error) {
+	sendAtStr, ok := header["Send-At"]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(DateFormat, sendAtStr[0])
+}
+
+// addressList reads the addresses in the values of an address header.
+func addressList(values []string) ([]string, error) {
+	var addresses []string
+	for _, value := range values {
+		list, err := mail.ParseAddressList(value)
+		if err != nil {
+			return nil, ErrInvalidEmail
+		}
+
+		for _, addr := range list {
+			addresses = append(addresses, addr.Address)
+		}
+	}
+
+	return addresses, nil
+}
+
+// originalID returns an identifier for a message which is sent to more than
+// one recipient. The Message-ID is used if the message has one.
+func originalID(header map[string][]string) (string, error) {
+	for key, value := range header {
+		if strings.EqualFold(key, "Message-Id") && len(value) > 0 {
+			return value[0], nil
+		}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // NewBitmessageDraftFromSMTP takes an SMTP e-mail and turns it into a Bitmessage,
 // but is less strict than NewBitmessageFromSMTP in how it checks the email.
 func NewBitmessageDraftFromSMTP(smtp *data.Content) (*Bmail, error) {
@@ -333,7 +579,7 @@ func NewBitmessageDraftFromSMTP(smtp *data.Content) (*Bmail, error) {
 	toList, ok := header["To"]
 	if !ok || len(toList) != 1 {
 		to = ""
-	} else if len(toList) != 1 {
+	} else {
 		to = toList[0]
 	}
 
@@ -356,6 +602,16 @@ func NewBitmessageDraftFromSMTP(smtp *data.Content) (*Bmail, error) {
 		expiration = exp
 	}
 
+	sendAt, err := readSendAt(header)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := readTTL(header)
+	if err != nil {
+		return nil, err
+	}
+
 	var subject string
 	if subj, ok := header["Subject"]; ok {
 		subject = subj[0]
@@ -363,24 +619,26 @@ func NewBitmessageDraftFromSMTP(smtp *data.Content) (*Bmail, error) {
 		subject = ""
 	}
 
-	body, err := GetSMTPBody(smtp)
+	content, err := GetSMTPContent(subject, smtp)
 	if err != nil {
 		return nil, err
 	}
+	inReplyTo, references := ThreadingHeaders(header)
 
 	return &Bmail{
 		From:       from,
 		To:         to,
 		Expiration: expiration,
 		Ack:        nil,
-		Content: &format.Encoding2{
-			Subject: subject,
-			Body:    body,
-		},
+		Content:    content,
 		State: &MessageState{
 			// false if broadcast; Code for setting it false if sending to
 			// channel/self is in GenerateObject.
 			AckExpected: to != "",
 		},
+		InReplyTo:  inReplyTo,
+		References: references,
+		SendAt:     sendAt,
+		TTL:        ttl,
 	}, nil
 }
@@ -6,7 +6,12 @@
 package email
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/mailhog/data"
 )
 
 func TestEmailAddressConversion(t *testing.T) {
@@ -60,3 +65,59 @@ func TestEmailAddressConversion(t *testing.T) {
 		}
 	}
 }
+
+func TestMultipleRecipients(t *testing.T) {
+	from := "BM-NBddNS6ZagzjNbMMkVBpecuSAPU1EgyQ@bm.addr"
+	a := "BM-NBPVwY5A26MtyfbHyh4UfA4Hn76DamAP@bm.addr"
+	b := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr"
+	c := "BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr"
+
+	bm, err := NewBitmessageFromSMTP(&data.Content{
+		Headers: map[string][]string{
+			"From":       []string{from},
+			"To":         []string{"Alice <" + a + ">, " + b},
+			"Cc":         []string{a},
+			"Bcc":        []string{c},
+			"Subject":    []string{"Hello"},
+			"Message-Id": []string{"<original@bm.agent>"},
+		},
+		Body: "Hello, everyone.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bms := bm.Split()
+	var to []string
+	for _, m := range bms {
+		to = append(to, m.To)
+	}
+	if !reflect.DeepEqual(to, []string{a, b, c}) {
+		t.Fatalf("Expected messages to %v, got %v", []string{a, b, c}, to)
+	}
+
+	for _, m := range bms {
+		if m.Recipients.Original != "<original@bm.agent>" {
+			t.Errorf("Expected message to be linked to the original, got %q",
+				m.Recipients.Original)
+		}
+		if len(m.Recipients.Bcc) != 0 {
+			t.Errorf("Bcc recipients %v were not removed.", m.Recipients.Bcc)
+		}
+
+		m.ImapData = &ImapData{TimeReceived: time.Now()}
+		em, err := m.ToEmail()
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipients := strings.Join(em.Content.Headers["X-Bitmessage-Recipients"], "")
+		if recipients != strings.Join([]string{a, b, a}, ", ") {
+			t.Errorf("Wrong recipients header %q", recipients)
+		}
+		for key, value := range em.Content.Headers {
+			if strings.Contains(strings.Join(value, ""), c) && !(key == "To" && m.To == c) {
+				t.Errorf("Bcc recipient was revealed in header %s", key)
+			}
+		}
+	}
+}
@@ -67,57 +67,9 @@ func GetContentType(contentType string) (content, subtype string, param map[stri
 	return matches[1], matches[2], param, nil
 }
 
-// getPlainBody retrieves the plaintext body from the e-mail.
-func getPlainBody(email *data.Content) (string, error) {
-	contentType, ok := email.Headers["Content-Type"]
-	if !ok {
-		return "", errors.New("Unrecognized MIME version")
-	}
-	content, subtype, _, err := GetContentType(contentType[0])
-	if err != nil {
-		return "", err
-	}
-
-	if content == "text" && subtype == "plain" {
-		return email.Body, nil
-	}
-
-	return "", nil
-}
-
-// GetSMTPBody return the body of an e-mail to be delivered through SMTP.
+// GetSMTPBody return the plain text body of an e-mail to be delivered
+// through SMTP.
 func GetSMTPBody(email *data.Content) (string, error) {
-	if version, ok := email.Headers["MIME-Version"]; ok {
-		if version[0] != "1.0" {
-			return "", errors.New("Unrecognized MIME version")
-		}
-
-		// Case 1: message just has type text/plain
-		contentType, ok := email.Headers["Content-Type"]
-		if !ok {
-			return "", errors.New("Unrecognized MIME version")
-		}
-		content, subtype, _, err := GetContentType(contentType[0])
-		if err != nil {
-			return "", err
-		}
-		if content == "text" && subtype == "plain" {
-			return email.Body, nil
-		}
-
-		// Case 2: message has type mime/alternative, so get text/plain
-		if content == "multipart" && subtype == "alternative" {
-			for _, part := range email.ParseMIMEBody().Parts {
-				body, _ := getPlainBody(part)
-				if body != "" {
-					return body, nil
-				}
-			}
-			return "", errors.New("Couldn't find a text/plain MIME part")
-		}
-
-		// TODO we should be able to support html bodies eventually.
-		return "", fmt.Errorf("Unsupported Content-Type: %s; use text/plain instead", contentType[0])
-	}
-	return email.Body, nil
+	body, _, err := readMIME(email)
+	return body, err
 }
@@ -22,6 +22,7 @@ type IMAPEmail struct {
 	ImapSequenceNumber uint32
 	ImapUID            uint64
 	ImapFlags          types.Flags
+	ImapKeywords       []string
 	Date               time.Time
 	Mailbox            Mailbox
 	Content            *data.Content
@@ -48,15 +49,50 @@ func (e *IMAPEmail) Size() uint32 {
 // (This is not the date on the envelope of the email).
 func (e *IMAPEmail) InternalDate() time.Time { return e.Date }
 
-// Body returns the body of the email.
+// Body returns the body of the email. The whole MIME body is returned so
+// that clients can see every part of the message.
 func (e *IMAPEmail) Body() string {
-	body, _ := GetSMTPBody(e.Content)
-	return body
+	return e.Content.Body
 }
 
 // Keywords returns the list of custom keywords/flags for this message.
 func (e *IMAPEmail) Keywords() []string {
-	return make([]string, 0)
+	if e.ImapKeywords == nil {
+		return make([]string, 0)
+	}
+	return e.ImapKeywords
+}
+
+// OverwriteKeywords overwrites the keywords for this message and returns the
+// updated message. Invalid keywords are ignored.
+func (e *IMAPEmail) OverwriteKeywords(keywords []string) mailstore.Message {
+	e.ImapKeywords = nil
+	return e.AddKeywords(keywords)
+}
+
+// AddKeywords adds keywords to this message and returns the updated message.
+// Invalid keywords are ignored.
+func (e *IMAPEmail) AddKeywords(keywords []string) mailstore.Message {
+	for _, k := range keywords {
+		if !ValidKeyword(k) || HasKeyword(e.ImapKeywords, k) {
+			continue
+		}
+		e.ImapKeywords = append(e.ImapKeywords, k)
+	}
+	return e
+}
+
+// RemoveKeywords removes keywords from this message and returns the updated
+// message.
+func (e *IMAPEmail) RemoveKeywords(keywords []string) mailstore.Message {
+	kept := make([]string, 0, len(e.ImapKeywords))
+	for _, k := range e.ImapKeywords {
+		if !HasKeyword(keywords, k) {
+			kept = append(kept, k)
+		}
+	}
+	e.ImapKeywords = kept
+	return e
 }
 
 // Flags returns the flags for this message.
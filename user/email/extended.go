@@ -0,0 +1,282 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/DanielKrawisz/bmutil/format"
+	"github.com/DanielKrawisz/bmutil/format/serialize"
+	"github.com/DanielKrawisz/bmutil/wire"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// ExtendedEncoding is the number of Bitmessage's extended encoding, in which
+// a message is a zlib-compressed msgpack object.
+const ExtendedEncoding = 3
+
+const (
+	// MaxContentSize is the largest message that can be sent. It leaves
+	// room within an object for the keys, the signature, the encryption
+	// and the ack.
+	MaxContentSize = wire.MaxPayloadOfMsgObject - 2048
+
+	// maxExtendedSize is the largest that an extended message is allowed
+	// to be once it is decompressed.
+	maxExtendedSize = 1 << 24
+)
+
+var (
+	// ErrMessageTooLarge is returned when a message is too large to fit in
+	// a Bitmessage object.
+	ErrMessageTooLarge = fmt.Errorf("Message is larger than the maximum of %d bytes",
+		MaxContentSize)
+
+	// ErrInvalidExtended is returned when a message in the extended
+	// encoding cannot be read.
+	ErrInvalidExtended = errors.New("Invalid extended encoding")
+)
+
+// Part is a part of a message other than its plain text body, such as an
+// HTML version of the body or an attachment.
+type Part struct {
+	// ContentType is the full MIME type of the part, including parameters.
+	ContentType string
+
+	// Name is the file name of an attachment. It is empty for parts which
+	// are shown inline.
+	Name string
+
+	Data []byte
+}
+
+// Extended is the content of a message in the extended encoding. Clients
+// which do not understand the parts can still show the subject and body.
+// It implements format.Encoding.
+type Extended struct {
+	Subject string
+	Body    string
+	Parts   []*Part
+
+	// The Message-IDs of the message to which this one is a reply and of
+	// the rest of the conversation. See MessageID.
+	InReplyTo  string
+	References []string
+}
+
+// Encoding returns the number of the encoding.
+func (e *Extended) Encoding() uint64 {
+	return ExtendedEncoding
+}
+
+// Message returns the message in the extended encoding.
+func (e *Extended) Message() []byte {
+	var msg bytes.Buffer
+	enc := msgpack.NewEncoder(&msg)
+
+	// Writing to a bytes.Buffer never fails, so neither does encoding.
+	fields := 3
+	if len(e.Parts) > 0 {
+		fields++
+	}
+	if e.InReplyTo != "" {
+		fields++
+	}
+	if len(e.References) > 0 {
+		fields++
+	}
+	enc.EncodeMapLen(fields)
+	enc.EncodeString("")
+	enc.EncodeString("message")
+	enc.EncodeString("subject")
+	enc.EncodeString(e.Subject)
+	enc.EncodeString("body")
+	enc.EncodeString(e.Body)
+
+	if len(e.Parts) > 0 {
+		enc.EncodeString("parts")
+		enc.EncodeArrayLen(len(e.Parts))
+		for _, part := range e.Parts {
+			enc.EncodeMapLen(3)
+			enc.EncodeString("type")
+			enc.EncodeString(part.ContentType)
+			enc.EncodeString("name")
+			enc.EncodeString(part.Name)
+			enc.EncodeString("data")
+			enc.EncodeBytes(part.Data)
+		}
+	}
+
+	if e.InReplyTo != "" {
+		enc.EncodeString("in-reply-to")
+		enc.EncodeString(e.InReplyTo)
+	}
+
+	if len(e.References) > 0 {
+		enc.EncodeString("references")
+		enc.EncodeArrayLen(len(e.References))
+		for _, ref := range e.References {
+			enc.EncodeString(ref)
+		}
+	}
+
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(msg.Bytes())
+	w.Close()
+	return b.Bytes()
+}
+
+// ToProtobuf encodes the message in a protobuf format.
+func (e *Extended) ToProtobuf() *serialize.Encoding {
+	return &serialize.Encoding{
+		Format: ExtendedEncoding,
+		Body:   e.Message(),
+	}
+}
+
+// DecodeExtended reads a message in the extended encoding.
+func DecodeExtended(msg []byte) (*Extended, error) {
+	r, err := zlib.NewReader(bytes.NewReader(msg))
+	if err != nil {
+		return nil, ErrInvalidExtended
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxExtendedSize+1))
+	if err != nil {
+		return nil, ErrInvalidExtended
+	}
+	if len(b) > maxExtendedSize {
+		return nil, ErrMessageTooLarge
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	e := &Extended{}
+	var msgType string
+	err = decodeMap(dec, func(key string) error {
+		var err error
+		switch key {
+		case "":
+			msgType, err = dec.DecodeString()
+		case "subject":
+			e.Subject, err = dec.DecodeString()
+		case "body":
+			e.Body, err = dec.DecodeString()
+		case "parts":
+			e.Parts, err = decodeParts(dec)
+		case "in-reply-to":
+			e.InReplyTo, err = dec.DecodeString()
+		case "references":
+			e.References, err = decodeStrings(dec)
+		default:
+			err = dec.Skip()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, ErrInvalidExtended
+	}
+
+	if msgType != "message" {
+		return nil, fmt.Errorf("Unsupported message type %q", msgType)
+	}
+
+	return e, nil
+}
+
+// decodeMap reads a msgpack map with string keys and calls f to read the
+// value of each key.
+func decodeMap(dec *msgpack.Decoder, f func(key string) error) error {
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		key, err := dec.DecodeString()
+		if err != nil {
+			return err
+		}
+		if err = f(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeParts reads the parts of an extended message.
+func decodeParts(dec *msgpack.Decoder) ([]*Part, error) {
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []*Part
+	for i := 0; i < n; i++ {
+		part := &Part{}
+		err := decodeMap(dec, func(key string) error {
+			var err error
+			switch key {
+			case "type":
+				part.ContentType, err = dec.DecodeString()
+			case "name":
+				part.Name, err = dec.DecodeString()
+			case "data":
+				part.Data, err = dec.DecodeBytes()
+			default:
+				err = dec.Skip()
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, part)
+	}
+
+	return parts, nil
+}
+
+// decodeStrings reads an array of strings.
+func decodeStrings(dec *msgpack.Decoder) ([]string, error) {
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return nil, err
+	}
+
+	var strs []string
+	for i := 0; i < n; i++ {
+		s, err := dec.DecodeString()
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, s)
+	}
+
+	return strs, nil
+}
+
+// readContent returns the content of a message which was received from the
+// network in a form that bmagent understands.
+func readContent(c format.Encoding) (format.Encoding, error) {
+	switch c.(type) {
+	case *format.Encoding1, *format.Encoding2, *Extended:
+		return c, nil
+	}
+
+	if c.Encoding() == ExtendedEncoding {
+		return DecodeExtended(c.Message())
+	}
+
+	return c, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DanielKrawisz/bmutil/format"
+	"github.com/mailhog/data"
+)
+
+func TestExtendedEncoding(t *testing.T) {
+	attachment := make([]byte, 300)
+	for i := range attachment {
+		attachment[i] = byte(i)
+	}
+
+	e := &Extended{
+		Subject: "Pictures",
+		Body:    "Here are the pictures.",
+		Parts: []*Part{
+			&Part{
+				ContentType: `text/html; charset="UTF-8"`,
+				Data:        []byte("<p>Here are the <b>pictures</b>.</p>"),
+			},
+			&Part{
+				ContentType: "image/png",
+				Name:        "cat.png",
+				Data:        attachment,
+			},
+		},
+	}
+
+	decoded, err := DecodeExtended(e.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, e) {
+		t.Errorf("Expected %v, got %v", e, decoded)
+	}
+
+	_, err = DecodeExtended([]byte("not compressed"))
+	if err != ErrInvalidExtended {
+		t.Errorf("Expected error %v, got %v", ErrInvalidExtended, err)
+	}
+
+	// Rendering the message as MIME and reading it back gives the same
+	// message.
+	contentType, body := e.ToMIME()
+	content, err := GetSMTPContent(e.Subject, &data.Content{
+		Headers: map[string][]string{
+			"MIME-Version": []string{"1.0"},
+			"Content-Type": []string{contentType},
+		},
+		Body: body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(content, e) {
+		t.Errorf("Expected %v, got %v", e, content)
+	}
+
+	// Plain text messages use encoding 2.
+	content, err = GetSMTPContent("Hello", &data.Content{
+		Headers: map[string][]string{
+			"MIME-Version":              []string{"1.0"},
+			"Content-Type":              []string{`text/plain; charset="UTF-8"`},
+			"Content-Transfer-Encoding": []string{"quoted-printable"},
+		},
+		Body: "caf=C3=A9",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &format.Encoding2{Subject: "Hello", Body: "café"}
+	if !reflect.DeepEqual(content, expected) {
+		t.Errorf("Expected %v, got %v", expected, content)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SystemFlags are the flags defined by the IMAP protocol which can be
+// permanently stored by bmagent.
+var SystemFlags = []string{`\Answered`, `\Flagged`, `\Deleted`, `\Seen`, `\Draft`}
+
+// keywordRegex matches an IMAP atom, which is what a keyword must be. System
+// flags, which begin with a backslash, are not keywords.
+var keywordRegex = regexp.MustCompile(`^[^\\(){ %*"\]\x00-\x1f\x7f]+$`)
+
+// ValidKeyword returns whether the given string can be used as a custom IMAP
+// keyword.
+func ValidKeyword(keyword string) bool {
+	return keywordRegex.MatchString(keyword)
+}
+
+// HasKeyword returns whether the keyword is in the list. Keywords are
+// compared case-insensitively.
+func HasKeyword(keywords []string, keyword string) bool {
+	for _, k := range keywords {
+		if strings.EqualFold(k, keyword) {
+			return true
+		}
+	}
+	return false
+}
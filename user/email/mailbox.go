@@ -9,6 +9,21 @@ import (
 	"github.com/jordwest/imap-server/types"
 )
 
+// MailboxUpdate describes a change to a mailbox. It contains the
+// information that must be sent to an IMAP client in the IDLE state.
+type MailboxUpdate struct {
+	// The number of messages in the mailbox after the change.
+	Messages uint32
+
+	// The number of recent messages in the mailbox after the change.
+	Recent uint32
+
+	// The sequence numbers of messages that were removed from the mailbox,
+	// in the order in which they should be reported to the client. Each
+	// sequence number is valid after the removals listed before it.
+	Expunged []uint32
+}
+
 // Mailbox represent a mailbox compatible with both IMAP and Bitmessage.
 type Mailbox interface {
 	mailstore.Mailbox
@@ -21,4 +36,18 @@ type Mailbox interface {
 
 	// DeleteBitmessageByUID deletes a bitmessage by uid.
 	DeleteBitmessageByUID(id uint64) error
+
+	// Watch returns a channel on which an update is sent every time the
+	// Mailbox changes, and a function which stops the updates and closes
+	// the channel.
+	Watch() (<-chan *MailboxUpdate, func())
+
+	// Search returns the uids of the messages which match the given
+	// criteria, in ascending order.
+	Search(criteria *SearchCriteria) []uint64
+
+	// PermanentFlags returns the flags and keywords which can be stored
+	// permanently in the Mailbox, as they should be listed in the
+	// PERMANENTFLAGS response code.
+	PermanentFlags() []string
 }
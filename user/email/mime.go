@@ -0,0 +1,261 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+
+	"github.com/DanielKrawisz/bmutil/format"
+	"github.com/mailhog/data"
+)
+
+const (
+	plainContentType = `text/plain; charset="UTF-8"`
+
+	// base64LineLength is the length of the lines of base64-encoded parts.
+	base64LineLength = 76
+)
+
+// getHeader returns the first value of a header, ignoring the case of its
+// name.
+func getHeader(headers map[string][]string, key string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, key) && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// GetSMTPContent returns the content of an e-mail to be delivered through
+// SMTP. Plain text e-mails use encoding 2, which every client understands.
+// Anything else, such as an HTML body or attachments, requires the extended
+// encoding.
+//
+// Threading headers also require the extended encoding. See ThreadingHeaders.
+func GetSMTPContent(subject string, email *data.Content) (format.Encoding, error) {
+	body, parts, err := readMIME(email)
+	if err != nil {
+		return nil, err
+	}
+
+	inReplyTo, references := ThreadingHeaders(email.Headers)
+
+	if len(parts) == 0 && inReplyTo == "" && len(references) == 0 {
+		return &format.Encoding2{
+			Subject: subject,
+			Body:    body,
+		}, nil
+	}
+
+	return &Extended{
+		Subject:    subject,
+		Body:       body,
+		Parts:      parts,
+		InReplyTo:  inReplyTo,
+		References: references,
+	}, nil
+}
+
+// readMIME finds the plain text body of an e-mail and all of its other parts.
+func readMIME(email *data.Content) (string, []*Part, error) {
+	if version, ok := email.Headers["MIME-Version"]; ok {
+		if version[0] != "1.0" {
+			return "", nil, errors.New("Unrecognized MIME version")
+		}
+
+		r := &mimeReader{}
+		if err := r.read(email); err != nil {
+			return "", nil, err
+		}
+		return r.body, r.parts, nil
+	}
+	return email.Body, nil, nil
+}
+
+// mimeReader collects the parts of a MIME message.
+type mimeReader struct {
+	body    string
+	hasBody bool
+	parts   []*Part
+}
+
+func (r *mimeReader) read(content *data.Content) error {
+	contentType := getHeader(content.Headers, "Content-Type")
+	if contentType == "" {
+		contentType = plainContentType
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		body := content.ParseMIMEBody()
+		if body == nil {
+			return errors.New("Invalid multipart message")
+		}
+		for _, part := range body.Parts {
+			// Text outside of the parts, such as the preamble and what
+			// follows the last boundary, looks like a part with no headers.
+			if len(part.Headers) == 0 {
+				continue
+			}
+			if err := r.read(part); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	b, err := decodeTransferEncoding(content)
+	if err != nil {
+		return err
+	}
+
+	// The name of an attachment is given by Content-Disposition, or
+	// sometimes only by Content-Type.
+	name := params["name"]
+	if disposition := getHeader(content.Headers, "Content-Disposition"); disposition != "" {
+		d, dParams, err := mime.ParseMediaType(disposition)
+		if err == nil && d == "attachment" {
+			if dParams["filename"] != "" {
+				name = dParams["filename"]
+			} else if name == "" {
+				name = "attachment"
+			}
+		}
+	}
+
+	// The first plain text part is the body.
+	if mediaType == "text/plain" && name == "" && !r.hasBody {
+		r.body = string(b)
+		r.hasBody = true
+		return nil
+	}
+
+	r.parts = append(r.parts, &Part{
+		ContentType: contentType,
+		Name:        name,
+		Data:        b,
+	})
+	return nil
+}
+
+// decodeTransferEncoding returns the body of a part of a MIME message
+// without its Content-Transfer-Encoding.
+func decodeTransferEncoding(content *data.Content) ([]byte, error) {
+	switch strings.ToLower(getHeader(content.Headers, "Content-Transfer-Encoding")) {
+	case "base64":
+		return base64.StdEncoding.DecodeString(strings.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, content.Body))
+	case "quoted-printable":
+		return ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(content.Body)))
+	default:
+		return []byte(content.Body), nil
+	}
+}
+
+// mimePart is a part of a MIME message which is about to be written.
+type mimePart struct {
+	contentType string
+	disposition string
+	encoding    string
+	body        string
+}
+
+// newMIMEPart prepares a Part to be written in a MIME message.
+func newMIMEPart(p *Part) *mimePart {
+	var lines []string
+	encoded := base64.StdEncoding.EncodeToString(p.Data)
+	for len(encoded) > base64LineLength {
+		lines = append(lines, encoded[:base64LineLength])
+		encoded = encoded[base64LineLength:]
+	}
+	lines = append(lines, encoded)
+
+	part := &mimePart{
+		contentType: p.ContentType,
+		encoding:    "base64",
+		body:        strings.Join(lines, "\r\n"),
+	}
+	if p.Name != "" {
+		part.disposition = mime.FormatMediaType("attachment",
+			map[string]string{"filename": p.Name})
+	}
+	return part
+}
+
+// multipartBody writes parts into a multipart message, which is returned
+// as a single part.
+func multipartBody(subtype string, parts []*mimePart) *mimePart {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for _, part := range parts {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Type", part.contentType)
+		if part.disposition != "" {
+			h.Set("Content-Disposition", part.disposition)
+		}
+		if part.encoding != "" {
+			h.Set("Content-Transfer-Encoding", part.encoding)
+		}
+
+		// Writing to a bytes.Buffer does not fail.
+		pw, _ := w.CreatePart(h)
+		pw.Write([]byte(part.body))
+	}
+	w.Close()
+
+	return &mimePart{
+		contentType: mime.FormatMediaType("multipart/"+subtype,
+			map[string]string{"boundary": w.Boundary()}),
+		body: b.String(),
+	}
+}
+
+// ToMIME renders the message as MIME. It returns the value of the
+// Content-Type header and the body. An HTML version of the message is given
+// as an alternative to the plain text body and the other parts are
+// attachments.
+func (e *Extended) ToMIME() (string, string) {
+	main := &mimePart{
+		contentType: plainContentType,
+		encoding:    "8bit",
+		body:        e.Body,
+	}
+
+	alternatives := []*mimePart{main}
+	var attachments []*mimePart
+	for _, p := range e.Parts {
+		mediaType, _, _ := mime.ParseMediaType(p.ContentType)
+		if p.Name == "" && mediaType == "text/html" {
+			alternatives = append(alternatives, newMIMEPart(p))
+		} else {
+			attachments = append(attachments, newMIMEPart(p))
+		}
+	}
+
+	if len(alternatives) > 1 {
+		main = multipartBody("alternative", alternatives)
+	}
+	if len(attachments) > 0 {
+		main = multipartBody("mixed", append([]*mimePart{main}, attachments...))
+	}
+
+	return main.contentType, main.body
+}
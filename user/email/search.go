@@ -0,0 +1,83 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"time"
+
+	"github.com/jordwest/imap-server/types"
+)
+
+// SearchCriteria represents the keys of an IMAP SEARCH command. A message
+// matches if it satisfies every key that is set. String keys are matched
+// as case-insensitive substrings, except that the body is only searched
+// for whole words, so it matches if it contains every word of the key.
+type SearchCriteria struct {
+	From    []string
+	To      []string
+	Subject []string
+	Body    []string
+
+	// Text matches the subject or the body.
+	Text []string
+
+	// Since and Before are compared with the date that the message was
+	// received, disregarding the time of day.
+	Since  time.Time
+	Before time.Time
+
+	// Flags that must be set and flags that must not be set.
+	WithFlags    types.Flags
+	WithoutFlags types.Flags
+
+	// Keywords that must be set and keywords that must not be set.
+	Keyword   []string
+	Unkeyword []string
+
+	// If not nil, only messages with uids in this set match.
+	UIDs types.SequenceSet
+}
+
+// InSequenceSet returns whether the uid or sequence number n is in the set.
+// last is the highest uid or sequence number in the mailbox, which is what
+// "*" refers to.
+func InSequenceSet(n, last uint64, set types.SequenceSet) bool {
+	for _, r := range set {
+		var min, max uint64
+		if r.Min.Last() {
+			min = last
+		} else {
+			v, err := r.Min.Value()
+			if err != nil {
+				continue
+			}
+			min = uint64(v)
+		}
+
+		switch {
+		case r.Max.Nil():
+			max = min
+		case r.Max.Last():
+			max = last
+		default:
+			v, err := r.Max.Value()
+			if err != nil {
+				continue
+			}
+			max = uint64(v)
+		}
+
+		// The range may be given in either order.
+		if min > max {
+			min, max = max, min
+		}
+
+		if n >= min && n <= max {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/DanielKrawisz/bmutil"
+)
+
+// The states of a message which is being sent, as given by Status.
+const (
+	StateScheduled    = "scheduled"
+	StateWaitPubkey   = "waiting for pubkey"
+	StateWaitPow      = "waiting for proof-of-work"
+	StateWaitAck      = "waiting for ack"
+	StateAcknowledged = "acknowledged"
+	StateSent         = "sent"
+	StateFailed       = "failed"
+	StateHeld         = "held"
+)
+
+// Status returns where a message which is being sent is in the process of
+// sending it.
+func (m *Bmail) Status() string {
+	switch {
+	case !m.SendAt.IsZero():
+		return StateScheduled
+	case m.State == nil:
+		return StateWaitPow
+	case m.State.Error != "":
+		return StateFailed
+	case m.State.Held != "":
+		return StateHeld + ": " + m.State.Held
+	case m.State.PubkeyRequestOutstanding:
+		return StateWaitPubkey
+	case m.State.SendTries == 0:
+		return StateWaitPow
+	case m.State.AckReceived:
+		return StateAcknowledged
+	case m.State.AckExpected:
+		return StateWaitAck
+	default:
+		return StateSent
+	}
+}
+
+// InventoryHash returns the inventory hash of the object in which the
+// message was sent, as a hex string, or an empty string if the message has
+// not been sent.
+func (m *Bmail) InventoryHash() string {
+	if !messageIDRegex.MatchString(m.MessageID) {
+		return ""
+	}
+	return m.MessageID[1:65]
+}
+
+// StatusHeaders returns headers which show the delivery status of a message
+// which is being sent.
+func (m *Bmail) StatusHeaders() map[string][]string {
+	headers := make(map[string][]string)
+	headers["X-Bitmessage-State"] = []string{m.Status()}
+
+	if m.State != nil {
+		headers["X-Bitmessage-Send-Tries"] = []string{strconv.FormatUint(uint64(m.State.SendTries), 10)}
+		if m.State.SendTries > 0 {
+			headers["X-Bitmessage-Last-Send"] = []string{m.State.LastSend.Format(DateFormat)}
+		}
+
+		var ack string
+		switch {
+		case m.State.AckReceived:
+			ack = "received"
+		case m.State.AckExpected:
+			ack = "expected"
+		default:
+			ack = "none"
+		}
+		headers["X-Bitmessage-Ack"] = []string{ack}
+
+		if m.State.PowFailures > 0 {
+			headers["X-Bitmessage-Pow-Failures"] = []string{strconv.FormatUint(uint64(m.State.PowFailures), 10)}
+		}
+		if m.State.Error != "" {
+			headers["X-Bitmessage-Error"] = []string{m.State.Error}
+		}
+	}
+
+	if hash := m.InventoryHash(); hash != "" {
+		headers["X-Bitmessage-Inventory-Hash"] = []string{hash}
+
+		// Once the message has been sent, its expiration is that of the
+		// object that was sent.
+		if !m.Expiration.IsZero() {
+			headers["X-Bitmessage-Expires"] = []string{m.Expiration.Format(DateFormat)}
+		}
+	}
+
+	if from, err := ToBm(m.From); err == nil && from != "" {
+		if addr, err := bmutil.DecodeAddress(from); err == nil {
+			headers["X-Bitmessage-Stream"] = []string{fmt.Sprintf("%d", addr.Stream())}
+		}
+	}
+
+	return headers
+}
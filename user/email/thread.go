@@ -0,0 +1,46 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DanielKrawisz/bmutil/hash"
+)
+
+// messageIDRegex matches the Message-IDs made by MessageID.
+var messageIDRegex = regexp.MustCompile(`^<[0-9a-f]{64}@bm\.addr>$`)
+
+// MessageID returns the Message-ID of the message sent in the object with
+// the given inventory hash. The sender and every recipient of a message
+// agree on it without having to send it along with the message.
+func MessageID(inv *hash.Sha) string {
+	return fmt.Sprintf("<%s@bm.addr>", inv.String())
+}
+
+// ThreadingHeaders reads the In-Reply-To and References headers of an
+// e-mail. Only the Message-IDs of Bitmessages are kept, since no others mean
+// anything to the recipients and they could reveal something about the
+// sender.
+func ThreadingHeaders(headers map[string][]string) (string, []string) {
+	var inReplyTo string
+	for _, id := range strings.Fields(getHeader(headers, "In-Reply-To")) {
+		if messageIDRegex.MatchString(id) {
+			inReplyTo = id
+			break
+		}
+	}
+
+	var references []string
+	for _, id := range strings.Fields(getHeader(headers, "References")) {
+		if messageIDRegex.MatchString(id) {
+			references = append(references, id)
+		}
+	}
+
+	return inReplyTo, references
+}
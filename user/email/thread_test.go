@@ -0,0 +1,55 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mailhog/data"
+)
+
+func TestThreadingHeaders(t *testing.T) {
+	first := "<" + strings.Repeat("ab", 32) + "@bm.addr>"
+	second := "<" + strings.Repeat("cd", 32) + "@bm.addr>"
+
+	// Message-IDs made by other mail clients are left out.
+	content, err := GetSMTPContent("Re: Hello", &data.Content{
+		Headers: map[string][]string{
+			"In-Reply-To": []string{second},
+			"References":  []string{first + " <1234@example.com> " + second},
+		},
+		Body: "Hello again.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Extended{
+		Subject:    "Re: Hello",
+		Body:       "Hello again.",
+		InReplyTo:  second,
+		References: []string{first, second},
+	}
+	if !reflect.DeepEqual(content, expected) {
+		t.Errorf("Expected %v, got %v", expected, content)
+	}
+
+	decoded, err := DecodeExtended(expected.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("Expected %v, got %v", expected, decoded)
+	}
+
+	inReplyTo, references := ThreadingHeaders(map[string][]string{
+		"In-Reply-To": []string{"<1234@example.com>"},
+	})
+	if inReplyTo != "" || references != nil {
+		t.Errorf("Expected no threading headers, got %q and %v", inReplyTo, references)
+	}
+}
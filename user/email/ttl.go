@@ -0,0 +1,103 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// MaxTTL is the longest time that an object may live in the Bitmessage
+// network. Peers reject objects which expire any later.
+const MaxTTL = 28 * 24 * time.Hour
+
+// ErrInvalidTTL is returned when a time to live cannot be read.
+var ErrInvalidTTL = errors.New("Invalid time to live")
+
+// ttlRegex matches a time to live such as 4d or 1d12h.
+var ttlRegex = regexp.MustCompile(`^(\d+[wdhms])+$`)
+
+// ttlUnits are the units in which a time to live is written, in order of
+// size.
+var ttlUnits = []struct {
+	symbol byte
+	length time.Duration
+}{
+	{'w', 7 * 24 * time.Hour},
+	{'d', 24 * time.Hour},
+	{'h', time.Hour},
+	{'m', time.Minute},
+	{'s', time.Second},
+}
+
+// ParseTTL reads a time to live, which is given as a sequence of numbers
+// each followed by one of the units w, d, h, m or s. For example, 4d is four
+// days and 1d12h is a day and a half.
+func ParseTTL(s string) (time.Duration, error) {
+	if !ttlRegex.MatchString(s) {
+		return 0, ErrInvalidTTL
+	}
+
+	var ttl time.Duration
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			continue
+		}
+
+		n, err := strconv.ParseUint(s[start:i], 10, 32)
+		if err != nil {
+			return 0, ErrInvalidTTL
+		}
+		for _, unit := range ttlUnits {
+			if unit.symbol == s[i] {
+				// Check before multiplying, which could overflow.
+				if n > uint64(MaxTTL/unit.length) {
+					return 0, ErrInvalidTTL
+				}
+				ttl += time.Duration(n) * unit.length
+			}
+		}
+		if ttl > MaxTTL {
+			return 0, ErrInvalidTTL
+		}
+		start = i + 1
+	}
+
+	if ttl == 0 {
+		return 0, ErrInvalidTTL
+	}
+	return ttl, nil
+}
+
+// FormatTTL writes a time to live in the form read by ParseTTL.
+func FormatTTL(ttl time.Duration) string {
+	ttl -= ttl % time.Second
+	if ttl <= 0 {
+		return "0s"
+	}
+
+	var s string
+	for _, unit := range ttlUnits[1:] {
+		if ttl >= unit.length {
+			s += fmt.Sprintf("%d%c", ttl/unit.length, unit.symbol)
+			ttl %= unit.length
+		}
+	}
+	return s
+}
+
+// readTTL reads the X-Bitmessage-TTL header.
+func readTTL(header map[string][]string) (time.Duration, error) {
+	ttlStr, ok := header["X-Bitmessage-TTL"]
+	if !ok {
+		return 0, nil
+	}
+
+	return ParseTTL(ttlStr[0])
+}
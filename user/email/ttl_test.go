@@ -0,0 +1,50 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		s   string
+		ttl time.Duration
+	}{
+		{"4d", 4 * 24 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"2w", 14 * 24 * time.Hour},
+		{"1h30m15s", time.Hour + 30*time.Minute + 15*time.Second},
+	}
+
+	for _, test := range tests {
+		ttl, err := ParseTTL(test.s)
+		if err != nil {
+			t.Errorf("ParseTTL(%q) gave error %v", test.s, err)
+			continue
+		}
+		if ttl != test.ttl {
+			t.Errorf("ParseTTL(%q): expected %v, got %v", test.s, test.ttl, ttl)
+		}
+
+		reparsed, err := ParseTTL(FormatTTL(ttl))
+		if err != nil || reparsed != ttl {
+			t.Errorf("FormatTTL(%v) gave %q, which could not be read back",
+				ttl, FormatTTL(ttl))
+		}
+	}
+
+	if FormatTTL(36*time.Hour) != "1d12h" {
+		t.Errorf("Expected 1d12h, got %s", FormatTTL(36*time.Hour))
+	}
+
+	for _, s := range []string{"", "4", "d", "4x", "-4d", "0d", "29d", "4d ", "30500w"} {
+		if _, err := ParseTTL(s); err != ErrInvalidTTL {
+			t.Errorf("ParseTTL(%q): expected error %v, got %v", s, ErrInvalidTTL, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+// FolderDelimiter separates the levels of hierarchical folder names.
+const FolderDelimiter = "/"
+
+var (
+	// ErrInvalidMailboxName is returned when a mailbox name cannot be used.
+	ErrInvalidMailboxName = errors.New("Invalid mailbox name")
+
+	// ErrMailboxExists is returned when trying to create a mailbox with the
+	// name of one that already exists.
+	ErrMailboxExists = errors.New("Mailbox already exists")
+
+	// ErrMailboxNotFound is returned when the given mailbox does not exist.
+	ErrMailboxNotFound = errors.New("Mailbox not found")
+
+	// ErrProtectedMailbox is returned when trying to rename or delete one of
+	// the mailboxes that bmagent requires in order to work.
+	ErrProtectedMailbox = errors.New("Mailbox cannot be renamed or deleted")
+
+	// ErrInferiorMailboxes is returned when trying to delete a mailbox which
+	// has other mailboxes below it in the hierarchy.
+	ErrInferiorMailboxes = errors.New("Mailbox has inferior hierarchical names")
+)
+
+// protectedFolders are the folders which cannot be renamed or deleted.
+var protectedFolders = map[string]struct{}{
+	InboxFolderName:    struct{}{},
+	OutboxFolderName:   struct{}{},
+	LimboFolderName:    struct{}{},
+	SentFolderName:     struct{}{},
+	CommandsFolderName: struct{}{},
+}
+
+// normalizeMailboxName cleans up a mailbox name given by an IMAP client.
+func normalizeMailboxName(name string) (string, error) {
+	name = strings.TrimSuffix(name, FolderDelimiter)
+
+	// Enforce the case insensitivity of Inbox.
+	if strings.ToLower(name) == strings.ToLower(InboxFolderName) {
+		return InboxFolderName, nil
+	}
+
+	if name == "" || strings.HasPrefix(name, FolderDelimiter) {
+		return "", ErrInvalidMailboxName
+	}
+	for _, level := range strings.Split(name, FolderDelimiter) {
+		if level == "" {
+			return "", ErrInvalidMailboxName
+		}
+	}
+
+	return name, nil
+}
+
+// isInferior returns whether the mailbox called name is below the mailbox
+// called parent in the hierarchy.
+func isInferior(name, parent string) bool {
+	return strings.HasPrefix(name, parent+FolderDelimiter)
+}
+
+// checkMutable returns an error if the mailbox cannot be renamed or deleted.
+func (u *User) checkMutable(name string) (*mailbox, error) {
+	if _, ok := protectedFolders[name]; ok {
+		return nil, ErrProtectedMailbox
+	}
+
+	mbox, ok := u.boxes[name]
+	if !ok {
+		return nil, ErrMailboxNotFound
+	}
+
+	// Virtual mailboxes are generated from the identities and
+	// subscriptions and cannot be changed by the user.
+	if mbox.sub != nil {
+		return nil, ErrProtectedMailbox
+	}
+
+	return mbox, nil
+}
+
+// newMailbox creates a new mailbox in the store. It assumes that the lock
+// for the set of mailboxes is held.
+func (u *User) newMailbox(name string) (*mailbox, error) {
+	if _, ok := u.boxes[name]; ok {
+		return nil, ErrMailboxExists
+	}
+
+	folder, err := u.folders.New(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var mb *mailbox
+	switch name {
+	case DraftsFolderName:
+		mb, err = newDrafts(name, folder, u.keys.Names())
+	case OutboxFolderName:
+		mb, err = newOutbox(name, folder, u.keys.Names(), u.send, u.cancelPow)
+	default:
+		mb, err = newMailbox(name, folder, u.keys.Names())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.showStatus(mb)
+	u.boxes[name] = mb
+	return mb, nil
+}
+
+// NewMailbox adds a new mailbox. If the name is hierarchical, any superior
+// mailboxes that do not exist are created as well.
+func (u *User) NewMailbox(name string) (email.Mailbox, error) {
+	name, err := normalizeMailboxName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u.boxesMtx.Lock()
+	defer u.boxesMtx.Unlock()
+
+	if _, ok := u.boxes[name]; ok {
+		return nil, ErrMailboxExists
+	}
+
+	// Create superior mailboxes.
+	levels := strings.Split(name, FolderDelimiter)
+	for i := 1; i < len(levels); i++ {
+		superior := strings.Join(levels[:i], FolderDelimiter)
+		if _, ok := u.boxes[superior]; ok {
+			continue
+		}
+
+		if _, err := u.newMailbox(superior); err != nil {
+			return nil, err
+		}
+	}
+
+	email.IMAPLog.Info("Creating mailbox ", name)
+	return u.newMailbox(name)
+}
+
+// RenameMailbox renames a mailbox along with all mailboxes below it in the
+// hierarchy.
+func (u *User) RenameMailbox(name, newname string) error {
+	name, err := normalizeMailboxName(name)
+	if err != nil {
+		return err
+	}
+	newname, err = normalizeMailboxName(newname)
+	if err != nil {
+		return err
+	}
+
+	u.boxesMtx.Lock()
+	defer u.boxesMtx.Unlock()
+
+	if _, err := u.checkMutable(name); err != nil {
+		return err
+	}
+	if _, ok := u.boxes[newname]; ok {
+		return ErrMailboxExists
+	}
+	if newname == name || isInferior(newname, name) {
+		return ErrInvalidMailboxName
+	}
+
+	// Gather the mailboxes to be renamed.
+	names := []string{name}
+	for n := range u.boxes {
+		if isInferior(n, name) {
+			if _, err := u.checkMutable(n); err != nil {
+				return err
+			}
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	// Create any superior mailboxes of the new name that don't exist.
+	levels := strings.Split(newname, FolderDelimiter)
+	for i := 1; i < len(levels); i++ {
+		superior := strings.Join(levels[:i], FolderDelimiter)
+		if _, ok := u.boxes[superior]; ok {
+			continue
+		}
+
+		if _, err := u.newMailbox(superior); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range names {
+		renamed := newname + strings.TrimPrefix(n, name)
+		if _, ok := u.boxes[renamed]; ok {
+			return ErrMailboxExists
+		}
+
+		email.IMAPLog.Info("Renaming mailbox ", n, " to ", renamed)
+		err := u.folders.Rename(n, renamed)
+		if err != nil {
+			return err
+		}
+
+		mbox := u.boxes[n]
+		mbox.Lock()
+		mbox.name = renamed
+		mbox.Unlock()
+
+		delete(u.boxes, n)
+		u.boxes[renamed] = mbox
+	}
+
+	return nil
+}
+
+// DeleteMailbox deletes a mailbox and all the messages in it. Mailboxes
+// with inferior hierarchical names cannot be deleted.
+func (u *User) DeleteMailbox(name string) error {
+	name, err := normalizeMailboxName(name)
+	if err != nil {
+		return err
+	}
+
+	u.boxesMtx.Lock()
+	defer u.boxesMtx.Unlock()
+
+	if _, err := u.checkMutable(name); err != nil {
+		return err
+	}
+	for n := range u.boxes {
+		if isInferior(n, name) {
+			return ErrInferiorMailboxes
+		}
+	}
+
+	email.IMAPLog.Info("Deleting mailbox ", name)
+	err = u.folders.Delete(name)
+	if err != nil {
+		return err
+	}
+
+	delete(u.boxes, name)
+	return nil
+}
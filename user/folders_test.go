@@ -0,0 +1,191 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/store/data"
+)
+
+// emptyKeys is a keys.Manager with no keys in it.
+type emptyKeys struct{}
+
+func (emptyKeys) Get(address string) *keys.PrivateID { return nil }
+
+func (emptyKeys) New(name string, stream uint64, behavior uint32) *keys.PrivateID {
+	return nil
+}
+
+func (emptyKeys) NewUnnamed(stream uint64, behavior uint32) *keys.PrivateID {
+	return nil
+}
+
+func (emptyKeys) Names() map[string]string { return make(map[string]string) }
+
+func TestUserFolders(t *testing.T) {
+	folders := data.NewMemFolders()
+	for _, name := range []string{InboxFolderName, OutboxFolderName,
+		LimboFolderName, SentFolderName, CommandsFolderName, DraftsFolderName} {
+		if _, err := folders.New(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists := func(name string, expected bool) {
+		_, err := u.MailboxByName(name)
+		if (err == nil) != expected {
+			t.Errorf("Mailbox %s: expected existence to be %v", name, expected)
+		}
+	}
+
+	// Creating a hierarchical mailbox creates its superiors.
+	_, err = u.NewMailbox("Work/Projects/bmagent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists("Work", true)
+	exists("Work/Projects", true)
+	exists("Work/Projects/bmagent", true)
+
+	_, err = u.NewMailbox("Work/")
+	if err != ErrMailboxExists {
+		t.Error("Expected ErrMailboxExists, got", err)
+	}
+	_, err = u.NewMailbox("Work//Empty")
+	if err != ErrInvalidMailboxName {
+		t.Error("Expected ErrInvalidMailboxName, got", err)
+	}
+
+	// Renaming a mailbox renames its inferiors.
+	err = u.RenameMailbox("Work/Projects", "Archive/2016")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists("Work", true)
+	exists("Work/Projects", false)
+	exists("Work/Projects/bmagent", false)
+	exists("Archive", true)
+	exists("Archive/2016", true)
+	exists("Archive/2016/bmagent", true)
+
+	// A mailbox with inferiors cannot be deleted.
+	err = u.DeleteMailbox("Archive/2016")
+	if err != ErrInferiorMailboxes {
+		t.Error("Expected ErrInferiorMailboxes, got", err)
+	}
+	err = u.DeleteMailbox("Archive/2016/bmagent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists("Archive/2016/bmagent", false)
+
+	// Drafts is not protected.
+	err = u.RenameMailbox(DraftsFolderName, "Unsent")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Protected mailboxes cannot be renamed or deleted.
+	for _, name := range []string{"inbox", OutboxFolderName, LimboFolderName,
+		SentFolderName, CommandsFolderName} {
+		if err := u.RenameMailbox(name, "Other"); err != ErrProtectedMailbox {
+			t.Errorf("Renaming %s: expected ErrProtectedMailbox, got %v", name, err)
+		}
+		if err := u.DeleteMailbox(name); err != ErrProtectedMailbox {
+			t.Errorf("Deleting %s: expected ErrProtectedMailbox, got %v", name, err)
+		}
+		exists(name, true)
+	}
+}
+
+func TestDeliverQuarantined(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(InboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The Quarantine folder is created for the first message.
+	for i := 1; i <= 2; i++ {
+		bm := MakeTestBitmessage("BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr",
+			"BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr", "Spam", "Spam.")
+		if err := u.DeliverQuarantined(bm); err != nil {
+			t.Fatal(err)
+		}
+
+		mbox, err := u.MailboxByName(QuarantineFolderName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mbox.Messages() != uint32(i) {
+			t.Errorf("Expected %d messages in quarantine, got %d", i, mbox.Messages())
+		}
+	}
+
+	if n := u.boxes[InboxFolderName].Messages(); n != 0 {
+		t.Errorf("Expected no messages in the Inbox, got %d", n)
+	}
+	if n := u.boxes[QuarantineFolderName].Recent(); n != 2 {
+		t.Errorf("Expected 2 recent messages in quarantine, got %d", n)
+	}
+}
+
+func TestConcurrentMailboxes(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(InboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mailboxes are created and deleted while messages are delivered.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if _, err := u.NewMailbox("Work"); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := u.DeleteMailbox("Work"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		bm := MakeTestBitmessage("BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr",
+			"BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr", "Hello", "Hello.")
+		if err := u.DeliverFromBMNet(bm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+
+	// Messages for mailboxes that don't exist are not delivered.
+	bm := MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Hello", "Hello.")
+	if err := u.DeliverPublic("BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8", nil); err != ErrMailboxNotFound {
+		t.Errorf("Expected %v without an Outbox, got %v", ErrMailboxNotFound, err)
+	}
+	if err := u.Move(bm, OutboxFolderName, SentFolderName); err != ErrMailboxNotFound {
+		t.Errorf("Expected %v without an Outbox, got %v", ErrMailboxNotFound, err)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"strings"
+)
+
+// sessionCapabilities are the capabilities which depend on the session
+// rather than on imap-server. Whatever imap-server announces of them is
+// replaced by what the session supports.
+var sessionCapabilities = map[string]bool{
+	"IDLE":          true,
+	"STARTTLS":      true,
+	"LOGINDISABLED": true,
+}
+
+// capabilities returns the capabilities of the session which are added to
+// those that imap-server announces.
+func (s *session) capabilities() []string {
+	caps := []string{"IDLE"}
+	if !s.secure() {
+		caps = append(caps, "STARTTLS", "LOGINDISABLED")
+	}
+	return caps
+}
+
+// addCapabilities replaces the capabilities which depend on the session in
+// a CAPABILITY response or response code from imap-server with caps.
+func addCapabilities(line string, caps []string) string {
+	upper := strings.ToUpper(line)
+
+	var start, end int
+	if strings.HasPrefix(upper, "* CAPABILITY ") {
+		start = len("* CAPABILITY ")
+		end = len(strings.TrimRight(line, "\r\n"))
+	} else if i := strings.Index(upper, "[CAPABILITY "); i >= 0 {
+		start = i + len("[CAPABILITY ")
+		end = strings.Index(line[start:], "]")
+		if end < 0 {
+			return line
+		}
+		end += start
+	} else {
+		return line
+	}
+
+	var announced []string
+	for _, c := range strings.Fields(line[start:end]) {
+		if !sessionCapabilities[strings.ToUpper(c)] {
+			announced = append(announced, c)
+		}
+	}
+
+	return line[:start] + strings.Join(append(announced, caps...), " ") + line[end:]
+}
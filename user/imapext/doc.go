@@ -0,0 +1,18 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package imapext handles the IMAP commands which are not supported by
+// github.com/jordwest/imap-server. imap-server has no way to add commands or
+// response codes, so imapext stands between it and the IMAP clients. It passes
+// everything through except for the commands that it handles itself, which
+// are answered using the mailboxes of the user.
+//
+// imapext also handles STARTTLS, so that it can read the commands of clients
+// which use TLS. imap-server is on the other side of a pipe and never sees
+// TLS.
+//
+// Commands which are handled here are only executed after imap-server has
+// answered every command before them, so that the responses reach the client
+// in order.
+package imapext
@@ -0,0 +1,63 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"strings"
+)
+
+// permanentFlagsCode returns the PERMANENTFLAGS response code for the
+// mailbox with the given name.
+func (s *session) permanentFlagsCode(name string) (string, bool) {
+	box, err := s.mailbox(name)
+	if err != nil {
+		return "", false
+	}
+
+	return "[PERMANENTFLAGS (" + strings.Join(box.PermanentFlags(), " ") + ")]", true
+}
+
+// replacePermanentFlags replaces the PERMANENTFLAGS response code which
+// imap-server sends in answer to SELECT with the flags and keywords that the
+// mailbox can store. It must be called with the lock of the session held.
+func (s *session) replacePermanentFlags(p *pending, line string) string {
+	if p.name != "SELECT" {
+		return line
+	}
+
+	i := strings.Index(strings.ToUpper(line), "[PERMANENTFLAGS ")
+	if i < 0 {
+		return line
+	}
+	j := strings.Index(line[i:], "]")
+	if j < 0 {
+		return line
+	}
+
+	code, ok := s.permanentFlagsCode(p.mailbox)
+	if !ok {
+		return line
+	}
+
+	p.permanentFlags = true
+	return line[:i] + code + line[i+j+1:]
+}
+
+// missingPermanentFlags returns a PERMANENTFLAGS response to be sent before
+// the response which completes SELECT, if imap-server did not send one. It
+// must be called with the lock of the session held.
+func (s *session) missingPermanentFlags(p *pending) string {
+	if p.name != "SELECT" || p.permanentFlags {
+		return ""
+	}
+
+	code, ok := s.permanentFlagsCode(p.mailbox)
+	if !ok {
+		return ""
+	}
+
+	p.permanentFlags = true
+	return "* OK " + code + " Flags permitted\r\n"
+}
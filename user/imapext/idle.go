@@ -0,0 +1,91 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"strings"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+// errNotDone is returned when a client in the IDLE state sends something
+// other than DONE.
+var errNotDone = syntaxError("Expected DONE")
+
+// writeUpdate tells the client about a change to the selected mailbox.
+func (s *session) writeUpdate(update *email.MailboxUpdate) error {
+	for _, seqno := range update.Expunged {
+		if err := s.writeLine("* %d EXPUNGE", seqno); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writeLine("* %d EXISTS", update.Messages); err != nil {
+		return err
+	}
+	return s.writeLine("* %d RECENT", update.Recent)
+}
+
+// cmdIdle sends the changes to the selected mailbox to the client as they
+// happen, until the client sends DONE. If no mailbox is selected, it only
+// waits for DONE.
+func cmdIdle(s *session, tag string, args []argument) error {
+	if len(args) != 0 {
+		return s.respond(tag, "BAD", "IDLE takes no arguments")
+	}
+
+	s.mtx.Lock()
+	selected := s.selected
+	s.mtx.Unlock()
+
+	var updates <-chan *email.MailboxUpdate
+	if selected != "" {
+		box, err := s.mailbox(selected)
+		if err != nil {
+			return s.respond(tag, "NO", err.Error())
+		}
+
+		var stop func()
+		updates, stop = box.Watch()
+		defer stop()
+	}
+
+	if err := s.writeLine("+ idling"); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		line, err := s.clientR.ReadString('\n')
+		if err == nil && !strings.EqualFold(strings.TrimRight(line, "\r\n"), "DONE") {
+			err = errNotDone
+		}
+		done <- err
+	}()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+
+			if err := s.writeUpdate(update); err != nil {
+				return err
+			}
+
+		case err := <-done:
+			if err == errNotDone {
+				return s.respond(tag, "BAD", err.Error())
+			}
+			if err != nil {
+				return err
+			}
+
+			return s.respond(tag, "OK", "IDLE terminated")
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"crypto/tls"
+	"net"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/mailstore"
+)
+
+// Mailstore is the part of the mailstore of a user which is needed to handle
+// the commands that imap-server does not support. It is implemented by
+// user.User.
+type Mailstore interface {
+	// MailboxByName returns the mailbox with the given name.
+	MailboxByName(name string) (mailstore.Mailbox, error)
+
+	// NewMailbox creates a mailbox along with any superior mailboxes in the
+	// hierarchy that do not exist.
+	NewMailbox(name string) (email.Mailbox, error)
+
+	// RenameMailbox renames a mailbox and the mailboxes below it.
+	RenameMailbox(name, newname string) error
+
+	// DeleteMailbox deletes a mailbox.
+	DeleteMailbox(name string) error
+}
+
+// Listener is a net.Listener to be given to imap-server. Every connection
+// that it accepts is handled by a session which answers the commands that
+// imap-server does not support and passes the rest through to imap-server.
+type Listener struct {
+	net.Listener
+	store  Mailstore
+	config *tls.Config
+}
+
+// NewListener wraps a listener for IMAP clients. If config is not nil,
+// clients must start TLS with STARTTLS before they log in. TLS ends at the
+// session, so imap-server only ever sees plaintext.
+func NewListener(l net.Listener, store Mailstore, config *tls.Config) *Listener {
+	return &Listener{
+		Listener: l,
+		store:    store,
+		config:   config,
+	}
+}
+
+// Accept waits for a client to connect and returns the connection that
+// imap-server should serve. It is part of the net.Listener interface.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	server, client := net.Pipe()
+	newSession(c, client, l.store, l.config).start()
+
+	return &pipeConn{
+		Conn:   server,
+		client: c,
+	}, nil
+}
+
+// pipeConn is the end of the pipe to a session that is given to imap-server.
+// It reports the addresses of the client connection.
+type pipeConn struct {
+	net.Conn
+	client net.Conn
+}
+
+// LocalAddr returns the local address of the client connection.
+func (c *pipeConn) LocalAddr() net.Addr {
+	return c.client.LocalAddr()
+}
+
+// RemoteAddr returns the address of the client.
+func (c *pipeConn) RemoteAddr() net.Addr {
+	return c.client.RemoteAddr()
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+// mailboxNames returns the values of the arguments if there are n of them
+// and none is a list.
+func mailboxNames(args []argument, n int) ([]string, bool) {
+	if len(args) != n {
+		return nil, false
+	}
+
+	names := make([]string, n)
+	for i, arg := range args {
+		if arg.isList {
+			return nil, false
+		}
+		names[i] = arg.value
+	}
+
+	return names, true
+}
+
+// cmdCreate creates a mailbox. Superior mailboxes in the hierarchy are
+// created as needed.
+func cmdCreate(s *session, tag string, args []argument) error {
+	names, ok := mailboxNames(args, 1)
+	if !ok {
+		return s.respond(tag, "BAD", "Expected a mailbox name")
+	}
+
+	if _, err := s.store.NewMailbox(names[0]); err != nil {
+		return s.respond(tag, "NO", err.Error())
+	}
+
+	return s.respond(tag, "OK", "CREATE completed")
+}
+
+// cmdRename renames a mailbox along with the mailboxes below it in the
+// hierarchy.
+func cmdRename(s *session, tag string, args []argument) error {
+	names, ok := mailboxNames(args, 2)
+	if !ok {
+		return s.respond(tag, "BAD", "Expected two mailbox names")
+	}
+
+	if err := s.store.RenameMailbox(names[0], names[1]); err != nil {
+		return s.respond(tag, "NO", err.Error())
+	}
+
+	s.mtx.Lock()
+	if s.selected == names[0] {
+		s.selected = names[1]
+	}
+	s.mtx.Unlock()
+
+	return s.respond(tag, "OK", "RENAME completed")
+}
+
+// cmdDelete deletes a mailbox.
+func cmdDelete(s *session, tag string, args []argument) error {
+	names, ok := mailboxNames(args, 1)
+	if !ok {
+		return s.respond(tag, "BAD", "Expected a mailbox name")
+	}
+
+	if err := s.store.DeleteMailbox(names[0]); err != nil {
+		return s.respond(tag, "NO", err.Error())
+	}
+
+	s.mtx.Lock()
+	if s.selected == names[0] {
+		s.selected = ""
+	}
+	s.mtx.Unlock()
+
+	return s.respond(tag, "OK", "DELETE completed")
+}
@@ -0,0 +1,221 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxLiteral is the size of the largest literal that is accepted in a
+// command which is handled by a session.
+const maxLiteral = 1 << 16
+
+// literalRegex matches the announcement of a literal at the end of a line.
+var literalRegex = regexp.MustCompile(`\{([0-9]+)(\+?)\}\r?\n?$`)
+
+// syntaxError is returned when a command cannot be parsed.
+type syntaxError string
+
+// Error returns the error message. It is part of the error interface.
+func (e syntaxError) Error() string {
+	return string(e)
+}
+
+var (
+	errUnterminatedList   = syntaxError("Missing ')'")
+	errUnexpectedParen    = syntaxError("Unexpected ')'")
+	errUnterminatedString = syntaxError("Missing '\"'")
+	errBadLiteral         = syntaxError("Invalid literal")
+)
+
+// literalSize returns the size of the literal which is announced at the end
+// of the line, if there is one, and whether it is non-synchronizing.
+func literalSize(line string) (n int64, nonSync bool, ok bool) {
+	m := literalRegex.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false, false
+	}
+
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false, false
+	}
+
+	return n, m[2] == "+", true
+}
+
+// parseCommand splits the first line of a command into its tag, its name in
+// upper case and the rest of the line. UID commands are named as "UID"
+// followed by the name of the command.
+func parseCommand(line string) (tag, name, rest string) {
+	fields := strings.SplitN(strings.TrimRight(line, "\r\n"), " ", 3)
+	if len(fields) < 2 {
+		return "", "", ""
+	}
+	tag, name = fields[0], strings.ToUpper(fields[1])
+	if len(fields) == 3 {
+		rest = fields[2]
+	}
+
+	if name == "UID" {
+		fields = strings.SplitN(rest, " ", 2)
+		name = name + " " + strings.ToUpper(fields[0])
+		rest = ""
+		if len(fields) == 2 {
+			rest = fields[1]
+		}
+	}
+
+	return tag, name, rest
+}
+
+// argument is an argument of a command. It is either a string, which may
+// have been given as an atom, a quoted string or a literal, or a
+// parenthesized list.
+type argument struct {
+	value  string
+	list   []argument
+	isList bool
+}
+
+// scanner splits the arguments of a command. A command is continued on the
+// next line after every literal.
+type scanner struct {
+	r    *bufio.Reader
+	line string
+
+	// ready is called before a synchronizing literal is read. It should
+	// tell the client to go ahead and send the literal.
+	ready func() error
+}
+
+// arguments returns the arguments of the command.
+func (sc *scanner) arguments() ([]argument, error) {
+	return sc.list(false)
+}
+
+// list reads arguments until the end of the command, or until the end of a
+// parenthesized list if nested is true.
+func (sc *scanner) list(nested bool) ([]argument, error) {
+	var args []argument
+	for {
+		sc.line = strings.TrimLeft(sc.line, " ")
+		if sc.line == "" {
+			if nested {
+				return nil, errUnterminatedList
+			}
+			return args, nil
+		}
+
+		switch sc.line[0] {
+		case '(':
+			sc.line = sc.line[1:]
+			list, err := sc.list(true)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, argument{list: list, isList: true})
+
+		case ')':
+			if !nested {
+				return nil, errUnexpectedParen
+			}
+			sc.line = sc.line[1:]
+			return args, nil
+
+		case '"':
+			s, err := sc.quoted()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, argument{value: s})
+
+		case '{':
+			s, err := sc.literal()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, argument{value: s})
+
+		default:
+			args = append(args, argument{value: sc.atom()})
+		}
+	}
+}
+
+// quoted reads a quoted string.
+func (sc *scanner) quoted() (string, error) {
+	var s []byte
+	for i := 1; i < len(sc.line); i++ {
+		switch c := sc.line[i]; c {
+		case '"':
+			sc.line = sc.line[i+1:]
+			return string(s), nil
+		case '\\':
+			i++
+			if i == len(sc.line) {
+				return "", errUnterminatedString
+			}
+			s = append(s, sc.line[i])
+		default:
+			s = append(s, c)
+		}
+	}
+
+	return "", errUnterminatedString
+}
+
+// literal reads a literal and the line which follows it.
+func (sc *scanner) literal() (string, error) {
+	n, nonSync, ok := literalSize(sc.line)
+	if !ok || literalRegex.FindStringIndex(sc.line)[0] != 0 {
+		return "", errBadLiteral
+	}
+
+	// A literal which is too big is refused before the client sends it.
+	// There is no way to refuse one that doesn't wait.
+	if n > maxLiteral {
+		if nonSync {
+			return "", io.ErrShortBuffer
+		}
+		return "", errBadLiteral
+	}
+
+	if !nonSync && sc.ready != nil {
+		if err := sc.ready(); err != nil {
+			return "", err
+		}
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(sc.r, b); err != nil {
+		return "", err
+	}
+
+	line, err := sc.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	sc.line = strings.TrimRight(line, "\r\n")
+
+	return string(b), nil
+}
+
+// atom reads an atom, or any other string of characters which ends at a
+// space or a parenthesis.
+func (sc *scanner) atom() string {
+	i := strings.IndexAny(sc.line, " ()")
+	if i < 0 {
+		i = len(sc.line)
+	}
+
+	s := sc.line[:i]
+	sc.line = sc.line[i:]
+	return s
+}
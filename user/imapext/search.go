@@ -0,0 +1,344 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+// dateFormat is the format of dates in search keys.
+const dateFormat = "2-Jan-2006"
+
+var (
+	errNoSearchKey        = syntaxError("Expected a search key")
+	errMissingArgument    = syntaxError("Missing argument to search key")
+	errUnsupportedKey     = syntaxError("Unsupported search key")
+	errBadDate            = syntaxError("Invalid date")
+	errBadSequenceSet     = syntaxError("Invalid sequence set")
+	errUnsupportedCharset = syntaxError("[BADCHARSET (US-ASCII UTF-8)] Unsupported charset")
+)
+
+// sequenceSetRegex matches the search keys which are sequence sets.
+var sequenceSetRegex = regexp.MustCompile(`^[0-9*][0-9*:,]*$`)
+
+// flagKey is a search key which matches messages by a flag.
+type flagKey struct {
+	flag types.Flags
+
+	// set is whether the flag must be set or not set.
+	set bool
+}
+
+// flagKeys are the search keys which match messages by their flags.
+var flagKeys = map[string]flagKey{
+	"ANSWERED":   {types.FlagAnswered, true},
+	"UNANSWERED": {types.FlagAnswered, false},
+	"DELETED":    {types.FlagDeleted, true},
+	"UNDELETED":  {types.FlagDeleted, false},
+	"DRAFT":      {types.FlagDraft, true},
+	"UNDRAFT":    {types.FlagDraft, false},
+	"FLAGGED":    {types.FlagFlagged, true},
+	"UNFLAGGED":  {types.FlagFlagged, false},
+	"SEEN":       {types.FlagSeen, true},
+	"UNSEEN":     {types.FlagSeen, false},
+	"RECENT":     {types.FlagRecent, true},
+	"OLD":        {types.FlagRecent, false},
+}
+
+// searcher evaluates the keys of a SEARCH command. Every key is evaluated
+// with the Search method of the mailbox, and the results are combined
+// according to NOT, OR and the lists of keys.
+type searcher struct {
+	box email.Mailbox
+
+	// all are the uids of every message in the mailbox, in the order of
+	// their sequence numbers.
+	all []uint64
+}
+
+// search returns the uids of the messages which match the criteria.
+func (sr *searcher) search(criteria *email.SearchCriteria) []uint64 {
+	return intersect(sr.all, sr.box.Search(criteria))
+}
+
+// keys returns the uids of the messages which match all of the keys.
+func (sr *searcher) keys(args []argument) ([]uint64, error) {
+	if len(args) == 0 {
+		return nil, errNoSearchKey
+	}
+
+	uids := sr.all
+	for len(args) > 0 {
+		var matched []uint64
+		var err error
+		matched, args, err = sr.key(args)
+		if err != nil {
+			return nil, err
+		}
+
+		uids = intersect(uids, matched)
+	}
+
+	return uids, nil
+}
+
+// key returns the uids of the messages which match the first key in args,
+// along with the arguments after the key.
+func (sr *searcher) key(args []argument) ([]uint64, []argument, error) {
+	arg, args := args[0], args[1:]
+	if arg.isList {
+		uids, err := sr.keys(arg.list)
+		return uids, args, err
+	}
+
+	name := strings.ToUpper(arg.value)
+	if f, ok := flagKeys[name]; ok {
+		criteria := &email.SearchCriteria{}
+		if f.set {
+			criteria.WithFlags = f.flag
+		} else {
+			criteria.WithoutFlags = f.flag
+		}
+		return sr.search(criteria), args, nil
+	}
+
+	if sequenceSetRegex.MatchString(name) {
+		set, err := types.InterpretSequenceSet(name)
+		if err != nil {
+			return nil, nil, errBadSequenceSet
+		}
+
+		var uids []uint64
+		last := uint64(len(sr.all))
+		for i, uid := range sr.all {
+			if email.InSequenceSet(uint64(i+1), last, set) {
+				uids = append(uids, uid)
+			}
+		}
+		return uids, args, nil
+	}
+
+	switch name {
+	case "ALL":
+		return sr.all, args, nil
+
+	case "NEW":
+		return sr.search(&email.SearchCriteria{
+			WithFlags:    types.FlagRecent,
+			WithoutFlags: types.FlagSeen,
+		}), args, nil
+
+	case "NOT":
+		if len(args) == 0 {
+			return nil, nil, errMissingArgument
+		}
+		uids, args, err := sr.key(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		return difference(sr.all, uids), args, nil
+
+	case "OR":
+		if len(args) == 0 {
+			return nil, nil, errMissingArgument
+		}
+		a, args, err := sr.key(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(args) == 0 {
+			return nil, nil, errMissingArgument
+		}
+		b, args, err := sr.key(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		return union(a, b), args, nil
+
+	case "FROM", "TO", "SUBJECT", "BODY", "TEXT", "KEYWORD", "UNKEYWORD",
+		"SINCE", "BEFORE", "ON", "UID":
+		// These take an argument, which is read below.
+	default:
+		return nil, nil, errUnsupportedKey
+	}
+
+	// The rest of the keys take an argument.
+	if len(args) == 0 || args[0].isList {
+		return nil, nil, errMissingArgument
+	}
+	value, args := args[0].value, args[1:]
+
+	criteria := &email.SearchCriteria{}
+	switch name {
+	case "FROM":
+		criteria.From = []string{value}
+	case "TO":
+		criteria.To = []string{value}
+	case "SUBJECT":
+		criteria.Subject = []string{value}
+	case "BODY":
+		criteria.Body = []string{value}
+	case "TEXT":
+		criteria.Text = []string{value}
+	case "KEYWORD":
+		criteria.Keyword = []string{value}
+	case "UNKEYWORD":
+		criteria.Unkeyword = []string{value}
+
+	case "SINCE", "BEFORE", "ON":
+		date, err := time.Parse(dateFormat, value)
+		if err != nil {
+			return nil, nil, errBadDate
+		}
+
+		switch name {
+		case "SINCE":
+			criteria.Since = date
+		case "BEFORE":
+			criteria.Before = date
+		case "ON":
+			criteria.Since = date
+			criteria.Before = date.AddDate(0, 0, 1)
+		}
+
+	case "UID":
+		set, err := types.InterpretSequenceSet(value)
+		if err != nil {
+			return nil, nil, errBadSequenceSet
+		}
+		criteria.UIDs = set
+	}
+
+	return sr.search(criteria), args, nil
+}
+
+// intersect returns the uids which are in both a and b. Both must be sorted.
+func intersect(a, b []uint64) []uint64 {
+	var uids []uint64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			uids = append(uids, a[i])
+			i++
+			j++
+		}
+	}
+	return uids
+}
+
+// union returns the uids which are in either a or b. Both must be sorted.
+func union(a, b []uint64) []uint64 {
+	var uids []uint64
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			uids = append(uids, a[i])
+			i++
+		case a[i] > b[j]:
+			uids = append(uids, b[j])
+			j++
+		default:
+			uids = append(uids, a[i])
+			i++
+			j++
+		}
+	}
+	uids = append(uids, a[i:]...)
+	return append(uids, b[j:]...)
+}
+
+// difference returns the uids in a which are not in b. Both must be sorted.
+func difference(a, b []uint64) []uint64 {
+	var uids []uint64
+	j := 0
+	for _, uid := range a {
+		for j < len(b) && b[j] < uid {
+			j++
+		}
+		if j < len(b) && b[j] == uid {
+			continue
+		}
+		uids = append(uids, uid)
+	}
+	return uids
+}
+
+// search handles SEARCH and UID SEARCH.
+func search(s *session, tag string, args []argument, uid bool) error {
+	s.mtx.Lock()
+	selected := s.selected
+	s.mtx.Unlock()
+
+	box, err := s.mailbox(selected)
+	if err != nil {
+		return s.respond(tag, "NO", err.Error())
+	}
+
+	if len(args) > 0 && !args[0].isList && strings.EqualFold(args[0].value, "CHARSET") {
+		if len(args) < 2 || args[1].isList {
+			return s.respond(tag, "BAD", errMissingArgument.Error())
+		}
+
+		// Everything is compared as UTF-8, of which US-ASCII is a subset.
+		charset := strings.ToUpper(args[1].value)
+		if charset != "UTF-8" && charset != "US-ASCII" {
+			return s.respond(tag, "NO", errUnsupportedCharset.Error())
+		}
+		args = args[2:]
+	}
+
+	sr := &searcher{
+		box: box,
+		all: box.Search(&email.SearchCriteria{}),
+	}
+	uids, err := sr.keys(args)
+	if err != nil {
+		return s.respond(tag, "BAD", err.Error())
+	}
+
+	line := "* SEARCH"
+	if uid {
+		for _, u := range uids {
+			line += " " + strconv.FormatUint(u, 10)
+		}
+	} else {
+		// The uids are in the same order as in all.
+		j := 0
+		for i, u := range sr.all {
+			if j < len(uids) && uids[j] == u {
+				line += " " + strconv.Itoa(i+1)
+				j++
+			}
+		}
+	}
+
+	if err := s.writeLine(line); err != nil {
+		return err
+	}
+	return s.respond(tag, "OK", "SEARCH completed")
+}
+
+// cmdSearch returns the sequence numbers of the messages in the selected
+// mailbox which match the search keys.
+func cmdSearch(s *session, tag string, args []argument) error {
+	return search(s, tag, args, false)
+}
+
+// cmdUIDSearch returns the uids of the messages in the selected mailbox
+// which match the search keys.
+func cmdUIDSearch(s *session, tag string, args []argument) error {
+	return search(s, tag, args, true)
+}
@@ -0,0 +1,469 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+// state is a state of an IMAP session that a command requires.
+type state int
+
+const (
+	// stateNotAuthenticated is the state before the client has logged in.
+	stateNotAuthenticated state = iota
+
+	// stateAuthenticated is the state after the client has logged in.
+	stateAuthenticated
+
+	// stateSelected is the state after the client has selected a mailbox.
+	stateSelected
+)
+
+// command is a command which is handled by a session rather than by
+// imap-server.
+type command struct {
+	// state is the state that the session must be in.
+	state state
+
+	// handle executes the command and writes the response.
+	handle func(s *session, tag string, args []argument) error
+}
+
+// commands are the commands which are handled by a session, by name.
+var commands = map[string]*command{
+	"STARTTLS": {stateNotAuthenticated, cmdStartTLS},
+
+	"CREATE": {stateAuthenticated, cmdCreate},
+	"RENAME": {stateAuthenticated, cmdRename},
+	"DELETE": {stateAuthenticated, cmdDelete},
+	"IDLE":   {stateAuthenticated, cmdIdle},
+
+	"SEARCH":     {stateSelected, cmdSearch},
+	"UID SEARCH": {stateSelected, cmdUIDSearch},
+}
+
+var (
+	// errClosed is returned when the session has been closed.
+	errClosed = errors.New("Session closed")
+
+	// errNotExtended is returned when a mailbox does not support the
+	// commands that are handled by a session.
+	errNotExtended = errors.New("Mailbox does not support this command")
+)
+
+// pending is a command that has been passed to imap-server which has not
+// been answered yet.
+type pending struct {
+	tag  string
+	name string
+
+	// mailbox is the mailbox given to SELECT or EXAMINE.
+	mailbox string
+
+	// permanentFlags is whether the PERMANENTFLAGS of the mailbox have
+	// been sent in answer to SELECT.
+	permanentFlags bool
+}
+
+// session handles a connection to an IMAP client. It reads the commands of
+// the client and either handles them or passes them on to imap-server, and
+// it passes the responses of imap-server back to the client.
+type session struct {
+	store     Mailstore
+	tlsConfig *tls.Config
+
+	// conn is the connection to the client. client is the connection
+	// through which the session talks to it, which is replaced once TLS
+	// has been started. It may only be used with writeMtx held, except by
+	// the command which starts TLS.
+	conn    net.Conn
+	client  net.Conn
+	clientR *bufio.Reader
+	server  net.Conn
+	serverR *bufio.Reader
+
+	// writeMtx must be held while writing to the client.
+	writeMtx sync.Mutex
+
+	// The rest of the session is protected by mtx. cond is signaled when a
+	// pending command has been answered or the session is closed.
+	mtx           sync.Mutex
+	cond          *sync.Cond
+	pending       []*pending
+	authenticated bool
+	selected      string
+	tls           bool
+	closed        bool
+
+	closeOnce sync.Once
+}
+
+// newSession creates a session for a client of imap-server. server is the
+// connection to imap-server. If config is not nil, the client must start TLS
+// before it logs in.
+func newSession(client, server net.Conn, store Mailstore, config *tls.Config) *session {
+	s := &session{
+		store:     store,
+		tlsConfig: config,
+		conn:      client,
+		client:    client,
+		clientR:   bufio.NewReader(client),
+		server:    server,
+		serverR:   bufio.NewReader(server),
+	}
+	s.cond = sync.NewCond(&s.mtx)
+	return s
+}
+
+// start starts passing data between the client and imap-server.
+func (s *session) start() {
+	go s.clientHandler()
+	go s.serverHandler()
+}
+
+// close closes both connections of the session.
+func (s *session) close() {
+	s.closeOnce.Do(func() {
+		s.conn.Close()
+		s.server.Close()
+
+		s.mtx.Lock()
+		s.closed = true
+		s.cond.Broadcast()
+		s.mtx.Unlock()
+	})
+}
+
+// write writes to the client.
+func (s *session) write(line string) error {
+	s.writeMtx.Lock()
+	defer s.writeMtx.Unlock()
+
+	_, err := io.WriteString(s.client, line)
+	return err
+}
+
+// writeLine writes a line to the client.
+func (s *session) writeLine(format string, args ...interface{}) error {
+	return s.write(fmt.Sprintf(format, args...) + "\r\n")
+}
+
+// respond writes the response which completes a command.
+func (s *session) respond(tag, status, text string) error {
+	return s.writeLine("%s %s %s", tag, status, text)
+}
+
+// mailbox returns the mailbox of the user with the given name.
+func (s *session) mailbox(name string) (email.Mailbox, error) {
+	mbox, err := s.store.MailboxByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	box, ok := mbox.(email.Mailbox)
+	if !ok {
+		return nil, errNotExtended
+	}
+	return box, nil
+}
+
+// clientHandler reads the commands of the client until the connection is
+// closed.
+func (s *session) clientHandler() {
+	defer s.close()
+
+	for {
+		line, err := s.clientR.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		tag, name, rest := parseCommand(line)
+		if cmd, ok := commands[name]; ok {
+			if err := s.execute(cmd, tag, rest); err != nil {
+				email.IMAPLog.Debugf("Closing session with %s: %v", s.conn.RemoteAddr(), err)
+				return
+			}
+			continue
+		}
+
+		// imap-server must not see the password before TLS is started.
+		if (name == "LOGIN" || name == "AUTHENTICATE") && !s.secure() {
+			if !s.wait() {
+				return
+			}
+			if err := s.respond(tag, "NO", "[PRIVACYREQUIRED] Use STARTTLS first"); err != nil {
+				return
+			}
+			continue
+		}
+
+		if err := s.forward(tag, name, line); err != nil {
+			return
+		}
+	}
+}
+
+// wait waits until imap-server has answered every command which was passed
+// to it. It returns false if the session was closed.
+func (s *session) wait() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for len(s.pending) > 0 && !s.closed {
+		s.cond.Wait()
+	}
+
+	return !s.closed
+}
+
+// execute reads the arguments of a command which is handled by the session
+// and executes it. Only errors that end the session are returned.
+func (s *session) execute(cmd *command, tag, rest string) error {
+	// The commands before this one may change the state of the session
+	// and must be answered first.
+	if !s.wait() {
+		return errClosed
+	}
+
+	sc := &scanner{
+		r:    s.clientR,
+		line: rest,
+		ready: func() error {
+			return s.writeLine("+ Ready for literal data")
+		},
+	}
+	args, err := sc.arguments()
+	if err != nil {
+		if _, ok := err.(syntaxError); ok {
+			return s.respond(tag, "BAD", err.Error())
+		}
+		return err
+	}
+
+	s.mtx.Lock()
+	var ok bool
+	switch cmd.state {
+	case stateNotAuthenticated:
+		ok = !s.authenticated
+	case stateAuthenticated:
+		ok = s.authenticated
+	case stateSelected:
+		ok = s.authenticated && s.selected != ""
+	}
+	s.mtx.Unlock()
+	if !ok {
+		return s.respond(tag, "BAD", "Command not valid in this state")
+	}
+
+	return cmd.handle(s, tag, args)
+}
+
+// forward passes a command to imap-server along with any literals and lines
+// which follow it. The command is pending until imap-server answers it.
+func (s *session) forward(tag, name, line string) error {
+	p := &pending{
+		tag:  tag,
+		name: name,
+	}
+	if name != "" {
+		s.mtx.Lock()
+		s.pending = append(s.pending, p)
+		s.mtx.Unlock()
+	}
+
+	// The mailbox given to SELECT or EXAMINE is needed once it succeeds.
+	var command *bytes.Buffer
+	if name == "SELECT" || name == "EXAMINE" {
+		command = &bytes.Buffer{}
+	}
+
+	for {
+		n, _, ok := literalSize(line)
+		if command != nil {
+			command.WriteString(line)
+		}
+
+		// The mailbox must be known before imap-server can answer.
+		if !ok && command != nil {
+			mailbox := selectedMailbox(command)
+			s.mtx.Lock()
+			p.mailbox = mailbox
+			s.mtx.Unlock()
+		}
+
+		if _, err := io.WriteString(s.server, line); err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		var w io.Writer = s.server
+		if command != nil {
+			w = io.MultiWriter(s.server, command)
+		}
+		if _, err := io.CopyN(w, s.clientR, n); err != nil {
+			return err
+		}
+
+		var err error
+		line, err = s.clientR.ReadString('\n')
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// selectedMailbox returns the mailbox named in a SELECT or EXAMINE command.
+func selectedMailbox(command *bytes.Buffer) string {
+	r := bufio.NewReader(command)
+	line, _ := r.ReadString('\n')
+	_, _, rest := parseCommand(line)
+
+	sc := &scanner{
+		r:    r,
+		line: rest,
+	}
+	args, err := sc.arguments()
+	if err != nil || len(args) == 0 || args[0].isList {
+		return ""
+	}
+
+	return args[0].value
+}
+
+// serverHandler passes the responses of imap-server to the client until the
+// connection is closed.
+func (s *session) serverHandler() {
+	defer s.close()
+
+	// start is whether the next line begins a response.
+	start := true
+	for {
+		line, err := s.serverR.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		var p *pending
+		var ok bool
+		if start {
+			line, p, ok = s.response(line)
+		}
+
+		if err := s.write(line); err != nil {
+			return
+		}
+
+		// Literals are passed through untouched.
+		if n, _, isLiteral := literalSize(line); isLiteral {
+			s.writeMtx.Lock()
+			_, err := io.CopyN(s.client, s.serverR, n)
+			s.writeMtx.Unlock()
+			if err != nil {
+				return
+			}
+
+			start = false
+			continue
+		}
+		start = true
+
+		if p == nil {
+			continue
+		}
+		s.answered(p, ok)
+	}
+}
+
+// response reads the first line of a response from imap-server and returns
+// it as it should be passed to the client. If the response answers a pending
+// command, the command is returned along with whether it succeeded.
+func (s *session) response(line string) (string, *pending, bool) {
+	line = addCapabilities(line, s.capabilities())
+
+	fields := strings.SplitN(strings.TrimRight(line, "\r\n"), " ", 3)
+	var status string
+	if len(fields) > 1 {
+		status = strings.ToUpper(fields[1])
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch fields[0] {
+	case "+":
+		return line, nil, false
+	case "*":
+		// An untagged BAD is the answer to a command that imap-server
+		// could not read.
+		if status == "BAD" && len(s.pending) > 0 {
+			return line, s.pending[0], false
+		}
+
+		// Untagged responses belong to the first pending command.
+		if len(s.pending) > 0 {
+			line = s.replacePermanentFlags(s.pending[0], line)
+		}
+		return line, nil, false
+	}
+
+	for _, p := range s.pending {
+		if p.tag == fields[0] {
+			ok := status == "OK"
+			if ok {
+				line = s.missingPermanentFlags(p) + line
+			}
+			return line, p, ok
+		}
+	}
+
+	return line, nil, false
+}
+
+// answered updates the state of the session after imap-server has answered
+// a command.
+func (s *session) answered(p *pending, ok bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for i, q := range s.pending {
+		if q == p {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			break
+		}
+	}
+
+	switch p.name {
+	case "LOGIN", "AUTHENTICATE":
+		if ok {
+			s.authenticated = true
+		}
+	case "SELECT", "EXAMINE":
+		// A mailbox is no longer selected if SELECT fails.
+		s.selected = ""
+		if ok {
+			s.selected = p.mailbox
+		}
+	case "CLOSE", "UNSELECT":
+		if ok {
+			s.selected = ""
+		}
+	}
+
+	s.cond.Broadcast()
+}
@@ -0,0 +1,618 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/mailstore"
+	"github.com/jordwest/imap-server/types"
+)
+
+var (
+	errTestExists    = errors.New("Mailbox already exists")
+	errTestNotFound  = errors.New("Mailbox not found")
+	errTestProtected = errors.New("Mailbox cannot be renamed or deleted")
+)
+
+// testMailbox is a mailbox of a testStore. Only the methods which are used by
+// sessions are implemented.
+type testMailbox struct {
+	email.Mailbox
+	name     string
+	updates  chan *email.MailboxUpdate
+	messages []testMessage
+	flags    []string
+
+	mtx      sync.Mutex
+	watching bool
+}
+
+func newTestMailbox(name string) *testMailbox {
+	return &testMailbox{
+		name:    name,
+		updates: make(chan *email.MailboxUpdate, 1),
+		flags:   []string{`\Deleted`, `\Seen`, `\*`},
+	}
+}
+
+func (mb *testMailbox) PermanentFlags() []string {
+	return mb.flags
+}
+
+func (mb *testMailbox) Watch() (<-chan *email.MailboxUpdate, func()) {
+	mb.mtx.Lock()
+	defer mb.mtx.Unlock()
+
+	mb.watching = true
+	return mb.updates, func() {
+		mb.mtx.Lock()
+		defer mb.mtx.Unlock()
+
+		mb.watching = false
+	}
+}
+
+func (mb *testMailbox) isWatched() bool {
+	mb.mtx.Lock()
+	defer mb.mtx.Unlock()
+
+	return mb.watching
+}
+
+// testMessage is a message in a testMailbox.
+type testMessage struct {
+	uid   uint64
+	from  string
+	flags types.Flags
+}
+
+// Search supports the criteria From, WithFlags, WithoutFlags and UIDs.
+func (mb *testMailbox) Search(criteria *email.SearchCriteria) []uint64 {
+	var last uint64
+	if len(mb.messages) > 0 {
+		last = mb.messages[len(mb.messages)-1].uid
+	}
+
+	var uids []uint64
+	for _, m := range mb.messages {
+		if len(criteria.From) > 0 && !strings.Contains(m.from, criteria.From[0]) {
+			continue
+		}
+		if !m.flags.HasFlags(criteria.WithFlags) || m.flags&criteria.WithoutFlags != 0 {
+			continue
+		}
+		if criteria.UIDs != nil && !email.InSequenceSet(m.uid, last, criteria.UIDs) {
+			continue
+		}
+		uids = append(uids, m.uid)
+	}
+	return uids
+}
+
+// testStore is a Mailstore which keeps track of the names of its mailboxes.
+type testStore struct {
+	mtx   sync.Mutex
+	boxes map[string]*testMailbox
+}
+
+func newTestStore(names ...string) *testStore {
+	ts := &testStore{boxes: make(map[string]*testMailbox)}
+	for _, name := range names {
+		ts.boxes[name] = newTestMailbox(name)
+	}
+	return ts
+}
+
+func (ts *testStore) MailboxByName(name string) (mailstore.Mailbox, error) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	mbox, ok := ts.boxes[name]
+	if !ok {
+		return nil, errTestNotFound
+	}
+	return mbox, nil
+}
+
+func (ts *testStore) NewMailbox(name string) (email.Mailbox, error) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if _, ok := ts.boxes[name]; ok {
+		return nil, errTestExists
+	}
+	mbox := newTestMailbox(name)
+	ts.boxes[name] = mbox
+	return mbox, nil
+}
+
+func (ts *testStore) RenameMailbox(name, newname string) error {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if name == "INBOX" {
+		return errTestProtected
+	}
+	mbox, ok := ts.boxes[name]
+	if !ok {
+		return errTestNotFound
+	}
+	if _, ok := ts.boxes[newname]; ok {
+		return errTestExists
+	}
+	delete(ts.boxes, name)
+	mbox.name = newname
+	ts.boxes[newname] = mbox
+	return nil
+}
+
+func (ts *testStore) DeleteMailbox(name string) error {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if name == "INBOX" {
+		return errTestProtected
+	}
+	if _, ok := ts.boxes[name]; !ok {
+		return errTestNotFound
+	}
+	delete(ts.boxes, name)
+	return nil
+}
+
+func (ts *testStore) has(name string) bool {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	_, ok := ts.boxes[name]
+	return ok
+}
+
+// testServer answers a few commands the way that imap-server does.
+func testServer(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	io.WriteString(conn, "* OK IMAP4rev1 Service Ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		var responses []string
+		tag, name, rest := parseCommand(line)
+		switch name {
+		case "LOGIN":
+			responses = []string{tag + " OK LOGIN completed"}
+		case "CAPABILITY":
+			responses = []string{
+				"* CAPABILITY IMAP4rev1 AUTH=PLAIN",
+				tag + " OK CAPABILITY completed",
+			}
+		case "SELECT":
+			responses = []string{"* 2 EXISTS", "* 0 RECENT"}
+			if rest != "Archive" {
+				responses = append(responses, `* OK [PERMANENTFLAGS (\Deleted \Seen \*)] Limited`)
+			}
+			responses = append(responses, tag+" OK [READ-WRITE] SELECT completed")
+		case "EXAMINE":
+			responses = []string{
+				"* 2 EXISTS",
+				"* 0 RECENT",
+				"* OK [PERMANENTFLAGS ()] Read-only",
+				tag + " OK [READ-ONLY] EXAMINE completed",
+			}
+		case "NOOP":
+			// Answer slowly so that commands which are handled by the
+			// session have to wait.
+			time.Sleep(20 * time.Millisecond)
+			responses = []string{tag + " OK NOOP completed"}
+		case "FETCH":
+			responses = []string{
+				"* 1 FETCH (BODY[] {9}",
+				"a1 OK DONE)",
+				tag + " OK FETCH completed",
+			}
+		default:
+			responses = []string{tag + " BAD Command not understood"}
+		}
+
+		for _, response := range responses {
+			if _, err := io.WriteString(conn, response+"\r\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// testClient is an IMAP client connected to a Listener.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dial starts a Listener in front of a testServer and connects to it.
+func dial(t *testing.T, store Mailstore) (*testClient, func()) {
+	return dialTLS(t, store, nil)
+}
+
+// dialTLS is like dial, but the Listener requires TLS if config is not nil.
+func dialTLS(t *testing.T, store Mailstore, config *tls.Config) (*testClient, func()) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewListener(tcp, store, config)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go testServer(conn)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", tcp.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &testClient{
+		t:    t,
+		conn: conn,
+		r:    bufio.NewReader(conn),
+	}
+	c.expect("* OK IMAP4rev1 Service Ready")
+
+	return c, func() {
+		conn.Close()
+		l.Close()
+	}
+}
+
+func (c *testClient) send(lines ...string) {
+	if _, err := io.WriteString(c.conn, strings.Join(lines, "\r\n")+"\r\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) expect(lines ...string) {
+	for _, line := range lines {
+		c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		got, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("Expected %q, got error %v", line, err)
+		}
+		if got = strings.TrimRight(got, "\r\n"); got != line {
+			c.t.Fatalf("Expected %q, got %q", line, got)
+		}
+	}
+}
+
+func TestMailboxCommands(t *testing.T) {
+	store := newTestStore("INBOX")
+	c, done := dial(t, store)
+	defer done()
+
+	// The client must log in first.
+	c.send("a1 CREATE Work")
+	c.expect("a1 BAD Command not valid in this state")
+	c.send("a2 LOGIN user pass")
+	c.expect("a2 OK LOGIN completed")
+
+	c.send(`a3 CREATE "Work/Projects"`)
+	c.expect("a3 OK CREATE completed")
+	if !store.has("Work/Projects") {
+		t.Error("Mailbox was not created.")
+	}
+	c.send("a4 CREATE Work/Projects")
+	c.expect("a4 NO Mailbox already exists")
+	c.send("a5 CREATE")
+	c.expect("a5 BAD Expected a mailbox name")
+
+	// Mailbox names may be sent as literals.
+	c.send("a6 RENAME {13}")
+	c.expect("+ Ready for literal data")
+	c.send("Work/Projects Play")
+	c.expect("a6 OK RENAME completed")
+	if store.has("Work/Projects") || !store.has("Play") {
+		t.Error("Mailbox was not renamed.")
+	}
+
+	c.send("a7 DELETE INBOX")
+	c.expect("a7 NO Mailbox cannot be renamed or deleted")
+	c.send("a8 DELETE Play")
+	c.expect("a8 OK DELETE completed")
+	if store.has("Play") {
+		t.Error("Mailbox was not deleted.")
+	}
+
+	// Responses remain in order when commands are pipelined.
+	c.send("a9 NOOP", "a10 CREATE Archive", "a11 NOOP")
+	c.expect("a9 OK NOOP completed", "a10 OK CREATE completed", "a11 OK NOOP completed")
+
+	// Literals in responses are passed through untouched.
+	c.send("a12 FETCH 1 BODY[]")
+	c.expect("* 1 FETCH (BODY[] {9}", "a1 OK DONE)", "a12 OK FETCH completed")
+	c.send("a13 CREATE Other")
+	c.expect("a13 OK CREATE completed")
+}
+
+func TestIdle(t *testing.T) {
+	store := newTestStore("INBOX")
+	inbox := store.boxes["INBOX"]
+	c, done := dial(t, store)
+	defer done()
+
+	c.send("b1 CAPABILITY")
+	c.expect("* CAPABILITY IMAP4rev1 AUTH=PLAIN IDLE", "b1 OK CAPABILITY completed")
+
+	c.send("b2 LOGIN user pass")
+	c.expect("b2 OK LOGIN completed")
+
+	// Without a selected mailbox, the client only waits.
+	c.send("b3 IDLE")
+	c.expect("+ idling")
+	c.send("DONE")
+	c.expect("b3 OK IDLE terminated")
+
+	c.send(`b4 SELECT "INBOX"`)
+	c.expect("* 2 EXISTS", "* 0 RECENT",
+		`* OK [PERMANENTFLAGS (\Deleted \Seen \*)] Limited`,
+		"b4 OK [READ-WRITE] SELECT completed")
+
+	c.send("b5 IDLE")
+	c.expect("+ idling")
+	if !inbox.isWatched() {
+		t.Fatal("Inbox is not being watched.")
+	}
+	inbox.updates <- &email.MailboxUpdate{
+		Messages: 3,
+		Recent:   1,
+		Expunged: []uint32{2},
+	}
+	c.expect("* 2 EXPUNGE", "* 3 EXISTS", "* 1 RECENT")
+	c.send("done")
+	c.expect("b5 OK IDLE terminated")
+	if inbox.isWatched() {
+		t.Error("Inbox is still being watched after IDLE.")
+	}
+
+	c.send("b6 IDLE")
+	c.expect("+ idling")
+	c.send("b7 NOOP")
+	c.expect("b6 BAD Expected DONE")
+}
+
+func TestAddCapabilities(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"* CAPABILITY IMAP4rev1\r\n", "* CAPABILITY IMAP4rev1 IDLE\r\n"},
+		{"* CAPABILITY IMAP4rev1 IDLE\r\n", "* CAPABILITY IMAP4rev1 IDLE\r\n"},
+		{"* CAPABILITY IMAP4rev1 STARTTLS IDLE\r\n", "* CAPABILITY IMAP4rev1 IDLE\r\n"},
+		{"* OK [CAPABILITY IMAP4rev1 AUTH=PLAIN] Ready\r\n",
+			"* OK [CAPABILITY IMAP4rev1 AUTH=PLAIN IDLE] Ready\r\n"},
+		{"* OK Ready\r\n", "* OK Ready\r\n"},
+		{"* 1 FETCH (FLAGS (\\Seen))\r\n", "* 1 FETCH (FLAGS (\\Seen))\r\n"},
+	}
+
+	for i, test := range tests {
+		if got := addCapabilities(test.line, []string{"IDLE"}); got != test.expected {
+			t.Errorf("addCapabilities test case %d; got %q, expected %q.", i, got, test.expected)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	store := newTestStore("INBOX")
+	store.boxes["INBOX"].messages = []testMessage{
+		{10, "alice@bm.addr", types.FlagSeen},
+		{20, "bob@bm.addr", types.FlagRecent},
+		{30, "alice@bm.addr", types.FlagSeen | types.FlagFlagged},
+		{40, "carol@bm.addr", 0},
+	}
+	c, done := dial(t, store)
+	defer done()
+
+	c.send("c1 LOGIN user pass")
+	c.expect("c1 OK LOGIN completed")
+
+	// A mailbox must be selected.
+	c.send("c2 SEARCH ALL")
+	c.expect("c2 BAD Command not valid in this state")
+
+	c.send("c3 SELECT INBOX")
+	c.expect("* 2 EXISTS", "* 0 RECENT",
+		`* OK [PERMANENTFLAGS (\Deleted \Seen \*)] Limited`,
+		"c3 OK [READ-WRITE] SELECT completed")
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"SEARCH ALL", "* SEARCH 1 2 3 4"},
+		{"UID SEARCH ALL", "* SEARCH 10 20 30 40"},
+		{"SEARCH FROM alice", "* SEARCH 1 3"},
+		{"UID SEARCH FROM alice", "* SEARCH 10 30"},
+		{"SEARCH SEEN", "* SEARCH 1 3"},
+		{"SEARCH NOT SEEN", "* SEARCH 2 4"},
+		{"SEARCH UNSEEN FROM bob", "* SEARCH 2"},
+		{"SEARCH OR FROM bob FLAGGED", "* SEARCH 2 3"},
+		{"SEARCH (FROM alice SEEN) NOT FLAGGED", "* SEARCH 1"},
+		{"SEARCH NEW", "* SEARCH 2"},
+		{"SEARCH 2:*", "* SEARCH 2 3 4"},
+		{"UID SEARCH 1,4", "* SEARCH 10 40"},
+		{"SEARCH UID 25:*", "* SEARCH 3 4"},
+		{"UID SEARCH UID 20:30 NOT FROM bob", "* SEARCH 30"},
+		{"SEARCH CHARSET UTF-8 FROM carol", "* SEARCH 4"},
+		{`SEARCH FROM "nobody"`, "* SEARCH"},
+	}
+
+	for i, test := range tests {
+		tag := "t" + strconv.Itoa(i)
+		c.send(tag + " " + test.command)
+		c.expect(test.expected, tag+" OK SEARCH completed")
+	}
+
+	c.send("c4 SEARCH CHARSET KOI8-R ALL")
+	c.expect("c4 NO [BADCHARSET (US-ASCII UTF-8)] Unsupported charset")
+	c.send("c5 SEARCH LARGER 100")
+	c.expect("c5 BAD Unsupported search key")
+	c.send("c6 SEARCH OR SEEN")
+	c.expect("c6 BAD Missing argument to search key")
+	c.send("c7 SEARCH SINCE yesterday")
+	c.expect("c7 BAD Invalid date")
+	c.send("c8 SEARCH (SEEN")
+	c.expect("c8 BAD Missing ')'")
+}
+
+func TestPermanentFlags(t *testing.T) {
+	store := newTestStore("INBOX", "Archive")
+	store.boxes["INBOX"].flags = []string{`\Answered`, `\Seen`, "$Label1", `\*`}
+	c, done := dial(t, store)
+	defer done()
+
+	c.send("d1 LOGIN user pass")
+	c.expect("d1 OK LOGIN completed")
+
+	// The flags of the mailbox replace those sent by imap-server.
+	c.send("d2 SELECT INBOX")
+	c.expect("* 2 EXISTS", "* 0 RECENT",
+		`* OK [PERMANENTFLAGS (\Answered \Seen $Label1 \*)] Limited`,
+		"d2 OK [READ-WRITE] SELECT completed")
+
+	// They are added if imap-server doesn't send any.
+	c.send("d3 SELECT Archive")
+	c.expect("* 2 EXISTS", "* 0 RECENT",
+		`* OK [PERMANENTFLAGS (\Deleted \Seen \*)] Flags permitted`,
+		"d3 OK [READ-WRITE] SELECT completed")
+
+	// Nothing can be stored in a mailbox opened with EXAMINE.
+	c.send("d4 EXAMINE INBOX")
+	c.expect("* 2 EXISTS", "* 0 RECENT",
+		"* OK [PERMANENTFLAGS ()] Read-only",
+		"d4 OK [READ-ONLY] EXAMINE completed")
+}
+
+// testTLSConfig returns the configuration of a server with a self-signed
+// certificate for 127.0.0.1.
+func testTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"bmagent"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{der},
+			PrivateKey:  key,
+		}},
+	}
+}
+
+func TestStartTLS(t *testing.T) {
+	store := newTestStore("INBOX")
+	store.boxes["INBOX"].messages = []testMessage{
+		{10, "alice@bm.addr", types.FlagSeen},
+		{20, "bob@bm.addr", types.FlagRecent},
+	}
+	inbox := store.boxes["INBOX"]
+	c, done := dialTLS(t, store, testTLSConfig(t))
+	defer done()
+
+	c.send("e1 CAPABILITY")
+	c.expect("* CAPABILITY IMAP4rev1 AUTH=PLAIN IDLE STARTTLS LOGINDISABLED",
+		"e1 OK CAPABILITY completed")
+
+	// The client may not log in before TLS is started.
+	c.send("e2 LOGIN user pass")
+	c.expect("e2 NO [PRIVACYREQUIRED] Use STARTTLS first")
+
+	c.send("e3 STARTTLS")
+	c.expect("e3 OK Begin TLS negotiation now")
+	conn := tls.Client(c.conn, &tls.Config{InsecureSkipVerify: true})
+	if err := conn.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	c.conn = conn
+	c.r = bufio.NewReader(conn)
+
+	c.send("e4 CAPABILITY")
+	c.expect("* CAPABILITY IMAP4rev1 AUTH=PLAIN IDLE", "e4 OK CAPABILITY completed")
+	c.send("e5 STARTTLS")
+	c.expect("e5 BAD TLS is already active")
+	c.send("e6 LOGIN user pass")
+	c.expect("e6 OK LOGIN completed")
+	c.send("e7 STARTTLS")
+	c.expect("e7 BAD Command not valid in this state")
+
+	// The commands which are handled by the session work through TLS.
+	c.send("e8 CREATE Work")
+	c.expect("e8 OK CREATE completed")
+	c.send("e9 RENAME Work Play")
+	c.expect("e9 OK RENAME completed")
+	c.send("e10 DELETE Play")
+	c.expect("e10 OK DELETE completed")
+	if store.has("Work") || store.has("Play") {
+		t.Error("Mailboxes were not created, renamed and deleted.")
+	}
+
+	c.send("e11 SELECT INBOX")
+	c.expect("* 2 EXISTS", "* 0 RECENT",
+		`* OK [PERMANENTFLAGS (\Deleted \Seen \*)] Limited`,
+		"e11 OK [READ-WRITE] SELECT completed")
+	c.send("e12 SEARCH UNSEEN")
+	c.expect("* SEARCH 2", "e12 OK SEARCH completed")
+	c.send("e13 UID SEARCH ALL")
+	c.expect("* SEARCH 10 20", "e13 OK SEARCH completed")
+
+	c.send("e14 IDLE")
+	c.expect("+ idling")
+	for !inbox.isWatched() {
+		time.Sleep(time.Millisecond)
+	}
+	inbox.updates <- &email.MailboxUpdate{
+		Messages: 3,
+		Recent:   1,
+	}
+	c.expect("* 3 EXISTS", "* 1 RECENT")
+	c.send("DONE")
+	c.expect("e14 OK IDLE terminated")
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package imapext
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"io"
+	"time"
+)
+
+// handshakeTimeout is how long a client has to complete the TLS handshake
+// after STARTTLS.
+const handshakeTimeout = 30 * time.Second
+
+// errPipelinedTLS is returned when a client sends more after STARTTLS
+// before TLS has been started.
+var errPipelinedTLS = errors.New("Data received before the TLS handshake")
+
+// secure returns whether the client may log in, which it may do once TLS
+// has been started, or at any time if the session does not support TLS.
+func (s *session) secure() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.tlsConfig == nil || s.tls
+}
+
+// cmdStartTLS starts TLS with the client. From then on, the session reads
+// and writes through TLS while imap-server continues in plaintext on the
+// other side of the pipe.
+func cmdStartTLS(s *session, tag string, args []argument) error {
+	if len(args) != 0 {
+		return s.respond(tag, "BAD", "STARTTLS takes no arguments")
+	}
+
+	s.mtx.Lock()
+	active := s.tls
+	s.mtx.Unlock()
+	if s.tlsConfig == nil {
+		return s.respond(tag, "NO", "TLS is not available")
+	}
+	if active {
+		return s.respond(tag, "BAD", "TLS is already active")
+	}
+
+	// Anything that the client sent after STARTTLS was sent in plaintext
+	// and cannot be trusted.
+	if s.clientR.Buffered() > 0 {
+		return errPipelinedTLS
+	}
+
+	// Nothing else may be written to the client until the handshake is
+	// done.
+	s.writeMtx.Lock()
+	defer s.writeMtx.Unlock()
+
+	if _, err := io.WriteString(s.client, tag+" OK Begin TLS negotiation now\r\n"); err != nil {
+		return err
+	}
+
+	conn := tls.Server(s.client, s.tlsConfig)
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	s.client = conn
+	s.clientR = bufio.NewReader(conn)
+
+	s.mtx.Lock()
+	s.tls = true
+	s.mtx.Unlock()
+	return nil
+}
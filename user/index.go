@@ -0,0 +1,214 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/DanielKrawisz/bmagent/cmd"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/format"
+)
+
+// maxTermLength is the length of the longest term that is put in the
+// full-text index. Anything longer is unlikely to be searched for.
+const maxTermLength = 64
+
+// ErrNoIndex is returned when a full-text search is attempted on folders
+// which do not have a full-text index.
+var ErrNoIndex = errors.New("Full-text search is not available")
+
+// tokenize splits text into lower case terms made of letters and numbers.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// indexTerms returns the terms of a message which are put in the full-text
+// index.
+func indexTerms(bm *email.Bmail) data.Terms {
+	terms := make(data.Terms)
+	add := func(text string) {
+		for _, term := range tokenize(text) {
+			if len(term) <= maxTermLength {
+				terms[term]++
+			}
+		}
+	}
+
+	add(bm.From)
+	add(bm.To)
+
+	switch c := bm.Content.(type) {
+	case *format.Encoding1:
+		add(c.Body)
+	case *format.Encoding2:
+		add(c.Subject)
+		add(c.Body)
+	case *email.Extended:
+		add(c.Subject)
+		add(c.Body)
+		for _, part := range c.Parts {
+			add(part.Name)
+		}
+	}
+
+	return terms
+}
+
+// queryTerms splits a search query into distinct terms.
+func queryTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]struct{})
+	for _, term := range tokenize(query) {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// insertNewMessage inserts a message in the folder of the mailbox with a new
+// uid, which is returned. The message is put in the full-text index if the
+// folder has one.
+func (box *mailbox) insertNewMessage(encode []byte, msg *email.Bmail) (uint64, error) {
+	if f, ok := box.mbox.(data.IndexedFolder); ok {
+		return f.InsertNewIndexedMessage(encode, msg.Content.Encoding(), indexTerms(msg))
+	}
+
+	return box.mbox.InsertNewMessage(encode, msg.Content.Encoding())
+}
+
+// insertMessage inserts a message in the folder of the mailbox under its
+// existing uid. The message is put in the full-text index if the folder has
+// one.
+func (box *mailbox) insertMessage(encode []byte, msg *email.Bmail) error {
+	if f, ok := box.mbox.(data.IndexedFolder); ok {
+		return f.InsertIndexedMessage(msg.ImapData.UID, encode,
+			msg.Content.Encoding(), indexTerms(msg))
+	}
+
+	return box.mbox.InsertMessage(msg.ImapData.UID, encode, msg.Content.Encoding())
+}
+
+// Search does a full-text search of the messages in every folder and returns
+// at most limit of them, ordered from the best match to the worst. If limit
+// is zero, every match is returned.
+func (u *User) Search(query string, limit uint32) ([]cmd.SearchResult, error) {
+	index, ok := u.folders.(data.Index)
+	if !ok {
+		return nil, ErrNoIndex
+	}
+
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	matches, err := index.Search(terms, int(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	u.boxesMtx.RLock()
+	defer u.boxesMtx.RUnlock()
+
+	results := make([]cmd.SearchResult, 0, len(matches))
+	for _, m := range matches {
+		mbox, ok := u.boxes[m.Folder]
+		if !ok {
+			continue
+		}
+
+		bm := mbox.BitmessageByUID(m.ID)
+		if bm == nil {
+			continue
+		}
+
+		results = append(results, cmd.SearchResult{
+			Message: bm,
+			Score:   m.Score,
+		})
+	}
+
+	return results, nil
+}
+
+// RebuildIndex replaces the full-text index with one made from the messages
+// currently in the folders. It is needed for stores which were created
+// before the index existed. It returns the number of messages indexed.
+func (u *User) RebuildIndex() (uint32, error) {
+	index, ok := u.folders.(data.Index)
+	if !ok {
+		return 0, ErrNoIndex
+	}
+
+	u.boxesMtx.RLock()
+	defer u.boxesMtx.RUnlock()
+
+	err := index.ClearIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint32
+	for _, mbox := range u.boxes {
+		// Virtual mailboxes show messages that are already indexed.
+		if mbox.sub != nil {
+			continue
+		}
+
+		n, err := mbox.rebuildIndex()
+		if err != nil {
+			return count, err
+		}
+		count += n
+	}
+
+	return count, nil
+}
+
+// rebuildIndex puts every message in the folder of the mailbox in the
+// full-text index.
+func (box *mailbox) rebuildIndex() (uint32, error) {
+	f, ok := box.mbox.(data.IndexedFolder)
+	if !ok {
+		return 0, ErrNoIndex
+	}
+
+	box.Lock()
+	defer box.Unlock()
+
+	// The messages can't be indexed from within ForEachMessage.
+	terms := make(map[uint64]data.Terms)
+	err := f.ForEachMessage(0, 0, anyEncoding, func(id, suffix uint64, msg []byte) error {
+		bm, _, err := decodeBitmessage(msg)
+		if err != nil {
+			email.IMAPLog.Errorf("Failed to decode message #%d: %v", id, err)
+			return nil
+		}
+
+		terms[id] = indexTerms(bm)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for id, t := range terms {
+		if err := f.IndexMessage(id, t); err != nil {
+			return 0, err
+		}
+	}
+
+	return uint32(len(terms)), nil
+}
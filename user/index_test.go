@@ -0,0 +1,105 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestIndexTerms(t *testing.T) {
+	terms := indexTerms(MakeTestBitmessage("alice@bm.addr", "bob@bm.addr",
+		"Pizza!", "Shall we have pizza, Bob?"))
+
+	expected := data.Terms{
+		"alice": 1, "bob": 2, "bm": 2, "addr": 2, "pizza": 2,
+		"shall": 1, "we": 1, "have": 1,
+	}
+	if !reflect.DeepEqual(terms, expected) {
+		t.Errorf("Expected terms %v, got %v", expected, terms)
+	}
+
+	query := queryTerms("Pizza pizza, BOB")
+	if !reflect.DeepEqual(query, []string{"pizza", "bob"}) {
+		t.Errorf("Expected query terms %v, got %v", []string{"pizza", "bob"}, query)
+	}
+}
+
+func TestFullTextSearch(t *testing.T) {
+	folders := data.NewMemFolders()
+	u := &User{
+		folders: folders,
+		boxes:   make(map[string]*mailbox),
+	}
+
+	for _, name := range []string{InboxFolderName, SentFolderName} {
+		f, err := folders.New(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u.boxes[name], err = newMailbox(name, f, make(map[string]string))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inbox := u.boxes[InboxFolderName]
+	sent := u.boxes[SentFolderName]
+
+	inbox.AddNew(MakeTestBitmessage("alice@bm.addr", "bob@bm.addr",
+		"Lunch", "Shall we have pizza? Pizza is good."), types.FlagRecent)
+	inbox.AddNew(MakeTestBitmessage("carol@bm.addr", "bob@bm.addr",
+		"Recipe", "Flour, water, yeast."), types.FlagRecent)
+	sent.AddNew(MakeTestBitmessage("bob@bm.addr", "alice@bm.addr",
+		"Re: Lunch", "Yes, pizza."), types.FlagSeen)
+
+	check := func(query string, limit uint32, expected ...string) {
+		results, err := u.Search(query, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Message.ImapData.Mailbox.Name()+" "+r.Message.From)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Search %q: expected %v, got %v", query, expected, got)
+		}
+	}
+
+	check("pizza", 0, "Inbox alice@bm.addr", "Sent bob@bm.addr")
+	check("yeast", 0, "Inbox carol@bm.addr")
+	check("pizza", 1, "Inbox alice@bm.addr")
+	check("nothing", 0)
+
+	// Changing a message changes its terms in the index.
+	bm := inbox.BitmessageByUID(2)
+	bm.Content = MakeTestBitmessage("", "", "Recipe", "Pizza dough.").Content
+	inbox.Lock()
+	err := inbox.saveBitmessage(bm)
+	inbox.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("yeast", 0)
+	check("dough", 0, "Inbox carol@bm.addr")
+
+	// Deleted messages are not found.
+	sent.DeleteBitmessageByUID(1)
+	check("pizza", 0, "Inbox alice@bm.addr", "Inbox carol@bm.addr")
+
+	n, err := u.RebuildIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 messages to be indexed, got %d", n)
+	}
+	check("dough", 0, "Inbox carol@bm.addr")
+}
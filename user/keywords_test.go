@@ -0,0 +1,77 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestKeywords(t *testing.T) {
+	f, err := data.NewMemFolders().New(InboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "a", "first"), types.FlagRecent)
+	mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "b", "second"), types.FlagRecent)
+
+	// Messages without keywords must not gain any metadata.
+	bm := mb.BitmessageByUID(1)
+	encoded, err := serializeBitmessage(bm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := readMetadata(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md != nil {
+		t.Errorf("Unexpected metadata %v", md)
+	}
+
+	bm.ImapData.Keywords = []string{"$Important", "Work"}
+	mb.Lock()
+	err = mb.saveBitmessage(bm)
+	mb.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keywords must survive being read back from the folder.
+	mb, err = newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := mb.BitmessageByUID(1).ImapData.Keywords
+	if !reflect.DeepEqual(got, []string{"$Important", "Work"}) {
+		t.Errorf("Got keywords %v, expected %v", got, []string{"$Important", "Work"})
+	}
+	if len(mb.BitmessageByUID(2).ImapData.Keywords) != 0 {
+		t.Error("Message 2 should not have keywords.")
+	}
+
+	uids := mb.Search(&email.SearchCriteria{Keyword: []string{"work"}})
+	if !reflect.DeepEqual(uids, []uint64{1}) {
+		t.Errorf("Keyword search; got %v, expected %v", uids, []uint64{1})
+	}
+	uids = mb.Search(&email.SearchCriteria{Unkeyword: []string{"work"}})
+	if !reflect.DeepEqual(uids, []uint64{2}) {
+		t.Errorf("Unkeyword search; got %v, expected %v", uids, []uint64{2})
+	}
+
+	expected := append(append([]string{}, email.SystemFlags...), "$Important", "Work", `\*`)
+	if flags := mb.PermanentFlags(); !reflect.DeepEqual(flags, expected) {
+		t.Errorf("Got permanent flags %v, expected %v", flags, expected)
+	}
+}
@@ -21,6 +21,11 @@ import (
 	smtp "github.com/mailhog/data"
 )
 
+// anyEncoding is the suffix which is given to a folder to read the messages
+// of every encoding. The messages in a folder have the number of their
+// encoding as their suffix.
+const anyEncoding = 0
+
 // MessageSequence represents a sequence of uids contained in this mailbox.
 // It implements sort.Interface.
 type MessageSequence []uint64
@@ -111,6 +116,33 @@ type mailbox struct {
 	addresses map[string]string
 	drafts    bool // Whether this is a drafts folder.
 
+	// If set, new messages saved in the mailbox are given to send instead
+	// of being stored as they are. This is used for the outbox.
+	send func(*email.Bmail) ([]*email.Bmail, error)
+
+	// If set, messages are shown with the headers that it returns, which
+	// tell about their delivery status. This is used for the mailboxes
+	// which hold messages that are being sent or that have been sent.
+	status func(*email.Bmail) map[string][]string
+
+	// If set, it is called with the uid of every message that is deleted
+	// from the mailbox. This is used to stop sending messages which are
+	// deleted from the outbox.
+	cancel func(uint64)
+
+	// Other mailboxes which show messages from the same folder. They must
+	// be refreshed whenever this mailbox is changed.
+	linked []*mailbox
+
+	// Clients waiting to be told about changes to the mailbox.
+	watchers watchers
+
+	// The searchable fields of every message in the mailbox. It is changed
+	// while the lock of box is held for writing, except that it may be built
+	// while the lock is held for reading, so indexMtx is held to build it.
+	index    *searchIndex
+	indexMtx sync.Mutex
+
 	sync.RWMutex // Protect the following fields.
 	uids         MessageSequence
 	numRecent    uint32
@@ -133,6 +165,14 @@ func (box *mailbox) decodeBitmessageForImap(uid uint64, seqno uint32, msg []byte
 	return l
 }
 
+// toEmail converts a Bitmessage in the mailbox into an IMAPEmail.
+func (box *mailbox) toEmail(b *email.Bmail) (*email.IMAPEmail, error) {
+	if box.status == nil {
+		return b.ToEmail()
+	}
+	return b.ToEmailWithHeaders(box.status(b))
+}
+
 func (box *mailbox) getObject(b *email.Bmail) obj.Object {
 	if b.ImapData == nil {
 		return nil
@@ -167,17 +207,17 @@ func (box *mailbox) updateMailboxStats(entry *email.Bmail, id uint64) {
 // be called after the mailbox has been modified by an agent other than the
 // IMAP server. This could be the SMTP server, or new message from bmd.
 func (box *mailbox) refresh() error {
-
 	// Set NextUID
 	box.nextUID = uint32(box.mbox.NextID())
 
 	box.numRecent = 0
 	box.numUnseen = 0
+	box.index = newSearchIndex()
 	list := list.New()
 
 	// Run through every message to get the uids, count the recent and
 	// unseen messages, and to update pkrequests and powqueue.
-	err := box.mbox.ForEachMessage(0, 0, 2, func(id, suffix uint64, msg []byte) error {
+	err := box.mbox.ForEachMessage(0, 0, anyEncoding, func(id, suffix uint64, msg []byte) error {
 		b, _, err := decodeBitmessage(msg)
 		if err != nil {
 			return email.IMAPLog.Errorf("Failed to decode message #%d: %v", id, err)
@@ -189,6 +229,7 @@ func (box *mailbox) refresh() error {
 		}
 
 		box.updateMailboxStats(b, id)
+		box.index.add(id, b)
 
 		list.PushBack(id)
 		return nil
@@ -205,6 +246,7 @@ func (box *mailbox) refresh() error {
 
 	sort.Sort(box.uids)
 
+	box.notify()
 	return nil
 }
 
@@ -225,6 +267,9 @@ func (box *mailbox) LastUID() uint32 {
 	box.RLock()
 	defer box.RUnlock()
 
+	if len(box.uids) == 0 {
+		return box.nextUID
+	}
 	return uint32(box.uids[len(box.uids)-1])
 }
 
@@ -281,7 +326,7 @@ func (box *mailbox) MessageBySequenceNumber(seqno uint32) mailstore.Message {
 	if bm == nil {
 		return nil
 	}
-	em, err := bm.ToEmail()
+	em, err := box.toEmail(bm)
 	if err != nil {
 		email.IMAPLog.Errorf("MessageBySequenceNumber(%d) gave error %v", seqno, err)
 		return nil
@@ -330,7 +375,7 @@ func (box *mailbox) MessageByUID(uid uint32) mailstore.Message {
 	if letter == nil {
 		return nil
 	}
-	em, err := letter.ToEmail()
+	em, err := box.toEmail(letter)
 	if err != nil {
 		email.IMAPLog.Errorf("Failed to convert message #%d to e-mail: %v", uid, err)
 	}
@@ -354,7 +399,7 @@ func (box *mailbox) getRange(startUID, endUID uint64, startSequence, endSequence
 	bitmessages := make([]*email.Bmail, 0, endSequence-startSequence+1)
 
 	i := uint32(0)
-	err := box.mbox.ForEachMessage(startUID, endUID, 2, func(id, suffix uint64, msg []byte) error {
+	err := box.mbox.ForEachMessage(startUID, endUID, anyEncoding, func(id, suffix uint64, msg []byte) error {
 
 		bm := box.decodeBitmessageForImap(id, startSequence+i, msg)
 		if bm == nil {
@@ -528,6 +573,8 @@ func (box *mailbox) bitmessageSetBySequenceNumber(set types.SequenceSet) []*emai
 
 // addNew adds a new Bitmessage to the Mailbox.
 func (box *mailbox) addNew(bmsg *email.Bmail, flags types.Flags) error {
+	var c *change
+	defer func() { box.updateLinked(c) }()
 	box.Lock()
 	defer box.Unlock()
 
@@ -544,9 +591,9 @@ func (box *mailbox) addNew(bmsg *email.Bmail, flags types.Flags) error {
 		Mailbox:        box,
 	}
 
-	box.saveBitmessage(bmsg)
-
-	return nil
+	var err error
+	c, err = box.writeBitmessage(bmsg)
+	return err
 }
 
 // AddNew adds a new Bitmessage to the Mailbox.
@@ -568,7 +615,7 @@ func (box *mailbox) MessageSetByUID(set types.SequenceSet) []mailstore.Message {
 		if msg == nil {
 			panic("nil bitmessage returned!")
 		}
-		em[i], err = msg.ToEmail()
+		em[i], err = box.toEmail(msg)
 		if err != nil {
 			email.IMAPLog.Errorf("Failed to convert message #%d to e-mail: %v",
 				msg.ImapData.UID, err)
@@ -589,7 +636,7 @@ func (box *mailbox) MessageSetBySequenceNumber(set types.SequenceSet) []mailstor
 	msgs := box.bitmessageSetBySequenceNumber(set)
 	em := make([]mailstore.Message, len(msgs))
 	for i, msg := range msgs {
-		em[i], err = msg.ToEmail()
+		em[i], err = box.toEmail(msg)
 		if err != nil {
 			email.IMAPLog.Errorf("Failed to convert message #%d to e-mail: %v",
 				msg.ImapData.UID, err)
@@ -601,6 +648,8 @@ func (box *mailbox) MessageSetBySequenceNumber(set types.SequenceSet) []mailstor
 
 // DeleteBitmessageByUID deletes a Bitmessage by its UID.
 func (box *mailbox) DeleteBitmessageByUID(id uint64) error {
+	var c *change
+	defer func() { box.updateLinked(c) }()
 	box.Lock()
 	defer box.Unlock()
 
@@ -614,48 +663,59 @@ func (box *mailbox) DeleteBitmessageByUID(id uint64) error {
 		return err
 	}
 
-	// Update the box's state based on the information in the message deleted.
-	if bmsg.ImapData != nil {
-
-		if bmsg.ImapData.Flags&types.FlagRecent == types.FlagRecent {
-			box.numRecent--
-		}
-
-		if bmsg.ImapData.Flags&types.FlagSeen != types.FlagSeen {
-			box.numUnseen--
-		}
+	if box.cancel != nil {
+		box.cancel(id)
 	}
 
-	// Remove from objects map.
-	delete(box.objects, id)
-
-	for i, uid := range box.uids {
-		if uid == id {
-			box.uids = append(box.uids[0:i], box.uids[i+1:]...)
-			break
-		}
-	}
+	// Update the box's state based on the information in the message deleted.
+	c = &change{uid: id, old: bmsg}
+	box.update(c)
 	return nil
 }
 
 // saveBitmessage saves the given Bitmessage in the folder.
 func (box *mailbox) saveBitmessage(msg *email.Bmail) error {
+	_, err := box.writeBitmessage(msg)
+	return err
+}
+
+// updateBitmessage saves the new state of a Bitmessage which is already in
+// the folder. If the message has been deleted in the meantime, nothing is
+// saved, so that it does not reappear, and false is returned.
+func (box *mailbox) updateBitmessage(msg *email.Bmail) (bool, error) {
+	box.Lock()
+	defer box.Unlock()
+
+	if box.bmsgByUID(msg.ImapData.UID) == nil {
+		return false, nil
+	}
+	return true, box.saveBitmessage(msg)
+}
+
+// writeBitmessage saves the given Bitmessage in the folder and updates the
+// mailbox. It returns the change that was made so that it can be applied to
+// the linked mailboxes.
+func (box *mailbox) writeBitmessage(msg *email.Bmail) (*change, error) {
 	// Generate the new version of the message.
 	encode, err := serializeBitmessage(msg, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var previous *email.Bmail
+
 	// Insert the new version of the message.
 	if msg.ImapData.UID == 0 {
-		msg.ImapData.UID, err = box.mbox.InsertNewMessage(encode, msg.Content.Encoding())
+		msg.ImapData.UID, err = box.insertNewMessage(encode, msg)
 	} else {
+		previous = box.bmsgByUID(msg.ImapData.UID)
+
 		// Delete the old message from the database.
-		err := box.mbox.DeleteMessage(uint64(msg.ImapData.UID))
+		err = box.mbox.DeleteMessage(uint64(msg.ImapData.UID))
 		if err != nil {
 			email.IMAPLog.Errorf("Mailbox(%s).DeleteMessage(%d) gave error %v",
 				box.Name(), msg.ImapData.UID, err)
-			return err
+			return nil, err
 		}
 
 		// Delete object frmo object map if it exists.
@@ -666,27 +726,26 @@ func (box *mailbox) saveBitmessage(msg *email.Bmail) error {
 			// There is still a message there despite our attempts to delete it.
 			// That indicates that an entry exists in the folder which does not
 			// belong to this mailbox.
-			return errors.New("Unable to save.")
+			return nil, errors.New("Unable to save.")
 		}
 
-		err = box.mbox.InsertMessage(msg.ImapData.UID, encode, msg.Content.Encoding())
+		err = box.insertMessage(encode, msg)
 	}
 
 	if err != nil {
 		email.IMAPLog.Errorf("Mailbox(%s).InsertMessage(id=%d, suffix=%d) gave error %v",
 			box.Name(), msg.ImapData.UID, msg.Content.Encoding(), err)
-		return err
+		return nil, err
 	}
 
-	// TODO: don't refresh the whole thing every time we save. Jeez that's
-	// a lot of extra work!
-	err = box.refresh()
-	if err != nil {
-		email.IMAPLog.Errorf("Mailbox(%s).Refresh gave error %v", box.Name(), err)
-		return err
+	c := &change{
+		uid:     msg.ImapData.UID,
+		old:     previous,
+		current: msg,
 	}
+	box.update(c)
 
-	return nil
+	return c, nil
 }
 
 // Save saves an IMAP email in the Mailbox.
@@ -707,6 +766,7 @@ func (box *mailbox) Save(em *email.IMAPEmail) error {
 		UID:            em.ImapUID,
 		SequenceNumber: em.ImapSequenceNumber,
 		Flags:          em.ImapFlags,
+		Keywords:       em.ImapKeywords,
 		TimeReceived:   em.Date,
 		Mailbox:        box,
 	}
@@ -725,11 +785,19 @@ func (box *mailbox) Save(em *email.IMAPEmail) error {
 		}
 
 		msg.State = previous.State
+		msg.Recipients = previous.Recipients
+	} else if box.send != nil {
+		_, err = box.send(msg)
+		return err
 	}
 
+	var c *change
+	defer func() { box.updateLinked(c) }()
 	box.Lock()
 	defer box.Unlock()
-	return box.saveBitmessage(msg)
+
+	c, err = box.writeBitmessage(msg)
+	return err
 }
 
 // DeleteFlaggedMessages deletes messages that were flagged for deletion.
@@ -752,7 +820,7 @@ func (box *mailbox) DeleteFlaggedMessages() ([]mailstore.Message, error) {
 	// Delete them.
 	msgs := make([]mailstore.Message, 0, len(delBMsgs))
 	for _, b := range delBMsgs {
-		msg, err := b.ToEmail()
+		msg, err := box.toEmail(b)
 		if err != nil {
 			email.IMAPLog.Errorf("Failed to convert #%d to e-mail: %v", b.ImapData.UID,
 				err)
@@ -794,7 +862,7 @@ func newMailbox(name string, mbox data.Folder, addresses map[string]string) (*ma
 	}
 
 	// Populate various data fields.
-	if err := m.refresh(); err != nil {
+	if err := m.load(); err != nil {
 		return nil, err
 	}
 	return m, nil
@@ -814,8 +882,22 @@ func newDrafts(name string, mbox data.Folder, addresses map[string]string) (*mai
 	}
 
 	// Populate various data fields.
-	if err := m.refresh(); err != nil {
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// newOutbox returns a new Outbox folder. Messages which are saved in it
+// are given to send.
+func newOutbox(name string, mbox data.Folder, addresses map[string]string,
+	send func(*email.Bmail) ([]*email.Bmail, error), cancel func(uint64)) (*mailbox, error) {
+	m, err := newMailbox(name, mbox, addresses)
+	if err != nil {
 		return nil, err
 	}
+
+	m.send = send
+	m.cancel = cancel
 	return m, nil
 }
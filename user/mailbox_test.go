@@ -274,3 +274,70 @@ func TestInterface(t *testing.T) {
 		testMessageSetByUID(tc)
 	}
 }
+
+func TestExtendedMessages(t *testing.T) {
+	f, err := data.NewMemFolders().New(InboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "plain", "first"), types.FlagRecent)
+	bm := MakeTestBitmessage("BM-From", "BM-To", "", "")
+	bm.Content = &email.Extended{
+		Subject: "extended",
+		Body:    "second",
+		Parts: []*email.Part{&email.Part{
+			ContentType: "text/html",
+			Data:        []byte("<b>second</b>"),
+		}},
+	}
+	if err := mb.AddNew(bm, types.FlagRecent); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := types.InterpretSequenceSet("1:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Open the mailbox again, first with the statistics that are saved when
+	// it is closed and then without them.
+	for _, saved := range []bool{true, false} {
+		if saved {
+			if err := mb.close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		mb, err = newMailbox(InboxFolderName, f, make(map[string]string))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mb.Messages() != 2 {
+			t.Errorf("Expected 2 messages, got %d", mb.Messages())
+		}
+
+		got := mb.BitmessageByUID(2)
+		if got == nil {
+			t.Fatal("Extended message not found.")
+		}
+		if ext, ok := got.Content.(*email.Extended); !ok || ext.Subject != "extended" ||
+			len(ext.Parts) != 1 {
+			t.Errorf("Expected the extended message, got %v", got.Content)
+		}
+
+		msgs := mb.MessageSetBySequenceNumber(all)
+		if len(msgs) != 2 || msgs[1].UID() != 2 || msgs[1].SequenceNumber() != 2 {
+			t.Errorf("Expected both messages to be fetched, got %v", msgs)
+		}
+
+		uids := mb.Search(&email.SearchCriteria{Subject: []string{"extended"}})
+		if len(uids) != 1 || uids[0] != 2 {
+			t.Errorf("Expected search result [2], got %v", uids)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metadata
+
+//go:generate protoc --go_out=. metadata.proto
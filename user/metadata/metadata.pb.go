@@ -0,0 +1,118 @@
+// Code generated by protoc-gen-go.
+// source: metadata.proto
+// DO NOT EDIT!
+
+/*
+Package metadata is a generated protocol buffer package.
+
+It is generated from these files:
+	metadata.proto
+
+It has these top-level messages:
+	Metadata
+	Folder
+*/
+package metadata
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// Metadata contains the information about a message which bmagent stores
+// in addition to the fields of serialize.Message.
+type Metadata struct {
+	// Custom IMAP keywords set on the message.
+	Keywords []string `protobuf:"bytes,1,rep,name=keywords" json:"keywords,omitempty"`
+	// The To and Cc recipients of the message from which this one was
+	// made, if it was sent to more than one address. Bcc recipients are
+	// never saved.
+	To []string `protobuf:"bytes,2,rep,name=to" json:"to,omitempty"`
+	Cc []string `protobuf:"bytes,3,rep,name=cc" json:"cc,omitempty"`
+	// Identifies the message from which this one was made.
+	Original string `protobuf:"bytes,4,opt,name=original" json:"original,omitempty"`
+	// The Message-ID of the message and of the messages to which it is a
+	// reply.
+	MessageId  string   `protobuf:"bytes,5,opt,name=message_id,json=messageId" json:"message_id,omitempty"`
+	InReplyTo  string   `protobuf:"bytes,6,opt,name=in_reply_to,json=inReplyTo" json:"in_reply_to,omitempty"`
+	References []string `protobuf:"bytes,7,rep,name=references" json:"references,omitempty"`
+	// The time at which the message is scheduled to be sent, if it is
+	// waiting in the Outbox.
+	SendAt string `protobuf:"bytes,8,opt,name=send_at,json=sendAt" json:"send_at,omitempty"`
+	// The time to live of the message in seconds, if it is not the default.
+	Ttl uint64 `protobuf:"varint,9,opt,name=ttl" json:"ttl,omitempty"`
+	// The number of times that the proof-of-work for the message was
+	// invalid, and the error which stopped it from being sent, if any.
+	PowFailures uint32 `protobuf:"varint,10,opt,name=pow_failures,json=powFailures" json:"pow_failures,omitempty"`
+	Error       string `protobuf:"bytes,11,opt,name=error" json:"error,omitempty"`
+	// Why the message is held in the Outbox until the user approves it,
+	// and whether the user approved sending it even though the recipient
+	// demands more proof-of-work than the limit.
+	Held     string `protobuf:"bytes,12,opt,name=held" json:"held,omitempty"`
+	Approved bool   `protobuf:"varint,13,opt,name=approved" json:"approved,omitempty"`
+	// Whether the signature of the sender was verified when the message
+	// was decoded from the network.
+	Verified bool `protobuf:"varint,14,opt,name=verified" json:"verified,omitempty"`
+}
+
+func (m *Metadata) Reset()                    { *m = Metadata{} }
+func (m *Metadata) String() string            { return proto.CompactTextString(m) }
+func (*Metadata) ProtoMessage()               {}
+func (*Metadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+// Folder contains the statistics of a mailbox, which are saved when the
+// mailbox is closed so that they don't have to be recalculated from every
+// message when it is opened again.
+type Folder struct {
+	NextUid  uint64 `protobuf:"varint,1,opt,name=next_uid,json=nextUid" json:"next_uid,omitempty"`
+	Messages uint32 `protobuf:"varint,2,opt,name=messages" json:"messages,omitempty"`
+	Recent   uint32 `protobuf:"varint,3,opt,name=recent" json:"recent,omitempty"`
+	Unseen   uint32 `protobuf:"varint,4,opt,name=unseen" json:"unseen,omitempty"`
+}
+
+func (m *Folder) Reset()                    { *m = Folder{} }
+func (m *Folder) String() string            { return proto.CompactTextString(m) }
+func (*Folder) ProtoMessage()               {}
+func (*Folder) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func init() {
+	proto.RegisterType((*Metadata)(nil), "metadata.Metadata")
+	proto.RegisterType((*Folder)(nil), "metadata.Folder")
+}
+
+func init() { proto.RegisterFile("metadata.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 333 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x4c, 0x91, 0xbf, 0x4e, 0xc3, 0x30,
+	0x10, 0x87, 0x95, 0xa4, 0x4d, 0xd3, 0xeb, 0x1f, 0x21, 0x0b, 0xc1, 0x81, 0x44, 0x15, 0x3a, 0x65,
+	0x62, 0xe1, 0x09, 0x58, 0x2a, 0x31, 0xb0, 0x44, 0x30, 0x47, 0x26, 0xbe, 0x16, 0x8b, 0xd4, 0x8e,
+	0x6c, 0xb7, 0xa5, 0x8f, 0xcd, 0x1b, 0x20, 0x3b, 0x69, 0xc4, 0x76, 0xdf, 0xf7, 0xd3, 0x5d, 0x2e,
+	0x3e, 0x58, 0xee, 0xc9, 0x71, 0xc1, 0x1d, 0x7f, 0x6a, 0x8d, 0x76, 0x9a, 0x65, 0x17, 0x5e, 0xff,
+	0xc6, 0x90, 0xbd, 0xf5, 0xc0, 0xee, 0x21, 0xfb, 0xa6, 0xf3, 0x49, 0x1b, 0x61, 0x31, 0xca, 0x93,
+	0x62, 0x5a, 0x0e, 0xcc, 0x96, 0x10, 0x3b, 0x8d, 0x71, 0xb0, 0xb1, 0xd3, 0x9e, 0xeb, 0x1a, 0x93,
+	0x8e, 0xeb, 0xda, 0xf7, 0x6a, 0x23, 0x77, 0x52, 0xf1, 0x06, 0x47, 0x79, 0xe4, 0x7b, 0x2f, 0xcc,
+	0x1e, 0x00, 0xf6, 0x64, 0x2d, 0xdf, 0x51, 0x25, 0x05, 0x8e, 0x43, 0x3a, 0xed, 0xcd, 0xab, 0x60,
+	0x2b, 0x98, 0x49, 0x55, 0x19, 0x6a, 0x9b, 0x73, 0xe5, 0x34, 0xa6, 0x5d, 0x2e, 0x55, 0xe9, 0xcd,
+	0xbb, 0x66, 0x2b, 0x00, 0x43, 0x5b, 0x32, 0xa4, 0x6a, 0xb2, 0x38, 0x09, 0x9f, 0xfc, 0x67, 0xd8,
+	0x2d, 0x4c, 0x2c, 0x29, 0x51, 0x71, 0x87, 0x59, 0xe8, 0x4d, 0x3d, 0xbe, 0x38, 0x76, 0x05, 0x89,
+	0x73, 0x0d, 0x4e, 0xf3, 0xa8, 0x18, 0x95, 0xbe, 0x64, 0x8f, 0x30, 0x6f, 0xf5, 0xa9, 0xda, 0x72,
+	0xd9, 0x1c, 0x0c, 0x59, 0x84, 0x3c, 0x2a, 0x16, 0xe5, 0xac, 0xd5, 0xa7, 0x4d, 0xaf, 0xd8, 0x35,
+	0x8c, 0xc9, 0x18, 0x6d, 0x70, 0x16, 0x66, 0x75, 0xc0, 0x18, 0x8c, 0xbe, 0xa8, 0x11, 0x38, 0x0f,
+	0x32, 0xd4, 0xfe, 0x97, 0x79, 0xdb, 0x1a, 0x7d, 0x24, 0x81, 0x8b, 0x3c, 0x2a, 0xb2, 0x72, 0x60,
+	0x9f, 0x1d, 0xc9, 0xc8, 0xad, 0x24, 0x81, 0xcb, 0x2e, 0xbb, 0xf0, 0x5a, 0x43, 0xba, 0xd1, 0x8d,
+	0x20, 0xc3, 0xee, 0x20, 0x53, 0xf4, 0xe3, 0xaa, 0x83, 0x14, 0x18, 0x85, 0x2d, 0x27, 0x9e, 0x3f,
+	0x64, 0x18, 0xd0, 0xbf, 0x90, 0xc5, 0x38, 0x6c, 0x39, 0x30, 0xbb, 0x81, 0xd4, 0x50, 0x4d, 0xca,
+	0x61, 0x12, 0x92, 0x9e, 0xbc, 0x3f, 0x28, 0x4b, 0xa4, 0xc2, 0x05, 0x16, 0x65, 0x4f, 0x9f, 0x69,
+	0xb8, 0xfa, 0xf3, 0xdf, 0x00, 0x74, 0x69, 0xcf, 0xe4, 0x07, 0x02, 0x00, 0x00,
+}
@@ -0,0 +1,168 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"sync"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+// watcher is a client that is watching a mailbox. Updates that the client
+// has not received yet are combined into one, so that none are lost however
+// slowly the client reads them.
+type watcher struct {
+	// out is the channel on which updates are sent to the client.
+	out chan *email.MailboxUpdate
+
+	// wake is signalled when there is a new update and stop is closed when
+	// the client stops watching.
+	wake chan struct{}
+	stop chan struct{}
+
+	// seen is the uids in the mailbox as the client last saw them, and
+	// latest is the uids after the most recent change, or nil if the client
+	// has been told about every change. recent is the number of recent
+	// messages after the most recent change. They are protected by the mutex
+	// of the watchers.
+	seen   MessageSequence
+	latest MessageSequence
+	recent uint32
+}
+
+// watchers keeps track of the clients that are watching a mailbox for
+// changes, such as IMAP clients in the IDLE state.
+type watchers struct {
+	mtx      sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+// add registers a new watcher of a mailbox with the given uids.
+func (w *watchers) add(uids MessageSequence) (<-chan *email.MailboxUpdate, func()) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.watchers == nil {
+		w.watchers = make(map[*watcher]struct{})
+	}
+
+	wt := &watcher{
+		out:  make(chan *email.MailboxUpdate),
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		seen: append(MessageSequence(nil), uids...),
+	}
+	w.watchers[wt] = struct{}{}
+	go w.run(wt)
+
+	var once sync.Once
+	return wt.out, func() {
+		once.Do(func() {
+			w.mtx.Lock()
+			defer w.mtx.Unlock()
+
+			delete(w.watchers, wt)
+			close(wt.stop)
+		})
+	}
+}
+
+// run sends the updates for a watcher until it stops watching, and then
+// closes its channel. Every update tells the client about the changes since
+// the last one that it received.
+func (w *watchers) run(wt *watcher) {
+	defer close(wt.out)
+
+	for {
+		select {
+		case <-wt.wake:
+		case <-wt.stop:
+			return
+		}
+
+		w.mtx.Lock()
+		if wt.latest == nil {
+			w.mtx.Unlock()
+			continue
+		}
+		update := &email.MailboxUpdate{
+			Messages: uint32(len(wt.latest)),
+			Recent:   wt.recent,
+			Expunged: expunged(wt.seen, wt.latest),
+		}
+		wt.seen, wt.latest = wt.latest, nil
+		w.mtx.Unlock()
+
+		select {
+		case wt.out <- update:
+		case <-wt.stop:
+			return
+		}
+	}
+}
+
+// send tells every watcher about the new state of the mailbox without
+// blocking.
+func (w *watchers) send(uids MessageSequence, recent uint32) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if len(w.watchers) == 0 {
+		return
+	}
+
+	// The uids of the mailbox are changed in place, so the watchers need
+	// a copy of them.
+	latest := append(make(MessageSequence, 0, len(uids)), uids...)
+	for wt := range w.watchers {
+		wt.latest = latest
+		wt.recent = recent
+
+		select {
+		case wt.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// expunged returns the sequence numbers of the uids in old which are not in
+// current, in the order in which they should be reported to an IMAP client.
+// Both sequences must be sorted.
+func expunged(old, current MessageSequence) []uint32 {
+	var seqnos []uint32
+
+	j := 0
+	for i, uid := range old {
+		for j < len(current) && current[j] < uid {
+			j++
+		}
+		if j < len(current) && current[j] == uid {
+			continue
+		}
+
+		// Report from the highest sequence number down, so that earlier
+		// expunges don't change the numbers of later ones.
+		seqnos = append([]uint32{uint32(i + 1)}, seqnos...)
+	}
+
+	return seqnos
+}
+
+// Watch returns a channel on which an update is sent every time the mailbox
+// changes, and a function which stops the updates and closes the channel.
+// It is part of the email.Mailbox interface.
+func (box *mailbox) Watch() (<-chan *email.MailboxUpdate, func()) {
+	box.RLock()
+	defer box.RUnlock()
+
+	return box.watchers.add(box.uids)
+}
+
+// notify informs the watchers of the mailbox of its current state. Each of
+// them works out what has changed since it was last told. It must be called
+// while the lock of box is held.
+func (box *mailbox) notify() {
+	box.watchers.send(box.uids, box.numRecent)
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestExpunged(t *testing.T) {
+	tests := []struct {
+		old      MessageSequence
+		current  MessageSequence
+		expunged []uint32
+	}{
+		{[]uint64{}, []uint64{}, nil},
+		{[]uint64{1, 2, 3}, []uint64{1, 2, 3}, nil},
+		{[]uint64{1, 2, 3}, []uint64{1, 2, 3, 4}, nil},
+		{[]uint64{1, 2, 3}, []uint64{1, 3}, []uint32{2}},
+		{[]uint64{1, 2, 3}, []uint64{}, []uint32{3, 2, 1}},
+		{[]uint64{2, 5, 7, 9}, []uint64{5, 9, 10}, []uint32{3, 1}},
+	}
+
+	for i, test := range tests {
+		got := expunged(test.old, test.current)
+		if !reflect.DeepEqual(got, test.expunged) {
+			t.Errorf("expunged test case %d; got %v, expected %v.", i, got, test.expunged)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	f, err := data.NewMemFolders().New(InboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates, stop := mb.Watch()
+
+	for i, body := range []string{"first", "second"} {
+		mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "a", body), types.FlagRecent)
+
+		u := <-updates
+		n := uint32(i + 1)
+		if u.Messages != n || u.Recent != n || len(u.Expunged) != 0 {
+			t.Errorf("Unexpected update %v after adding message %d", u, n)
+		}
+	}
+
+	mb.DeleteBitmessageByUID(1)
+
+	u := <-updates
+	if u.Messages != 1 || !reflect.DeepEqual(u.Expunged, []uint32{1}) {
+		t.Errorf("Unexpected update %v after deleting message", u)
+	}
+
+	stop()
+	if _, ok := <-updates; ok {
+		t.Error("Expected channel to be closed.")
+	}
+
+	// Stopping twice should not panic.
+	stop()
+}
+
+// TestWatchSlow checks that a watcher which does not keep up with the
+// changes to a mailbox is still told about every message that is removed.
+func TestWatchSlow(t *testing.T) {
+	f, err := data.NewMemFolders().New(InboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []uint64
+	for i := 0; i < 20; i++ {
+		bm := MakeTestBitmessage("BM-From", "BM-To", "a", "message")
+		if err := mb.AddNew(bm, types.FlagRecent); err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, bm.ImapData.UID)
+	}
+
+	updates, stop := mb.Watch()
+	defer stop()
+
+	deleted := map[uint64]bool{seen[2]: true, seen[4]: true, seen[6]: true}
+	for uid := range deleted {
+		if err := mb.DeleteBitmessageByUID(uid); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Follow the updates as a client would until the last one.
+	for {
+		var u *email.MailboxUpdate
+		select {
+		case u = <-updates:
+		case <-time.After(time.Second):
+			t.Fatal("Expected an update.")
+		}
+
+		for _, seqno := range u.Expunged {
+			if seqno < 1 || int(seqno) > len(seen) {
+				t.Fatalf("Invalid expunged sequence number %d", seqno)
+			}
+			seen = append(seen[:seqno-1], seen[seqno:]...)
+		}
+
+		if int(u.Messages) != len(seen) {
+			t.Fatalf("Expected %d messages, got %d", len(seen), u.Messages)
+		}
+		if u.Messages == 17 {
+			break
+		}
+	}
+
+	for _, uid := range seen {
+		if deleted[uid] {
+			t.Errorf("Message %d was deleted but not expunged.", uid)
+		}
+	}
+}
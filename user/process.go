@@ -5,6 +5,7 @@ import (
 
 	"github.com/DanielKrawisz/bmagent/powmgr"
 	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/hash"
 	"github.com/DanielKrawisz/bmutil/pow"
 	"github.com/DanielKrawisz/bmutil/wire"
 	"github.com/DanielKrawisz/bmutil/wire/obj"
@@ -15,9 +16,10 @@ import (
 // It generates the correct parameters for running the proof-of-work and
 // sends it to the proof-of-work queue with a function provided by the
 // user that says what to do with the completed message when the
-// proof-of-work is done.
-func sendPow(pm *powmgr.Pow) func(object obj.Object, powData *pow.Data, done func([]byte)) {
-	return func(object obj.Object, powData *pow.Data, done func([]byte)) {
+// proof-of-work is done, and another that says what to do if it fails.
+// It returns the order, which can be cancelled.
+func sendPow(pm *powmgr.Pow, orderType powmgr.OrderType) func(object obj.Object, powData *pow.Data, done func([]byte), failed func(error)) *powmgr.Order {
+	return func(object obj.Object, powData *pow.Data, done func([]byte), failed func(error)) *powmgr.Order {
 		encoded := wire.Encode(object)
 		q := encoded[8:] // exclude the nonce
 
@@ -25,14 +27,155 @@ func sendPow(pm *powmgr.Pow) func(object obj.Object, powData *pow.Data, done fun
 			uint64(object.Header().Expiration().Sub(time.Now()).Seconds()), *powData)
 
 		// Attempt to run pow on the message.
-		pm.Run(target, q, func(n pow.Nonce) {
+		return pm.Run(target, q, orderType, orderType.Priority(), func(n pow.Nonce) {
 			// Put the nonce bytes into the encoded form of the message.
 			q = append(n.Bytes(), q...)
 			done(q)
-		})
+		}, failed)
 	}
 }
 
+// powFailed returns a function which records in the state of a message that
+// the proof-of-work for it was invalid.
+func (u *User) powFailed(bmsg *email.Bmail) func(error) {
+	return func(err error) {
+		u.untrackPow(bmsg.ImapData.UID)
+
+		outbox, err := u.mailbox(OutboxFolderName)
+		if err != nil {
+			return
+		}
+		outbox.Lock()
+		defer outbox.Unlock()
+
+		// The message may have been deleted in the meantime.
+		if outbox.bmsgByUID(bmsg.ImapData.UID) == nil {
+			return
+		}
+
+		email.SMTPLog.Errorf("Proof-of-work for message from %s to %s failed: %v",
+			bmsg.From, bmsg.To, err)
+
+		bmsg.State.PowFailures++
+		if failure, ok := err.(*powmgr.Failure); !ok || !failure.Retrying {
+			bmsg.State.Error = err.Error()
+		}
+
+		if err := outbox.saveBitmessage(bmsg); err != nil {
+			email.SMTPLog.Errorf("Could not save message: %v", err)
+		}
+	}
+}
+
+// sendFailed saves the reason that a message in the outbox could not be sent
+// with it so that the user can see why and try again.
+func sendFailed(outbox *mailbox, bmsg *email.Bmail, err error) {
+	email.SMTPLog.Errorf("Could not send message from %s to %s: %v",
+		bmsg.From, bmsg.To, err)
+
+	outbox.Lock()
+	defer outbox.Unlock()
+
+	// The message may have been deleted in the meantime.
+	if outbox.bmsgByUID(bmsg.ImapData.UID) == nil {
+		return
+	}
+
+	bmsg.State.Error = err.Error()
+	if err := outbox.saveBitmessage(bmsg); err != nil {
+		email.SMTPLog.Errorf("Could not save message: %v", err)
+	}
+}
+
+// trackPow remembers the proof-of-work order for a message in the outbox so
+// that it can be cancelled if the message is deleted. Any earlier order for
+// the same message is cancelled so that it is not sent twice.
+func (u *User) trackPow(bmsg *email.Bmail, order *powmgr.Order) {
+	u.ordersMtx.Lock()
+	defer u.ordersMtx.Unlock()
+
+	if u.orders == nil {
+		u.orders = make(map[uint64]*powmgr.Order)
+	}
+
+	uid := bmsg.ImapData.UID
+	if previous, ok := u.orders[uid]; ok && previous != order {
+		previous.Cancel()
+	}
+
+	// The order may have been finished already, in which case it is not
+	// tracked because untrackPow has been called for it already.
+	if order.Finished() {
+		delete(u.orders, uid)
+		return
+	}
+	u.orders[uid] = order
+}
+
+// untrackPow forgets the proof-of-work order for the message in the outbox
+// with the given uid once the order is finished. An order which has replaced
+// it and is still running is kept.
+func (u *User) untrackPow(uid uint64) {
+	u.ordersMtx.Lock()
+	defer u.ordersMtx.Unlock()
+
+	if order, ok := u.orders[uid]; ok && order.Finished() {
+		delete(u.orders, uid)
+	}
+}
+
+// cancelPow cancels the proof-of-work for the message in the outbox with the
+// given uid, if there is any. It is called whenever a message is deleted
+// from the outbox, which includes when it is moved somewhere else.
+func (u *User) cancelPow(uid uint64) {
+	u.ordersMtx.Lock()
+	order, ok := u.orders[uid]
+	delete(u.orders, uid)
+	u.ordersMtx.Unlock()
+
+	if ok && order.Cancel() {
+		email.SMTPLog.Infof("Stopped sending message #%d, which was deleted from the outbox.", uid)
+	}
+}
+
+// sendCompleted sends a message in the outbox whose proof-of-work has been
+// completed and saves its new state. The outbox stays locked until the
+// message has been sent so that it cannot be deleted in the meantime. If it
+// was deleted already, it is not sent and false is returned.
+func (u *User) sendCompleted(outbox *mailbox, bmsg *email.Bmail,
+	object obj.Object, completed []byte) (bool, error) {
+	outbox.Lock()
+	defer outbox.Unlock()
+
+	// The message may have been deleted just as the proof-of-work was
+	// finished.
+	if outbox.bmsgByUID(bmsg.ImapData.UID) == nil {
+		email.SMTPLog.Infof("Message from %s to %s was deleted before it was sent.",
+			bmsg.From, bmsg.To)
+		return false, nil
+	}
+
+	// The Message-ID depends on the nonce, so it is not known until now.
+	bmsg.State.Error = ""
+	h := hash.InventoryHash(completed)
+	bmsg.MessageID = email.MessageID(h)
+	bmsg.Expiration = object.Header().Expiration()
+
+	bmsg.State.SendTries++
+	bmsg.State.LastSend = time.Now()
+
+	// Save Bitmessage in outbox folder. Move reads it from there, so its
+	// state must be saved first.
+	if err := outbox.saveBitmessage(bmsg); err != nil {
+		return false, err
+	}
+
+	email.SMTPLog.Infof("Created bitmessage with hash %s", h.String())
+
+	u.server.Send(completed)
+	return true, nil
+}
+
 // process takes a Bitmessage and does whatever needs to be done to it
 // next in order to send it into the network. There are some steps in this
 // process that take a bit of time, so they can't all be handled sequentially
@@ -44,31 +187,45 @@ func sendPow(pm *powmgr.Pow) func(object obj.Object, powData *pow.Data, done fun
 // The next step is proof-of-work, and that also takes time.
 func (u *User) process(bmsg *email.Bmail) error {
 	email.SMTPLog.Debug("process called.")
-	outbox := u.boxes[OutboxFolderName]
+	outbox, err := u.mailbox(OutboxFolderName)
+	if err != nil {
+		return err
+	}
 
 	// sendPreparedMessage is used after we have looked up private keys and
 	// generated an ack message, if applicable.
 	sendPreparedMessage := func(object obj.Object, powData *pow.Data) error {
+		// The network does not accept objects larger than this.
+		if len(wire.Encode(object)) > wire.MaxPayloadOfMsgObject {
+			return email.ErrMessageTooLarge
+		}
+
 		email.SMTPLog.Debug("Generating pow for message.")
-		err := outbox.saveBitmessage(bmsg)
+		saved, err := outbox.updateBitmessage(bmsg)
 		if err != nil {
 			return err
 		}
+		if !saved {
+			email.SMTPLog.Infof("Message from %s to %s was deleted before it was sent.",
+				bmsg.From, bmsg.To)
+			return nil
+		}
 
 		// Put the prepared object in the pow queue and send it off
 		// through the network.
-		sendPow(u.pm)(object, powData, func(completed []byte) {
+		orderType := powmgr.TypeMessage
+		if bmsg.To == email.Broadcast {
+			orderType = powmgr.TypeBroadcast
+		}
+		u.trackPow(bmsg, sendPow(u.pm, orderType)(object, powData, func(completed []byte) {
+			u.untrackPow(bmsg.ImapData.UID)
+
 			err := func() error {
-				// Save Bitmessage in outbox folder.
-				err := u.boxes[OutboxFolderName].saveBitmessage(bmsg)
-				if err != nil {
+				sent, err := u.sendCompleted(outbox, bmsg, object, completed)
+				if !sent || err != nil {
 					return err
 				}
 
-				email.SMTPLog.Infof("Created bitmessage with hash %s", obj.InventoryHash(object).String())
-
-				u.server.Send(completed)
-
 				// Select new box for the message.
 				var newBoxName string
 				if bmsg.State.AckExpected {
@@ -77,9 +234,6 @@ func (u *User) process(bmsg *email.Bmail) error {
 					newBoxName = SentFolderName
 				}
 
-				bmsg.State.SendTries++
-				bmsg.State.LastSend = time.Now()
-
 				return u.Move(bmsg, OutboxFolderName, newBoxName)
 			}()
 			// We can't return the error any further because this function
@@ -87,7 +241,7 @@ func (u *User) process(bmsg *email.Bmail) error {
 			if err != nil {
 				email.SMTPLog.Error("process could not send message: ", err.Error())
 			}
-		})
+		}, u.powFailed(bmsg)))
 
 		return nil
 	}
@@ -104,12 +258,15 @@ func (u *User) process(bmsg *email.Bmail) error {
 			email.SMTPLog.Debug("process: Pubkey request sent.")
 
 			// Try to save the message, as its state has changed.
-			err := outbox.saveBitmessage(bmsg)
-			if err != nil {
-				return err
-			}
+			_, err := outbox.updateBitmessage(bmsg)
+			return err
+		} else if err == email.ErrDifficultyTooHigh {
+			email.SMTPLog.Infof("Holding message from %s to %s: %s",
+				bmsg.From, bmsg.To, bmsg.State.Held)
 
-			return nil
+			// The message waits in the outbox until it is approved.
+			_, err := outbox.updateBitmessage(bmsg)
+			return err
 		} else if err == email.ErrAckMissing {
 			email.SMTPLog.Debug("process: Generating ack.")
 			ack, powData, err := u.generateAck(bmsg)
@@ -123,7 +280,9 @@ func (u *User) process(bmsg *email.Bmail) error {
 			// Save the ack.
 			u.acks[*h] = bmsg.ImapData.UID
 
-			sendPow(u.pm)(ack, powData, func(completed []byte) {
+			u.trackPow(bmsg, sendPow(u.pm, powmgr.TypeAck)(ack, powData, func(completed []byte) {
+				u.untrackPow(bmsg.ImapData.UID)
+
 				err := func() error {
 					// Add the ack to the message.
 					bmsg.Ack = completed
@@ -137,14 +296,14 @@ func (u *User) process(bmsg *email.Bmail) error {
 						return err
 					}
 
-					return sendPreparedMessage(object, objData.Pow)
+					return sendPreparedMessage(object, objData)
 				}()
 				// Once again, we can't return the error any further because
 				// trySend is over by the time this function is run.
 				if err != nil {
 					email.SMTPLog.Error("process: could not send pow ", err.Error())
 				}
-			})
+			}, u.powFailed(bmsg)))
 
 			return nil
 		} else {
@@ -154,5 +313,5 @@ func (u *User) process(bmsg *email.Bmail) error {
 	}
 
 	// If the object was generated successufully, do POW and send it.
-	return sendPreparedMessage(object, data.Pow)
+	return sendPreparedMessage(object, data)
 }
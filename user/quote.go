@@ -0,0 +1,156 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"math"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/cmd"
+	"github.com/DanielKrawisz/bmagent/powmgr"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/pow"
+	"github.com/DanielKrawisz/bmutil/wire"
+)
+
+// objectOverhead is roughly how much larger an object is than the content
+// of the message in it, once the header, keys, signature, encryption and
+// ack have been added.
+const objectOverhead = 512
+
+// Quote estimates the proof-of-work needed to send the draft with the given
+// uid without sending it. If ttl is not zero, the estimate is for a message
+// that lives that long in the network rather than as long as the draft says.
+// Recipients whose pubkeys are not known are quoted as unknown, and their
+// pubkeys are requested.
+func (u *User) Quote(uid uint64, ttl time.Duration) (*cmd.Quote, error) {
+	drafts, err := u.mailbox(DraftsFolderName)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := drafts.BitmessageByUID(uid)
+	if draft == nil {
+		return nil, ErrNoMessageFound
+	}
+
+	// Read the draft in the same way as SendDraft would.
+	em, err := draft.ToEmail()
+	if err != nil {
+		return nil, err
+	}
+
+	bmsg, err := email.NewBitmessageFromSMTP(em.Content)
+	if err != nil {
+		return nil, err
+	}
+	if ttl != 0 {
+		bmsg.TTL = ttl
+	}
+
+	bms := bmsg.Split()
+	for _, bm := range bms {
+		if err := u.validateAddresses(bm); err != nil {
+			return nil, err
+		}
+	}
+
+	// The address was checked by validateAddresses.
+	fromAddr, _ := email.ToBm(bmsg.From)
+	from := u.keys.Get(fromAddr)
+
+	objType := wire.ObjectTypeMsg
+	if bmsg.To == email.Broadcast {
+		objType = wire.ObjectTypeBroadcast
+	}
+
+	q := &cmd.Quote{
+		TTL:  u.ttl(bmsg, objType),
+		Size: uint64(len(bmsg.Content.Message())) + objectOverhead,
+	}
+	if u.pm != nil {
+		q.Hashrate = u.pm.Hashrate()
+	}
+
+	var total float64
+	q.Recipients = make([]cmd.RecipientQuote, len(bms))
+	for i, bm := range bms {
+		r := &q.Recipients[i]
+		r.Address = bm.To
+
+		// As in generateObject, broadcasts are done with our own
+		// difficulty and messages with the recipient's.
+		data := from.Data().Pow
+		if bm.To != email.Broadcast {
+			to, err := u.server.GetOrRequestPublicID(bm.To)
+			if err == email.ErrGetPubKeySent {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			data = minimumPow(to.Pow())
+		}
+
+		r.Known = true
+		r.Target = pow.CalculateTarget(q.Size, uint64(q.TTL.Seconds()), *data)
+		if u.pm != nil {
+			r.Time = u.pm.Estimate(r.Target)
+			total += float64(r.Time)
+		}
+	}
+	q.Time = duration(total)
+
+	return q, nil
+}
+
+// duration converts a number of nanoseconds to a time.Duration, which is
+// capped rather than allowed to overflow.
+func duration(ns float64) time.Duration {
+	if ns >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(ns)
+}
+
+// PowQueue returns the orders in the proof-of-work queue, beginning with the
+// one that is being worked on.
+func (u *User) PowQueue() []powmgr.OrderInfo {
+	if u.pm == nil {
+		return nil
+	}
+
+	return u.pm.Queue()
+}
+
+// PowPolicy returns the limits on how much of the computer proof-of-work
+// uses.
+func (u *User) PowPolicy() powmgr.Policy {
+	if u.pm == nil {
+		return powmgr.DefaultPolicy
+	}
+
+	return u.pm.Policy()
+}
+
+// SetPowPolicy changes the limits on how much of the computer proof-of-work
+// uses.
+func (u *User) SetPowPolicy(policy powmgr.Policy) error {
+	if u.pm == nil {
+		return ErrNoPowManager
+	}
+
+	return u.pm.SetPolicy(policy)
+}
+
+// Benchmark measures how fast proof-of-work is done with different numbers
+// of threads and saves the result, which is used for estimates from now on.
+func (u *User) Benchmark() (*powmgr.Calibration, error) {
+	if u.pm == nil {
+		return nil, ErrNoPowManager
+	}
+
+	return u.pm.Calibrate()
+}
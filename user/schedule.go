@@ -0,0 +1,71 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+// SendScheduled begins to send every message in the Outbox which was
+// scheduled to be sent at or before the given time. The schedule is saved
+// with the messages, so messages which came due while bmagent was not
+// running are sent the first time that this is called. A message which
+// cannot be sent does not stop the others from being sent.
+func (u *User) SendScheduled(now time.Time) error {
+	outbox, err := u.mailbox(OutboxFolderName)
+	if err != nil {
+		return nil
+	}
+
+	// Go through all messages in the Outbox and get the uids of those which
+	// are due.
+	var uids []uint64
+	err = outbox.mbox.ForEachMessage(0, 0, anyEncoding, func(id, _ uint64, msg []byte) error {
+		bmsg, _, err := decodeBitmessage(msg)
+		if err != nil {
+			return err
+		}
+
+		if !bmsg.SendAt.IsZero() && !bmsg.SendAt.After(now) {
+			uids = append(uids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		bmsg := outbox.BitmessageByUID(uid)
+		if bmsg == nil { // The message was deleted in the meantime.
+			continue
+		}
+
+		email.SMTPLog.Infof("Sending message from %s to %s scheduled for %s.",
+			bmsg.From, bmsg.To, bmsg.SendAt.Format(email.DateFormat))
+
+		// The message is no longer waiting, so it must not be sent again
+		// the next time around. It is only saved that way along with the
+		// progress that process makes, so that a message is still due if
+		// bmagent stops before it is on its way.
+		bmsg.SendAt = time.Time{}
+
+		// If the message cannot be sent, the reason is saved with it so
+		// that the user can see why it was not sent and schedule it again.
+		if err := u.process(bmsg); err != nil {
+			sendFailed(outbox, bmsg, err)
+			continue
+		}
+
+		// Not every step of process saves the message.
+		if _, err := outbox.updateBitmessage(bmsg); err != nil {
+			email.SMTPLog.Errorf("Could not save message #%d: %v", uid, err)
+		}
+	}
+
+	return nil
+}
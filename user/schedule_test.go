@@ -0,0 +1,128 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestSendScheduled(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(OutboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendAt, _ := time.Parse(email.DateFormat, "Mon Jan 2 15:04:05 -0700 MST 2040")
+	bm := MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Later", "Hello.")
+	bm.SendAt = sendAt
+	if err := u.boxes[OutboxFolderName].AddNew(bm, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	// The schedule is saved with the message.
+	folder, err := folders.Get(OutboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox, err := newMailbox(OutboxFolderName, folder, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := outbox.BitmessageByUID(1)
+	if !saved.SendAt.Equal(sendAt) {
+		t.Fatalf("Expected message to be scheduled for %v, got %v", sendAt, saved.SendAt)
+	}
+
+	em, err := saved.ToEmail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := em.Content.Headers["Send-At"]; len(header) != 1 || header[0] != sendAt.Format(email.DateFormat) {
+		t.Errorf("Expected Send-At header %s, got %v", sendAt.Format(email.DateFormat), header)
+	}
+
+	// Nothing is due yet.
+	if err := u.SendScheduled(sendAt.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if u.boxes[OutboxFolderName].BitmessageByUID(1).SendAt.IsZero() {
+		t.Error("Expected message to remain scheduled.")
+	}
+
+	// Another message is due at the same time.
+	bm = MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Also later", "Hello again.")
+	bm.SendAt = sendAt
+	if err := u.boxes[OutboxFolderName].AddNew(bm, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user has no identities, so neither message can be sent. Each is
+	// no longer scheduled, and the reason that it was not sent is saved
+	// with it.
+	if err := u.SendScheduled(sendAt); err != nil {
+		t.Fatal(err)
+	}
+	for uid := uint64(1); uid <= 2; uid++ {
+		bm := u.boxes[OutboxFolderName].BitmessageByUID(uid)
+		if !bm.SendAt.IsZero() {
+			t.Errorf("Expected message #%d not to be scheduled anymore.", uid)
+		}
+		if bm.State.Error != ErrMissingPrivateID.Error() {
+			t.Errorf("Expected message #%d to have error %q, got %q",
+				uid, ErrMissingPrivateID.Error(), bm.State.Error)
+		}
+	}
+}
+
+func TestSendScheduledPubkeyRequest(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(OutboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	from := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+	to := email.BmToEmail("BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8")
+	u, err := NewUser("cosmos", namedKeys{from: &keys.PrivateID{}}, nil, folders, nil,
+		pubkeyOps{to: email.ErrGetPubKeySent})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendAt, _ := time.Parse(email.DateFormat, "Mon Jan 2 15:04:05 -0700 MST 2040")
+	bm := MakeTestBitmessage(email.BmToEmail(from), to, "Later", "Hello.")
+	bm.SendAt = sendAt
+	if err := u.boxes[OutboxFolderName].AddNew(bm, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	// The message waits for the pubkey of its recipient and is saved as no
+	// longer scheduled along with that.
+	if err := u.SendScheduled(sendAt); err != nil {
+		t.Fatal(err)
+	}
+	saved := u.boxes[OutboxFolderName].BitmessageByUID(1)
+	if !saved.SendAt.IsZero() {
+		t.Error("Expected message not to be scheduled anymore.")
+	}
+	if !saved.State.PubkeyRequestOutstanding {
+		t.Error("Expected message to wait for a pubkey.")
+	}
+	if saved.State.Error != "" {
+		t.Errorf("Expected no error, got %q", saved.State.Error)
+	}
+}
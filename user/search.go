@@ -0,0 +1,277 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/format"
+	"github.com/jordwest/imap-server/types"
+)
+
+// searchEntry contains the searchable fields of a message so that searches
+// can be done without reading and decrypting every message in the folder.
+// Only the terms of the body are kept, not the body itself.
+type searchEntry struct {
+	from     string
+	to       string
+	subject  string
+	body     map[string]struct{}
+	received time.Time
+	flags    types.Flags
+	keywords []string
+}
+
+// newSearchEntry creates the index entry for a Bitmessage.
+func newSearchEntry(bm *email.Bmail) *searchEntry {
+	entry := &searchEntry{
+		from: strings.ToLower(bm.From),
+		to:   strings.ToLower(bm.To),
+		body: make(map[string]struct{}),
+	}
+
+	var body string
+	switch c := bm.Content.(type) {
+	case *format.Encoding1:
+		body = c.Body
+	case *format.Encoding2:
+		entry.subject = strings.ToLower(c.Subject)
+		body = c.Body
+	case *email.Extended:
+		entry.subject = strings.ToLower(c.Subject)
+		body = c.Body
+	}
+	for _, term := range tokenize(body) {
+		entry.body[term] = struct{}{}
+	}
+
+	if bm.ImapData != nil {
+		entry.received = bm.ImapData.TimeReceived
+		entry.flags = bm.ImapData.Flags
+		entry.keywords = bm.ImapData.Keywords
+	}
+
+	return entry
+}
+
+// searchIndex contains the searchable fields of every message in a mailbox
+// and the uids of the messages in whose bodies each term appears.
+type searchIndex struct {
+	entries map[uint64]*searchEntry
+	terms   map[string]map[uint64]struct{}
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		entries: make(map[uint64]*searchEntry),
+		terms:   make(map[string]map[uint64]struct{}),
+	}
+}
+
+// add puts a message in the index, replacing any message with the same uid.
+func (idx *searchIndex) add(uid uint64, bm *email.Bmail) {
+	idx.remove(uid)
+
+	entry := newSearchEntry(bm)
+	idx.entries[uid] = entry
+	for term := range entry.body {
+		uids, ok := idx.terms[term]
+		if !ok {
+			uids = make(map[uint64]struct{})
+			idx.terms[term] = uids
+		}
+		uids[uid] = struct{}{}
+	}
+}
+
+// remove takes a message out of the index. Nothing is done if the message
+// is not in the index.
+func (idx *searchIndex) remove(uid uint64) {
+	entry, ok := idx.entries[uid]
+	if !ok {
+		return
+	}
+
+	delete(idx.entries, uid)
+	for term := range entry.body {
+		uids := idx.terms[term]
+		delete(uids, uid)
+		if len(uids) == 0 {
+			delete(idx.terms, term)
+		}
+	}
+}
+
+// candidates returns the uids of the messages whose bodies contain every
+// term of the given texts. It returns nil if there are no such terms, in
+// which case any message might match.
+func (idx *searchIndex) candidates(texts []string) map[uint64]struct{} {
+	var terms []string
+	for _, text := range texts {
+		terms = append(terms, tokenize(text)...)
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	// Start from the rarest term.
+	rarest := idx.terms[terms[0]]
+	for _, term := range terms[1:] {
+		if uids := idx.terms[term]; len(uids) < len(rarest) {
+			rarest = uids
+		}
+	}
+
+	candidates := make(map[uint64]struct{})
+	for uid := range rarest {
+		if hasTerms(idx.entries[uid].body, terms) {
+			candidates[uid] = struct{}{}
+		}
+	}
+	return candidates
+}
+
+// hasTerms returns whether every one of the given terms is in the set.
+func hasTerms(set map[string]struct{}, terms []string) bool {
+	for _, term := range terms {
+		if _, ok := set[term]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAll returns whether s contains every one of the given substrings,
+// ignoring case. s must already be in lower case.
+func containsAll(s string, subs []string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, strings.ToLower(sub)) {
+			return false
+		}
+	}
+	return true
+}
+
+// day returns the date of t, disregarding the time of day.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// matches returns whether the entry matches the search criteria. It doesn't
+// check the uid.
+func (e *searchEntry) matches(c *email.SearchCriteria) bool {
+	if !containsAll(e.from, c.From) || !containsAll(e.to, c.To) ||
+		!containsAll(e.subject, c.Subject) {
+		return false
+	}
+
+	// The body matches text if it contains all of its terms.
+	for _, text := range c.Body {
+		if !hasTerms(e.body, tokenize(text)) {
+			return false
+		}
+	}
+	for _, text := range c.Text {
+		if !strings.Contains(e.subject, strings.ToLower(text)) &&
+			!hasTerms(e.body, tokenize(text)) {
+			return false
+		}
+	}
+
+	if !c.Since.IsZero() && day(e.received).Before(day(c.Since)) {
+		return false
+	}
+	if !c.Before.IsZero() && !day(e.received).Before(day(c.Before)) {
+		return false
+	}
+
+	if !e.flags.HasFlags(c.WithFlags) {
+		return false
+	}
+	if e.flags&c.WithoutFlags != 0 {
+		return false
+	}
+
+	for _, k := range c.Keyword {
+		if !email.HasKeyword(e.keywords, k) {
+			return false
+		}
+	}
+	for _, k := range c.Unkeyword {
+		if email.HasKeyword(e.keywords, k) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Search returns the uids of the messages which match the given criteria,
+// in ascending order. It is part of the email.Mailbox interface.
+func (box *mailbox) Search(criteria *email.SearchCriteria) []uint64 {
+	box.RLock()
+	defer box.RUnlock()
+
+	index := box.getSearchIndex()
+	candidates := index.candidates(criteria.Body)
+
+	var last uint64
+	if len(box.uids) > 0 {
+		last = box.uids[len(box.uids)-1]
+	}
+
+	var uids []uint64
+	for _, uid := range box.uids {
+		if criteria.UIDs != nil && !email.InSequenceSet(uid, last, criteria.UIDs) {
+			continue
+		}
+
+		if candidates != nil {
+			if _, ok := candidates[uid]; !ok {
+				continue
+			}
+		}
+
+		entry, ok := index.entries[uid]
+		if !ok {
+			continue
+		}
+
+		if entry.matches(criteria) {
+			uids = append(uids, uid)
+		}
+	}
+
+	return uids
+}
+
+// PermanentFlags returns the flags and keywords which can be stored
+// permanently in the mailbox. This includes every keyword that is in use
+// in the mailbox, and \* to indicate that new keywords can be created.
+// It is part of the email.Mailbox interface.
+func (box *mailbox) PermanentFlags() []string {
+	box.RLock()
+	defer box.RUnlock()
+
+	flags := make([]string, len(email.SystemFlags))
+	copy(flags, email.SystemFlags)
+
+	var keywords []string
+	for _, entry := range box.getSearchIndex().entries {
+		for _, k := range entry.keywords {
+			if !email.HasKeyword(keywords, k) {
+				keywords = append(keywords, k)
+			}
+		}
+	}
+	sort.Strings(keywords)
+
+	flags = append(flags, keywords...)
+	return append(flags, `\*`)
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestSearch(t *testing.T) {
+	f, err := data.NewMemFolders().New(InboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mb.AddNew(MakeTestBitmessage("alice@bm.addr", "bob@bm.addr",
+		"Lunch", "Shall we have pizza?"), types.FlagSeen)
+	mb.AddNew(MakeTestBitmessage("carol@bm.addr", "bob@bm.addr",
+		"Pizza recipe", "Flour, water, yeast."), types.FlagRecent)
+	mb.AddNew(MakeTestBitmessage("alice@bm.addr", "dave@bm.addr",
+		"Meeting", "Tomorrow at noon."), types.FlagFlagged|types.FlagSeen)
+
+	mustSet := func(s string) types.SequenceSet {
+		set, err := types.InterpretSequenceSet(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return set
+	}
+
+	today := time.Now()
+	tests := []struct {
+		criteria email.SearchCriteria
+		expected []uint64
+	}{
+		{email.SearchCriteria{}, []uint64{1, 2, 3}},
+		{email.SearchCriteria{From: []string{"ALICE"}}, []uint64{1, 3}},
+		{email.SearchCriteria{To: []string{"bob"}}, []uint64{1, 2}},
+		{email.SearchCriteria{Subject: []string{"pizza"}}, []uint64{2}},
+		{email.SearchCriteria{Body: []string{"pizza"}}, []uint64{1}},
+		{email.SearchCriteria{Body: []string{"PIZZA, have"}}, []uint64{1}},
+		{email.SearchCriteria{Body: []string{"pizza", "yeast"}}, nil},
+		{email.SearchCriteria{Body: []string{"piz"}}, nil},
+		{email.SearchCriteria{Text: []string{"pizza"}}, []uint64{1, 2}},
+		{email.SearchCriteria{From: []string{"alice"}, Body: []string{"noon"}}, []uint64{3}},
+		{email.SearchCriteria{WithFlags: types.FlagSeen}, []uint64{1, 3}},
+		{email.SearchCriteria{WithoutFlags: types.FlagSeen}, []uint64{2}},
+		{email.SearchCriteria{WithFlags: types.FlagFlagged}, []uint64{3}},
+		{email.SearchCriteria{Since: today}, []uint64{1, 2, 3}},
+		{email.SearchCriteria{Since: today.AddDate(0, 0, 1)}, nil},
+		{email.SearchCriteria{Before: today}, nil},
+		{email.SearchCriteria{Before: today.AddDate(0, 0, 1)}, []uint64{1, 2, 3}},
+		{email.SearchCriteria{UIDs: mustSet("2:*")}, []uint64{2, 3}},
+		{email.SearchCriteria{UIDs: mustSet("1,3"), From: []string{"alice"}}, []uint64{1, 3}},
+		{email.SearchCriteria{UIDs: mustSet("*")}, []uint64{3}},
+		{email.SearchCriteria{Subject: []string{"nothing"}}, nil},
+	}
+
+	for i, test := range tests {
+		got := mb.Search(&test.criteria)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Search test case %d; got %v, expected %v.", i, got, test.expected)
+		}
+	}
+
+	// Deleted messages are removed from the index.
+	mb.DeleteBitmessageByUID(1)
+	got := mb.Search(&email.SearchCriteria{From: []string{"alice"}})
+	if !reflect.DeepEqual(got, []uint64{3}) {
+		t.Errorf("Search after delete; got %v, expected %v.", got, []uint64{3})
+	}
+}
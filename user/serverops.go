@@ -34,6 +34,13 @@ type ServerOps interface {
 
 	// Send sends a message out into the network.
 	Send(obj []byte)
+
+	// Publish sends out the pubkeys of our identities which need to be
+	// published, such as those which were just created.
+	Publish()
+
+	// Subscribe starts listening for broadcasts from the given address.
+	Subscribe(address string) error
 }
 
 // generateBroadcast generates a wire.MsgBroadcast from a Bitmessage.
@@ -89,8 +96,44 @@ func generateMessage(content format.Encoding, ack []byte, from *identity.Private
 	return message.Object(), nil
 }
 
-// GenerateObject generates the wire.MsgObject form of the message.
-func (u *User) generateObject(m *email.Bmail, box *mailbox) (obj.Object, *obj.PubKeyData, error) {
+// ttl returns how long an object made from the given message is to live in
+// the network. This is given by the TTL of the message or its Expires header,
+// or else it is the default for the type of object. Once a message has been
+// sent, its expiration is that of the object that was sent, so it is not
+// used again.
+func (u *User) ttl(m *email.Bmail, objType wire.ObjectType) time.Duration {
+	ttl := m.TTL
+	if ttl == 0 && !m.Expiration.IsZero() && (m.State == nil || m.State.SendTries == 0) {
+		ttl = m.Expiration.Sub(time.Now())
+	}
+
+	if ttl <= 0 {
+		return u.expiration(objType)
+	}
+	if ttl > email.MaxTTL {
+		return email.MaxTTL
+	}
+	return ttl
+}
+
+// minimumPow returns the proof-of-work parameters for a message to a
+// recipient whose pubkey asks for the given ones. The network does not accept
+// less work than the default, whatever the pubkey says, so neither parameter
+// is allowed to be below its default.
+func minimumPow(pd *pow.Data) *pow.Data {
+	data := *pd
+	if data.NonceTrialsPerByte < pow.DefaultNonceTrialsPerByte {
+		data.NonceTrialsPerByte = pow.DefaultNonceTrialsPerByte
+	}
+	if data.ExtraBytes < pow.DefaultExtraBytes {
+		data.ExtraBytes = pow.DefaultExtraBytes
+	}
+	return &data
+}
+
+// GenerateObject generates the wire.MsgObject form of the message along with
+// the proof-of-work difficulty demanded by its recipient.
+func (u *User) generateObject(m *email.Bmail, box *mailbox) (obj.Object, *pow.Data, error) {
 
 	fromAddr, err := email.ToBm(m.From)
 	if err != nil {
@@ -103,14 +146,6 @@ func (u *User) generateObject(m *email.Bmail, box *mailbox) (obj.Object, *obj.Pu
 	}
 	fromID := from.Private
 
-	var o obj.Object
-	data := fromID.Data()
-	// check for cached object.
-	o = box.getObject(m)
-	if o != nil {
-		return o, data, nil
-	}
-
 	email.SMTPLog.Debug("GenerateObject: about to serialize bmsg from " + m.From + " to " + m.To)
 	to, err := u.server.GetOrRequestPublicID(m.To)
 
@@ -123,18 +158,40 @@ func (u *User) generateObject(m *email.Bmail, box *mailbox) (obj.Object, *obj.Pu
 		return nil, nil, err
 	}
 
+	// Broadcasts are done with our own difficulty and messages with the
+	// recipient's.
+	data := fromID.Data().Pow
+	if to != Broadcast {
+		data = minimumPow(to.Pow())
+	}
+
+	// check for cached object.
+	o := box.getObject(m)
+	if o != nil {
+		return o, data, nil
+	}
+
 	// This is a brodcast.
 	if to == Broadcast {
-		o, err = generateBroadcast(m.Content, fromID, u.expiration(wire.ObjectTypeBroadcast))
+		o, err = generateBroadcast(m.Content, fromID, u.ttl(m, wire.ObjectTypeBroadcast))
 	} else {
 		id := u.keys.Get(m.To)
 		if id != nil {
 			m.OfChannel = id.IsChan
 			// We're sending to ourselves/chan so don't bother with ack.
 			m.State.AckExpected = false
-		} else if to.Behavior()&identity.BehaviorAck == identity.BehaviorAck {
-			// Set AckExpected if the flag is set in the public key.
-			m.State.AckExpected = true
+		} else {
+			if to.Behavior()&identity.BehaviorAck == identity.BehaviorAck {
+				// Set AckExpected if the flag is set in the public key.
+				m.State.AckExpected = true
+			}
+
+			// Hold the message if the recipient asks for too much work.
+			toAddr, _ := email.ToBm(m.To)
+			if err := u.checkDifficulty(m, toAddr, data); err != nil {
+				m.State.PubkeyRequestOutstanding = false
+				return nil, nil, err
+			}
 		}
 
 		// Check for ack.
@@ -142,7 +199,7 @@ func (u *User) generateObject(m *email.Bmail, box *mailbox) (obj.Object, *obj.Pu
 			return nil, nil, email.ErrAckMissing
 		}
 
-		o, err = generateMessage(m.Content, m.Ack, fromID, to, u.expiration(wire.ObjectTypeMsg))
+		o, err = generateMessage(m.Content, m.Ack, fromID, to, u.ttl(m, wire.ObjectTypeMsg))
 	}
 
 	if err != nil {
@@ -179,7 +236,7 @@ func (u *User) generateAck(m *email.Bmail) (ack obj.Object, powData *pow.Data, e
 	// We don't save the message because it still needs POW done on it.
 	return wire.NewMsgObject(
 		wire.NewObjectHeader(0,
-			time.Now().Add(u.expiration(wire.ObjectTypeMsg)),
+			time.Now().Add(u.ttl(m, wire.ObjectTypeMsg)),
 			wire.ObjectTypeMsg,
 			obj.MessageVersion,
 			addr.Stream(),
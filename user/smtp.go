@@ -140,9 +140,42 @@ func (serv *SMTPServer) messageReceived(smtpMessage *data.SMTPMessage) (string,
 	// TODO is this a good host name?
 	message := smtpMessage.Parse("bmagent")
 
+	addEnvelopeRecipients(message.Content, smtpMessage.To)
+
 	return string(message.ID), serv.user.DeliverFromSMTP(message.Content)
 }
 
+// addEnvelopeRecipients adds the recipients in the SMTP envelope which are
+// not in any of the address headers of a message to its Bcc header, since
+// that is how mail clients send blind carbon copies.
+func addEnvelopeRecipients(content *data.Content, rcpt []string) {
+	known := make(map[string]struct{})
+	for _, key := range []string{"To", "Cc", "Bcc"} {
+		for _, value := range content.Headers[key] {
+			list, err := mail.ParseAddressList(value)
+			if err != nil {
+				continue
+			}
+			for _, addr := range list {
+				known[addr.Address] = struct{}{}
+			}
+		}
+	}
+
+	for _, r := range rcpt {
+		addr, err := mail.ParseAddress(r)
+		if err != nil {
+			continue
+		}
+		if _, ok := known[addr.Address]; ok {
+			continue
+		}
+
+		known[addr.Address] = struct{}{}
+		content.Headers["Bcc"] = append(content.Headers["Bcc"], addr.Address)
+	}
+}
+
 // NewSMTPServer returns a new smtp server.
 func NewSMTPServer(cfg *email.SMTPConfig, user *User) *SMTPServer {
 	// Set the correct log handler.
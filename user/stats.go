@@ -0,0 +1,190 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"sort"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmagent/user/metadata"
+	"github.com/golang/protobuf/proto"
+	"github.com/jordwest/imap-server/types"
+)
+
+// change describes a change to a single message in a folder, so that it can
+// be applied to every mailbox which shows the folder without reading the
+// other messages.
+type change struct {
+	uid     uint64
+	old     *email.Bmail // nil if the message is new.
+	current *email.Bmail // nil if the message was deleted.
+}
+
+// find returns the index of the uid in the sequence, and whether it is there.
+// If it is not, the index is where it would be inserted.
+func (uids MessageSequence) find(uid uint64) (int, bool) {
+	i := sort.Search(len(uids), func(i int) bool {
+		return uids[i] >= uid
+	})
+	return i, i < len(uids) && uids[i] == uid
+}
+
+// removeMailboxStats undoes updateMailboxStats for a message which is being
+// removed from the mailbox or replaced.
+func (box *mailbox) removeMailboxStats(entry *email.Bmail) {
+	if entry.ImapData == nil {
+		return
+	}
+	if entry.ImapData.Flags.HasFlags(types.FlagRecent) && box.numRecent > 0 {
+		box.numRecent--
+	}
+	if !entry.ImapData.Flags.HasFlags(types.FlagSeen) && box.numUnseen > 0 {
+		box.numUnseen--
+	}
+}
+
+// update applies a change to the uids, statistics and search index of the
+// mailbox. It must be called while the lock of box is held.
+func (box *mailbox) update(c *change) {
+	i, wasIn := box.uids.find(c.uid)
+	isIn := c.current != nil && (box.sub == nil || box.sub(c.current))
+
+	if wasIn {
+		if c.old != nil {
+			box.removeMailboxStats(c.old)
+		}
+		if box.index != nil {
+			box.index.remove(c.uid)
+		}
+
+		if !isIn {
+			copy(box.uids[i:], box.uids[i+1:])
+			box.uids = box.uids[:len(box.uids)-1]
+			delete(box.objects, c.uid)
+		}
+	}
+
+	if isIn {
+		box.updateMailboxStats(c.current, c.uid)
+		if box.index != nil {
+			box.index.add(c.uid, c.current)
+		}
+
+		// The uids are shifted in place, so that the slice is only
+		// reallocated when it runs out of capacity.
+		if !wasIn {
+			box.uids = append(box.uids, 0)
+			copy(box.uids[i+1:], box.uids[i:])
+			box.uids[i] = c.uid
+		}
+	}
+
+	box.nextUID = uint32(box.mbox.NextID())
+	box.notify()
+}
+
+// load populates the uids and statistics of the mailbox. If the statistics
+// were saved when the mailbox was last closed, the messages don't have to
+// be read. Otherwise, the mailbox is refreshed.
+func (box *mailbox) load() error {
+	if box.sub != nil {
+		return box.refresh()
+	}
+
+	b, err := box.mbox.Metadata()
+	if err != nil || b == nil {
+		return box.refresh()
+	}
+
+	// The statistics are removed once they are read so that they are not
+	// trusted if bmagent stops without closing the mailbox.
+	err = box.mbox.SetMetadata(nil)
+	if err != nil {
+		return err
+	}
+
+	stats := &metadata.Folder{}
+	err = proto.Unmarshal(b, stats)
+	if err != nil {
+		return box.refresh()
+	}
+
+	// As in refresh, messages of every encoding are included.
+	ids, err := box.mbox.IDs(anyEncoding)
+	if err != nil {
+		return err
+	}
+
+	if stats.NextUid != box.mbox.NextID() || stats.Messages != uint32(len(ids)) {
+		return box.refresh()
+	}
+
+	box.uids = ids
+	box.numRecent = stats.Recent
+	box.numUnseen = stats.Unseen
+	box.nextUID = uint32(stats.NextUid)
+	return nil
+}
+
+// close saves the statistics of the mailbox in its folder so that they can
+// be loaded the next time that the mailbox is opened.
+func (box *mailbox) close() error {
+	// Virtual mailboxes don't have a folder of their own.
+	if box.sub != nil {
+		return nil
+	}
+
+	box.RLock()
+	defer box.RUnlock()
+
+	b, err := proto.Marshal(&metadata.Folder{
+		NextUid:  box.mbox.NextID(),
+		Messages: box.messages(),
+		Recent:   box.numRecent,
+		Unseen:   box.numUnseen,
+	})
+	if err != nil {
+		return err
+	}
+
+	return box.mbox.SetMetadata(b)
+}
+
+// getSearchIndex returns the search index of the mailbox, which is built the
+// first time it is needed. It must be called while the lock of box is held,
+// for reading or for writing.
+func (box *mailbox) getSearchIndex() *searchIndex {
+	box.indexMtx.Lock()
+	defer box.indexMtx.Unlock()
+
+	if box.index != nil {
+		return box.index
+	}
+
+	index := newSearchIndex()
+	err := box.mbox.ForEachMessage(0, 0, anyEncoding, func(id, suffix uint64, msg []byte) error {
+		if _, ok := box.uids.find(id); !ok {
+			return nil
+		}
+
+		b, _, err := decodeBitmessage(msg)
+		if err != nil {
+			email.IMAPLog.Errorf("Failed to decode message #%d: %v", id, err)
+			return nil
+		}
+
+		index.add(id, b)
+		return nil
+	})
+	if err != nil {
+		// Try again next time.
+		email.IMAPLog.Errorf("Mailbox(%s) could not build search index: %v",
+			box.Name(), err)
+		return index
+	}
+
+	box.index = index
+	return index
+}
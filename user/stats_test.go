@@ -0,0 +1,93 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestMailboxStats(t *testing.T) {
+	f, err := data.NewMemFolders().New(InboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(mb *mailbox, uids MessageSequence, recent, unseen uint32) {
+		if !reflect.DeepEqual(mb.uids, uids) {
+			t.Errorf("Expected uids %v, got %v", uids, mb.uids)
+		}
+		if mb.Recent() != recent {
+			t.Errorf("Expected %d recent messages, got %d", recent, mb.Recent())
+		}
+		if mb.Unseen() != unseen {
+			t.Errorf("Expected %d unseen messages, got %d", unseen, mb.Unseen())
+		}
+		if mb.NextUID() != uint32(f.NextID()) {
+			t.Errorf("Expected next uid %d, got %d", f.NextID(), mb.NextUID())
+		}
+	}
+
+	mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "a", "first"), types.FlagRecent)
+	mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "b", "second"), types.FlagSeen)
+	mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "c", "third"), types.FlagRecent)
+	check(mb, MessageSequence{1, 2, 3}, 2, 2)
+
+	// Replacing a message updates the statistics.
+	bm := mb.BitmessageByUID(3)
+	bm.ImapData.Flags = types.FlagSeen
+	mb.Lock()
+	err = mb.saveBitmessage(bm)
+	mb.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(mb, MessageSequence{1, 2, 3}, 1, 1)
+
+	mb.DeleteBitmessageByUID(1)
+	check(mb, MessageSequence{2, 3}, 0, 0)
+
+	// The statistics should be loaded without reading the messages when
+	// the mailbox is opened again.
+	if err := mb.close(); err != nil {
+		t.Fatal(err)
+	}
+	mb, err = newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(mb, MessageSequence{2, 3}, 0, 0)
+	if mb.index != nil {
+		t.Error("Expected the search index not to have been built.")
+	}
+	if md, _ := f.Metadata(); md != nil {
+		t.Error("Expected statistics to be removed from the folder once read.")
+	}
+
+	// The search index is built when it is needed.
+	uids := mb.Search(&email.SearchCriteria{Subject: []string{"c"}})
+	if !reflect.DeepEqual(uids, []uint64{3}) {
+		t.Errorf("Expected search result %v, got %v", []uint64{3}, uids)
+	}
+
+	// Saved statistics which don't match the folder are not used.
+	if err := mb.close(); err != nil {
+		t.Fatal(err)
+	}
+	mb.AddNew(MakeTestBitmessage("BM-From", "BM-To", "d", "fourth"), types.FlagRecent)
+	mb, err = newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(mb, MessageSequence{2, 3, 4}, 1, 1)
+}
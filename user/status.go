@@ -0,0 +1,19 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"github.com/DanielKrawisz/bmagent/user/email"
+)
+
+// showStatus makes the mailbox show the delivery status of its messages if
+// it is one of the mailboxes which hold messages that are being sent or that
+// have been sent.
+func (u *User) showStatus(mb *mailbox) {
+	switch mb.name {
+	case OutboxFolderName, LimboFolderName, SentFolderName:
+		mb.status = (*email.Bmail).StatusHeaders
+	}
+}
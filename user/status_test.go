@@ -0,0 +1,125 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/powmgr"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestStatusHeaders(t *testing.T) {
+	folders := data.NewMemFolders()
+	for _, name := range []string{InboxFolderName, SentFolderName} {
+		if _, err := folders.New(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := strings.Repeat("ab", 32)
+	sent := MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Hello", "Hello.")
+	sent.MessageID = "<" + hash + "@bm.addr>"
+	sent.State = &email.MessageState{
+		SendTries:   1,
+		LastSend:    time.Now(),
+		AckExpected: true,
+	}
+	if err := u.boxes[SentFolderName].AddNew(sent, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	received := MakeTestBitmessage("BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr",
+		"BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr", "Hello", "Hello.")
+	received.Verified = true
+	if err := u.boxes[InboxFolderName].AddNew(received, types.FlagRecent); err != nil {
+		t.Fatal(err)
+	}
+
+	header := u.boxes[SentFolderName].MessageByUID(1).Header()
+	expected := map[string]string{
+		"X-Bitmessage-State":          email.StateWaitAck,
+		"X-Bitmessage-Send-Tries":     "1",
+		"X-Bitmessage-Ack":            "expected",
+		"X-Bitmessage-Inventory-Hash": hash,
+	}
+	for key, value := range expected {
+		if got := header.Get(key); got != value {
+			t.Errorf("Expected %s: %s, got %q", key, value, got)
+		}
+	}
+
+	// Our own message was never decoded from the network.
+	if got := header.Get("X-Bitmessage-Sender-Verified"); got != "" {
+		t.Errorf("Expected no sender verification, got %q", got)
+	}
+
+	// Received messages are shown without them, but with whether the
+	// signature of the sender was verified.
+	header = u.boxes[InboxFolderName].MessageByUID(1).Header()
+	if got := header.Get("X-Bitmessage-State"); got != "" {
+		t.Errorf("Expected no delivery status in the Inbox, got %q", got)
+	}
+	if got := header.Get("X-Bitmessage-Sender-Verified"); got != "yes" {
+		t.Errorf("Expected the sender to be verified, got %q", got)
+	}
+}
+
+func TestPowFailed(t *testing.T) {
+	folders := data.NewMemFolders()
+	if _, err := folders.New(OutboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := NewUser("cosmos", emptyKeys{}, nil, folders, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox := u.boxes[OutboxFolderName]
+
+	bm := MakeTestBitmessage("BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ@bm.addr",
+		"BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8@bm.addr", "Hello", "Hello.")
+	bm.State = &email.MessageState{}
+	if err := outbox.AddNew(bm, types.FlagSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failure that is being retried is counted but the message is still
+	// waiting for proof-of-work.
+	u.powFailed(bm)(&powmgr.Failure{Attempts: 1, Retrying: true})
+	header := outbox.MessageByUID(uint32(bm.ImapData.UID)).Header()
+	if got := header.Get("X-Bitmessage-State"); got != email.StateWaitPow {
+		t.Errorf("Expected state %q, got %q", email.StateWaitPow, got)
+	}
+	if got := header.Get("X-Bitmessage-Pow-Failures"); got != "1" {
+		t.Errorf("Expected 1 failure, got %q", got)
+	}
+
+	// When the proof-of-work is given up, the message has failed.
+	failure := &powmgr.Failure{Attempts: 2, Retrying: false}
+	u.powFailed(bm)(failure)
+	saved := outbox.BitmessageByUID(bm.ImapData.UID)
+	if saved.State.PowFailures != 2 || saved.State.Error != failure.Error() {
+		t.Errorf("Expected 2 failures and error %q, got %d and %q",
+			failure.Error(), saved.State.PowFailures, saved.State.Error)
+	}
+	header = outbox.MessageByUID(uint32(bm.ImapData.UID)).Header()
+	if got := header.Get("X-Bitmessage-State"); got != email.StateFailed {
+		t.Errorf("Expected state %q, got %q", email.StateFailed, got)
+	}
+	if got := header.Get("X-Bitmessage-Error"); got != failure.Error() {
+		t.Errorf("Expected error %q, got %q", failure.Error(), got)
+	}
+}
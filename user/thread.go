@@ -0,0 +1,86 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"strings"
+
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/format"
+)
+
+// stripReply removes any number of "Re:" prefixes from a subject.
+func stripReply(subject string) (string, bool) {
+	reply := false
+	for {
+		subject = strings.TrimSpace(subject)
+		if len(subject) < 3 || !strings.EqualFold(subject[:3], "re:") {
+			return subject, reply
+		}
+		subject = subject[3:]
+		reply = true
+	}
+}
+
+// thread finds the message to which a message received in encoding 2 is a
+// reply. Such messages cannot carry threading headers, so the best we can do
+// is to look for the newest message that we sent to the sender with the same
+// subject.
+func (u *User) thread(bm *email.Bmail) {
+	if bm.InReplyTo != "" {
+		return
+	}
+	content, ok := bm.Content.(*format.Encoding2)
+	if !ok {
+		return
+	}
+	subject, reply := stripReply(content.Subject)
+	if !reply || subject == "" {
+		return
+	}
+
+	criteria := &email.SearchCriteria{
+		To:      []string{bm.From},
+		Subject: []string{subject},
+	}
+
+	var original *email.Bmail
+	for _, name := range []string{SentFolderName, LimboFolderName} {
+		box, err := u.mailbox(name)
+		if err != nil {
+			continue
+		}
+
+		for _, uid := range box.Search(criteria) {
+			sent := box.BitmessageByUID(uid)
+			if sent == nil || sent.MessageID == "" || sent.ImapData == nil {
+				continue
+			}
+
+			// The search matches substrings, so check the subject exactly.
+			var sentSubject string
+			switch c := sent.Content.(type) {
+			case *format.Encoding2:
+				sentSubject = c.Subject
+			case *email.Extended:
+				sentSubject = c.Subject
+			}
+			if s, _ := stripReply(sentSubject); s != subject {
+				continue
+			}
+
+			if original == nil || sent.ImapData.TimeReceived.After(original.ImapData.TimeReceived) {
+				original = sent
+			}
+		}
+	}
+
+	if original == nil {
+		return
+	}
+
+	bm.InReplyTo = original.MessageID
+	bm.References = append(append([]string(nil), original.References...), original.MessageID)
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/jordwest/imap-server/types"
+)
+
+func TestThread(t *testing.T) {
+	folders := data.NewMemFolders()
+	u := &User{
+		folders: folders,
+		boxes:   make(map[string]*mailbox),
+	}
+
+	for _, name := range []string{SentFolderName, LimboFolderName} {
+		f, err := folders.New(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u.boxes[name], err = newMailbox(name, f, make(map[string]string))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := "<" + strings.Repeat("ab", 32) + "@bm.addr>"
+	second := "<" + strings.Repeat("cd", 32) + "@bm.addr>"
+	third := "<" + strings.Repeat("ef", 32) + "@bm.addr>"
+
+	sent := func(box, to, subject, id string, references []string, received time.Time) {
+		bm := MakeTestBitmessage("bob@bm.addr", to, subject, "")
+		bm.MessageID = id
+		bm.References = references
+		mb := u.boxes[box]
+		if err := mb.AddNew(bm, types.FlagSeen); err != nil {
+			t.Fatal(err)
+		}
+		bm.ImapData.TimeReceived = received
+		mb.Lock()
+		err := mb.saveBitmessage(bm)
+		mb.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	sent(SentFolderName, "alice@bm.addr", "Lunch", first, nil, now.Add(-2*time.Hour))
+	sent(LimboFolderName, "alice@bm.addr", "Re: Lunch", second, []string{first}, now.Add(-time.Hour))
+	sent(SentFolderName, "alice@bm.addr", "Lunch tomorrow", third, nil, now)
+	sent(SentFolderName, "carol@bm.addr", "Lunch", third, nil, now)
+
+	bm := MakeTestBitmessage("alice@bm.addr", "bob@bm.addr", "RE: re: Lunch", "Yes.")
+	u.thread(bm)
+	if bm.InReplyTo != second {
+		t.Errorf("Expected In-Reply-To %s, got %s", second, bm.InReplyTo)
+	}
+	if expected := []string{first, second}; !reflect.DeepEqual(bm.References, expected) {
+		t.Errorf("Expected References %v, got %v", expected, bm.References)
+	}
+
+	// Messages which are not replies are left alone.
+	bm = MakeTestBitmessage("alice@bm.addr", "bob@bm.addr", "Lunch", "Yes.")
+	u.thread(bm)
+	if bm.InReplyTo != "" || bm.References != nil {
+		t.Errorf("Expected no threading, got %s and %v", bm.InReplyTo, bm.References)
+	}
+}
@@ -8,6 +8,7 @@ package user
 import (
 	"errors"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/DanielKrawisz/bmagent/idmgr/keys"
@@ -36,6 +37,9 @@ var (
 
 	// ErrMissingPrivateID is returned when the private id could not be found.
 	ErrMissingPrivateID = errors.New("Private id not found")
+
+	// ErrNoPowManager is returned when proof-of-work is not available.
+	ErrNoPowManager = errors.New("Proof-of-work is not available")
 )
 
 // ObjectExpiration returns the time duration after which an object of the
@@ -46,7 +50,9 @@ type ObjectExpiration func(wire.ObjectType) time.Duration
 // a collection of imap folders belonging to a single user.
 type User struct {
 	username string
+	folders  data.Folders
 	boxes    map[string]*mailbox
+	boxesMtx sync.RWMutex // Protects boxes when mailboxes are added or removed.
 
 	// A map from ack strings to message uids. When an ack is received,
 	// we mark off a message as having been received by the recipient.
@@ -59,6 +65,19 @@ type User struct {
 	// A proof-of-work manager.
 	pm     *powmgr.Pow
 	server ServerOps
+
+	// The proof-of-work orders for messages in the outbox, by uid.
+	orders    map[uint64]*powmgr.Order
+	ordersMtx sync.Mutex
+
+	// The greatest proof-of-work difficulties that are done for messages
+	// without the user's approval, or nil if there are none.
+	limits    *PowLimits
+	limitsMtx sync.RWMutex
+
+	// Whether virtual folders for identities, chans and subscriptions
+	// are shown.
+	virtual bool
 }
 
 // NewUser creates a User object from the store.
@@ -68,11 +87,15 @@ func NewUser(username string, privateIds keys.Manager, expiration ObjectExpirati
 	folderNames := folders.Names()
 
 	u := &User{
-		username: username,
-		boxes:    make(map[string]*mailbox),
-		server:   server,
-		keys:     privateIds,
-		acks:     make(map[hash.Sha]uint64),
+		username:   username,
+		folders:    folders,
+		boxes:      make(map[string]*mailbox),
+		server:     server,
+		keys:       privateIds,
+		acks:       make(map[hash.Sha]uint64),
+		expiration: expiration,
+		pm:         pm,
+		orders:     make(map[uint64]*powmgr.Order),
 	}
 	email.IMAPLog.Tracef("User created with folders %v", folderNames)
 
@@ -87,25 +110,26 @@ func NewUser(username string, privateIds keys.Manager, expiration ObjectExpirati
 		switch name {
 		case DraftsFolderName:
 			mb, err = newDrafts(name, folder, u.keys.Names())
+		case OutboxFolderName:
+			mb, err = newOutbox(name, folder, u.keys.Names(), u.send, u.cancelPow)
 		default:
 			mb, err = newMailbox(name, folder, u.keys.Names())
 		}
 		if err != nil {
 			return nil, err
 		}
+		u.showStatus(mb)
 		u.boxes[name] = mb
 	}
 
 	return u, nil
 }
 
-// NewMailbox adds a new mailbox.
-func (u *User) NewMailbox(name string) (email.Mailbox, error) {
-	return nil, errors.New("Not yet implemented.")
-}
-
 // Mailboxes returns all the mailboxes. It is part of the IMAPMailbox interface.
 func (u *User) Mailboxes() []mailstore.Mailbox {
+	u.boxesMtx.RLock()
+	defer u.boxesMtx.RUnlock()
+
 	mboxes := make([]mailstore.Mailbox, 0, len(u.boxes))
 	for _, mbox := range u.boxes {
 		mboxes = append(mboxes, mbox)
@@ -122,18 +146,72 @@ func (u *User) MailboxByName(name string) (mailstore.Mailbox, error) {
 		name = InboxFolderName
 	}
 
+	mbox, err := u.mailbox(name)
+	if err != nil {
+		return nil, errors.New("Not found")
+	}
+	return mbox, nil
+}
+
+// mailbox returns the mailbox with the given name. Mailboxes may be added and
+// removed at any time, so they must always be looked up with it unless the
+// lock for the set of mailboxes is held already.
+func (u *User) mailbox(name string) (*mailbox, error) {
+	u.boxesMtx.RLock()
+	defer u.boxesMtx.RUnlock()
+
 	mbox, ok := u.boxes[name]
 	if !ok {
-		return nil, errors.New("Not found")
+		return nil, ErrMailboxNotFound
 	}
 	return mbox, nil
 }
 
+// Close saves the statistics of every mailbox so that the mailboxes can be
+// opened again without reading all of their messages.
+func (u *User) Close() error {
+	u.boxesMtx.RLock()
+	defer u.boxesMtx.RUnlock()
+
+	for _, mbox := range u.boxes {
+		if err := mbox.close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // DeliverFromBMNet adds a message received from bmd into the appropriate
 // folder.
 func (u *User) DeliverFromBMNet(bm *email.Bmail) error {
+	u.thread(bm)
+
 	// Put message in the right folder.
-	return u.boxes[InboxFolderName].AddNew(bm, types.FlagRecent)
+	inbox, err := u.mailbox(InboxFolderName)
+	if err != nil {
+		return err
+	}
+	return inbox.AddNew(bm, types.FlagRecent)
+}
+
+// DeliverQuarantined adds a message received over the bitmessage network
+// which did not meet the proof-of-work required of it to the Quarantine
+// folder, which is created if it does not exist.
+func (u *User) DeliverQuarantined(bm *email.Bmail) error {
+	u.boxesMtx.Lock()
+	mbox, ok := u.boxes[QuarantineFolderName]
+	if !ok {
+		var err error
+		mbox, err = u.newMailbox(QuarantineFolderName)
+		if err != nil {
+			u.boxesMtx.Unlock()
+			return err
+		}
+	}
+	u.boxesMtx.Unlock()
+
+	return mbox.AddNew(bm, types.FlagRecent)
 }
 
 // DeliverFromSMTP adds a message received via SMTP to the POW queue, if needed,
@@ -152,15 +230,8 @@ func (u *User) DeliverFromSMTP(smtp *smtp.Content) error {
 		return u.executeCommand(bmsg.From, smtp.Headers["Subject"][0], smtp.Body)
 	}
 
-	outbox := u.boxes[OutboxFolderName]
-
-	// Put message in outbox.
-	err = outbox.AddNew(bmsg, types.FlagSeen)
-	if err != nil {
-		return nil
-	}
-
-	return u.process(bmsg)
+	_, err = u.send(bmsg)
+	return err
 }
 
 // DeliverPublic takes a public key and attempts to match it with a message.
@@ -174,11 +245,14 @@ func (u *User) DeliverPublic(bmaddr string, public identity.Public) error {
 		return errors.New("Bitmessage address required.")
 	}
 
-	outbox := u.boxes[OutboxFolderName]
+	outbox, err := u.mailbox(OutboxFolderName)
+	if err != nil {
+		return err
+	}
 	var bms []*email.Bmail
 
 	// Go through all messages in the Outbox and get IDs of all the matches.
-	err := outbox.mbox.ForEachMessage(0, 0, 2, func(id, _ uint64, msg []byte) error {
+	err = outbox.mbox.ForEachMessage(0, 0, anyEncoding, func(id, _ uint64, msg []byte) error {
 		bmsg, _, err := decodeBitmessage(msg)
 		if err != nil { // (Almost) impossible error.
 			return err
@@ -205,8 +279,14 @@ func (u *User) DeliverPublic(bmaddr string, public identity.Public) error {
 
 // Move finds a email.Bmail in one mailbox and moves it to another.
 func (u *User) Move(bmsg *email.Bmail, from, to string) error {
-	fromBox := u.boxes[from]
-	toBox := u.boxes[to]
+	fromBox, err := u.mailbox(from)
+	if err != nil {
+		return err
+	}
+	toBox, err := u.mailbox(to)
+	if err != nil {
+		return err
+	}
 
 	uid := bmsg.ImapData.UID
 
@@ -216,7 +296,7 @@ func (u *User) Move(bmsg *email.Bmail, from, to string) error {
 	}
 
 	// Move message from old mailbox to the new one.
-	err := fromBox.DeleteBitmessageByUID(uid)
+	err = fromBox.DeleteBitmessageByUID(uid)
 	if err != nil {
 		return err
 	}
@@ -233,7 +313,11 @@ func (u *User) DeliverAckReply(hash *hash.Sha) error {
 		return ErrUnrecognizedAck
 	}
 
-	bmsg := u.boxes[LimboFolderName].bmsgByUID(uid)
+	limbo, err := u.mailbox(LimboFolderName)
+	if err != nil {
+		return err
+	}
+	bmsg := limbo.bmsgByUID(uid)
 
 	// Move the message to the sent folder.
 	if bmsg != nil {
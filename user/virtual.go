@@ -0,0 +1,210 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"errors"
+
+	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil"
+	"github.com/DanielKrawisz/bmutil/wire/obj"
+)
+
+// newVirtualMailbox returns a mailbox which shows a subset of the messages
+// in another mailbox. The messages are stored only once, in the folder of
+// the parent mailbox, and appear in the virtual mailbox if sub returns true
+// for them.
+func newVirtualMailbox(name string, parent *mailbox, sub func(*email.Bmail) bool) (*mailbox, error) {
+	if parent == nil {
+		return nil, errors.New("Nil mailbox.")
+	}
+
+	m := &mailbox{
+		mbox:      parent.mbox,
+		addresses: parent.addresses,
+		status:    parent.status,
+		name:      name,
+		sub:       sub,
+		objects:   make(map[uint64]obj.Object),
+	}
+
+	// Populate various data fields.
+	if err := m.refresh(); err != nil {
+		return nil, err
+	}
+
+	// Every mailbox which uses the same folder must be updated when
+	// any of them is changed.
+	for _, l := range parent.linked {
+		l.linked = append(l.linked, m)
+		m.linked = append(m.linked, l)
+	}
+	parent.linked = append(parent.linked, m)
+	m.linked = append(m.linked, parent)
+
+	return m, nil
+}
+
+// updateLinked applies a change to all mailboxes that share a folder with
+// this one. Nothing is done if c is nil. It must not be called while the
+// lock of box is held.
+func (box *mailbox) updateLinked(c *change) {
+	if c == nil {
+		return
+	}
+
+	for _, l := range box.linked {
+		l.Lock()
+		l.update(c)
+		l.Unlock()
+	}
+}
+
+// toAddress returns a function which selects the messages sent to the given
+// Bitmessage address. Broadcasts have the address of their sender in To, so
+// they are left out.
+func toAddress(addr string) func(*email.Bmail) bool {
+	to := email.BmToEmail(addr)
+	return func(bm *email.Bmail) bool {
+		return bm.From != email.Broadcast && bm.To == to
+	}
+}
+
+// fromSubscription returns a function which selects the broadcasts sent by
+// the given Bitmessage address.
+func fromSubscription(addr string) func(*email.Bmail) bool {
+	from := email.BmToEmail(addr)
+	return func(bm *email.Bmail) bool {
+		return bm.From == email.Broadcast && bm.To == from
+	}
+}
+
+// toIdentities returns a function which selects the messages sent to any of
+// the private identities in km which are chans if chans is true, and which
+// are not chans otherwise.
+func toIdentities(km keys.Manager, chans bool) func(*email.Bmail) bool {
+	return func(bm *email.Bmail) bool {
+		if bm.From == email.Broadcast {
+			return false
+		}
+
+		addr, err := email.ToBm(bm.To)
+		if err != nil {
+			return false
+		}
+
+		id := km.Get(addr)
+		return id != nil && id.IsChan == chans
+	}
+}
+
+// isBroadcast selects all broadcasts.
+func isBroadcast(bm *email.Bmail) bool {
+	return bm.From == email.Broadcast
+}
+
+// addVirtualMailbox adds a virtual mailbox showing the messages in the Inbox
+// for which sub returns true. Nothing is done if the mailbox already exists.
+func (u *User) addVirtualMailbox(name string, sub func(*email.Bmail) bool) error {
+	u.boxesMtx.Lock()
+	defer u.boxesMtx.Unlock()
+
+	if _, ok := u.boxes[name]; ok {
+		return nil
+	}
+
+	inbox, ok := u.boxes[InboxFolderName]
+	if !ok {
+		return ErrMailboxNotFound
+	}
+
+	mb, err := newVirtualMailbox(name, inbox, sub)
+	if err != nil {
+		return err
+	}
+
+	u.boxes[name] = mb
+	return nil
+}
+
+// addIdentityMailbox adds a virtual mailbox for the given private identity.
+// Chans go in the Chans folder and all other identities go in the
+// Identities folder.
+func (u *User) addIdentityMailbox(addr string) error {
+	id := u.keys.Get(addr)
+	if id == nil {
+		return ErrMissingPrivateID
+	}
+
+	name := id.Name
+	if name == "" {
+		name = addr
+	}
+
+	if id.IsChan {
+		return u.addVirtualMailbox(ChansFolderPrefix+name, toAddress(addr))
+	}
+	return u.addVirtualMailbox(IdentitiesFolderPrefix+name, toAddress(addr))
+}
+
+// AddSubscriptionMailbox adds a virtual mailbox for broadcasts from the given
+// address. It does nothing unless virtual folders have been enabled.
+func (u *User) AddSubscriptionMailbox(addr string) error {
+	if !u.virtual {
+		return nil
+	}
+
+	return u.addVirtualMailbox(SubscriptionsFolderPrefix+addr, fromSubscription(addr))
+}
+
+// Subscribe starts listening for broadcasts from the given address and adds
+// a virtual mailbox for them if virtual folders are enabled.
+func (u *User) Subscribe(address string) error {
+	addr, err := bmutil.DecodeAddress(address)
+	if err != nil {
+		return err
+	}
+	address = addr.String()
+
+	if err := u.server.Subscribe(address); err != nil {
+		return err
+	}
+
+	return u.AddSubscriptionMailbox(address)
+}
+
+// EnableVirtualFolders creates a virtual folder for each private identity and
+// chan of the user, and one for each broadcast address that the user is
+// subscribed to. The messages in these folders are the messages in the Inbox
+// which were sent to the given address. The folders containing them are
+// virtual folders too, which show the messages in all of their subfolders.
+func (u *User) EnableVirtualFolders(broadcasts []string) error {
+	u.virtual = true
+
+	if err := u.addVirtualMailbox(IdentitiesFolderName, toIdentities(u.keys, false)); err != nil {
+		return err
+	}
+	if err := u.addVirtualMailbox(ChansFolderName, toIdentities(u.keys, true)); err != nil {
+		return err
+	}
+	if err := u.addVirtualMailbox(SubscriptionsFolderName, isBroadcast); err != nil {
+		return err
+	}
+
+	for addr := range u.keys.Names() {
+		if err := u.addIdentityMailbox(addr); err != nil {
+			return err
+		}
+	}
+
+	for _, addr := range broadcasts {
+		if err := u.AddSubscriptionMailbox(addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2016 Daniel Krawisz.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/DanielKrawisz/bmagent/idmgr/keys"
+	"github.com/DanielKrawisz/bmagent/store/data"
+	"github.com/DanielKrawisz/bmagent/user/email"
+	"github.com/DanielKrawisz/bmutil/identity"
+	"github.com/jordwest/imap-server/types"
+)
+
+// namedKeys is a keys.Manager whose keys have names but no private keys.
+type namedKeys map[string]*keys.PrivateID
+
+func (k namedKeys) Get(address string) *keys.PrivateID { return k[address] }
+
+func (namedKeys) New(name string, stream uint64, behavior uint32) *keys.PrivateID {
+	return nil
+}
+
+func (namedKeys) NewUnnamed(stream uint64, behavior uint32) *keys.PrivateID {
+	return nil
+}
+
+func (k namedKeys) Names() map[string]string {
+	names := make(map[string]string)
+	for addr, id := range k {
+		names[addr] = id.Name
+	}
+	return names
+}
+
+// subscribeOps is a ServerOps which only records subscriptions.
+type subscribeOps struct {
+	subscriptions []string
+}
+
+func (*subscribeOps) GetOrRequestPublicID(string) (identity.Public, error) {
+	return nil, nil
+}
+
+func (*subscribeOps) Send(obj []byte) {}
+
+func (*subscribeOps) Publish() {}
+
+func (s *subscribeOps) Subscribe(address string) error {
+	s.subscriptions = append(s.subscriptions, address)
+	return nil
+}
+
+func TestVirtualMailbox(t *testing.T) {
+	addrA := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+	addrB := "BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8"
+
+	f, err := data.NewMemFolders().New(InboxFolderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbox, err := newMailbox(InboxFolderName, f, make(map[string]string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a message before the virtual mailboxes are created.
+	err = inbox.AddNew(MakeTestBitmessage("BM-From", email.BmToEmail(addrA),
+		"a", "first message to A"), types.FlagRecent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boxA, err := newVirtualMailbox(IdentitiesFolderPrefix+"A", inbox, toAddress(addrA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	boxB, err := newVirtualMailbox(IdentitiesFolderPrefix+"B", inbox, toAddress(addrB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subA, err := newVirtualMailbox(SubscriptionsFolderPrefix+addrA, inbox, fromSubscription(addrA))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages delivered to the inbox should appear in the virtual mailboxes.
+	inbox.AddNew(MakeTestBitmessage("BM-From", email.BmToEmail(addrA),
+		"a", "second message to A"), types.FlagRecent)
+	inbox.AddNew(MakeTestBitmessage("BM-From", email.BmToEmail(addrB),
+		"b", "message to B"), types.FlagRecent)
+	inbox.AddNew(MakeTestBitmessage(email.Broadcast, email.BmToEmail(addrA),
+		"c", "broadcast from A"), types.FlagRecent)
+
+	check := func(box *mailbox, expected uint32) {
+		if box.Messages() != expected {
+			t.Errorf("Mailbox %s: expected %d messages, got %d", box.Name(),
+				expected, box.Messages())
+		}
+	}
+
+	// The broadcast from A appears in the subscription but not in the
+	// folder of identity A, even though its To field is A's address.
+	check(inbox, 4)
+	check(boxA, 2)
+	check(boxB, 1)
+	check(subA, 1)
+
+	// Deleting a message from a virtual mailbox removes it from the inbox.
+	err = boxB.DeleteBitmessageByUID(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check(inbox, 3)
+	check(boxA, 2)
+	check(boxB, 0)
+	check(subA, 1)
+
+	if boxB.LastUID() != boxB.NextUID() {
+		t.Errorf("Expected LastUID of empty mailbox to be %d, got %d",
+			boxB.NextUID(), boxB.LastUID())
+	}
+}
+
+func TestVirtualFolders(t *testing.T) {
+	addrA := "BM-2cV9RshwouuVKWLBoyH5cghj3kMfw5G7BJ"
+	addrB := "BM-2DB6AzjZvzM8NkS3HMYWMP9R1Rt778mhN8"
+	addrC := "BM-GtovgYdgs7qXPkoYaRgrLFuFKz1SFpsw"
+
+	folders := data.NewMemFolders()
+	if _, err := folders.New(InboxFolderName); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := namedKeys{
+		addrA: &keys.PrivateID{Name: "A"},
+		addrB: &keys.PrivateID{Name: "B", IsChan: true},
+	}
+	ops := &subscribeOps{}
+	u, err := NewUser("cosmos", ids, nil, folders, nil, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Subscribing before virtual folders are enabled creates no folder.
+	if err = u.Subscribe(addrC); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = u.mailbox(SubscriptionsFolderPrefix + addrC); err != ErrMailboxNotFound {
+		t.Errorf("Expected ErrMailboxNotFound, got %v", err)
+	}
+
+	if err = u.EnableVirtualFolders(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	inbox := u.boxes[InboxFolderName]
+	inbox.AddNew(MakeTestBitmessage("BM-From", email.BmToEmail(addrA),
+		"a", "message to A"), types.FlagRecent)
+	inbox.AddNew(MakeTestBitmessage("BM-From", email.BmToEmail(addrB),
+		"b", "message to B"), types.FlagRecent)
+	inbox.AddNew(MakeTestBitmessage(email.Broadcast, email.BmToEmail(addrC),
+		"c", "broadcast from C"), types.FlagRecent)
+
+	// Subscribing afterwards adds a folder for the new address.
+	if err = u.Subscribe(addrA); err != nil {
+		t.Fatal(err)
+	}
+	if len(ops.subscriptions) != 2 || ops.subscriptions[1] != addrA {
+		t.Errorf("Expected subscriptions [%s %s], got %v", addrC, addrA,
+			ops.subscriptions)
+	}
+
+	// The folders containing the virtual folders are themselves mailboxes.
+	for name, expected := range map[string]uint32{
+		IdentitiesFolderName:              1,
+		IdentitiesFolderPrefix + "A":      1,
+		ChansFolderName:                   1,
+		ChansFolderPrefix + "B":           1,
+		SubscriptionsFolderName:           1,
+		SubscriptionsFolderPrefix + addrA: 0,
+	} {
+		box, err := u.MailboxByName(name)
+		if err != nil {
+			t.Errorf("Mailbox %s: %v", name, err)
+			continue
+		}
+		if box.Messages() != expected {
+			t.Errorf("Mailbox %s: expected %d messages, got %d", name,
+				expected, box.Messages())
+		}
+	}
+}